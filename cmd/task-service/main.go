@@ -2,24 +2,40 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"taskapi/internal/app"
+	"taskapi/internal/config"
 	"time"
 )
 
 func main() {
-	c := app.NewContainer()
-	defer c.Logger.Stop()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("config loaded: %s", cfg)
+
+	c, err := app.NewContainer(cfg)
+	if err != nil {
+		log.Fatalf("init: %v", err)
+	}
+	defer c.Close()
 
 	srv := &http.Server{
 		Addr:              c.Config.HTTPPort,
 		Handler:           c.Router.Handler(),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
+	srv.RegisterOnShutdown(c.Events.Close)
 
 	go func() {
 		log.Printf("HTTP server listening on %s\n", srv.Addr)
@@ -28,10 +44,31 @@ func main() {
 		}
 	}()
 
+	// SIGHUP перечитывает конфигурацию и переоткрывает файлы журнала после
+	// внешнего logrotate. Неверная конфигурация не применяется целиком.
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			if next, err := config.Load(os.Args[1:]); err != nil {
+				log.Printf("reload config: %v", err)
+			} else if changes, err := c.Reload(next); err != nil {
+				log.Printf("reload config: %v", err)
+			} else {
+				log.Printf("config reloaded: applied %v, restart required for %v", changes.Reload, changes.Restart)
+			}
+			if err := c.Logger.Reopen(); err != nil {
+				log.Printf("reopen logs: %v", err)
+			}
+		}
+	}()
+
 	stopCh := make(chan os.Signal, 1)
 	signal.Notify(stopCh, syscall.SIGINT, syscall.SIGTERM)
 	<-stopCh
+	signal.Stop(hupCh)
 	log.Println("shutting down...")
+	c.Health.SetShuttingDown()
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Config.ShutdownTime)*time.Second)
 	defer cancel()
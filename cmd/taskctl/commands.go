@@ -0,0 +1,536 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"taskapi/pkg/client"
+)
+
+type env struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	profile string
+	server  string
+	output  string
+	timeout time.Duration
+
+	// describe — режим автодополнения: команда только регистрирует флаги
+	// в fs и сразу возвращает errDescribe.
+	describe bool
+	fs       *flag.FlagSet
+}
+
+var errDescribe = errors.New("describe")
+
+type command func(e *env, args []string) error
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"create":     cmdCreate,
+		"get":        cmdGet,
+		"list":       cmdList,
+		"update":     cmdUpdate,
+		"delete":     cmdDelete,
+		"export":     cmdExport,
+		"import":     cmdImport,
+		"profile":    cmdProfile,
+		"completion": cmdCompletion,
+	}
+}
+
+// newFlags создает набор флагов команды вместе с общими флагами.
+func (e *env) newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("taskctl "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.StringVar(&e.profile, "profile", "", "профиль из конфигурации")
+	fs.StringVar(&e.server, "server", "", "адрес сервиса")
+	fs.StringVar(&e.output, "output", "", "формат вывода: table, json, yaml")
+	fs.StringVar(&e.output, "o", "", "короткая форма --output")
+	fs.DurationVar(&e.timeout, "timeout", 30*time.Second, "таймаут запроса")
+	e.fs = fs
+	return fs
+}
+
+// parse разбирает флаги вперемешку с позиционными аргументами:
+// `taskctl get ID -o json` работает так же, как `taskctl get -o json ID`.
+func (e *env) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	if e.describe {
+		e.fs = fs
+		return nil, errDescribe
+	}
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos, nil
+		}
+		if args[0] == "--" {
+			return append(pos, args[1:]...), nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+// client собирает клиент по флагам, переменным окружения и профилю.
+func (e *env) client() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	p, err := cfg.resolve(e.profile)
+	if err != nil {
+		return nil, err
+	}
+	server := e.server
+	if server == "" {
+		server = os.Getenv("TASKCTL_SERVER")
+	}
+	if server == "" {
+		server = p.Server
+	}
+	if server == "" {
+		server = defaultServer
+	}
+	if e.output == "" {
+		e.output = p.Output
+	}
+	if e.output == "" {
+		e.output = "table"
+	}
+	if !validOutput(e.output) {
+		return nil, usagef("неизвестный формат вывода %q (допустимы: %s)", e.output, strings.Join(outputFormats, ", "))
+	}
+	c, err := client.New(server, client.WithUserAgent("taskctl"))
+	if err != nil {
+		return nil, usageError{msg: err.Error()}
+	}
+	return c, nil
+}
+
+func (e *env) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(e.ctx, e.timeout)
+}
+
+func cmdCreate(e *env, args []string) error {
+	fs := e.newFlags("create")
+	var in client.CreateTaskInput
+	var status string
+	fs.StringVar(&in.Title, "title", "", "название (обязательно)")
+	fs.StringVar(&in.Description, "description", "", "описание")
+	fs.StringVar(&status, "status", "", "статус: todo, in_progress, done")
+	fs.StringVar(&in.Project, "project", "", "проект")
+	fs.StringVar(&in.Assignee, "assignee", "", "исполнитель")
+	fs.StringVar(&in.ParentID, "parent", "", "ID родительской задачи")
+	pos, err := e.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if in.Title == "" && len(pos) > 0 {
+		in.Title = strings.Join(pos, " ")
+	}
+	if in.Title == "" {
+		return usagef("нужен --title")
+	}
+	in.Status = client.Status(status)
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	t, err := c.CreateTask(ctx, in)
+	if err != nil {
+		return err
+	}
+	return printTasks(e.stdout, e.output, []client.Task{t}, true)
+}
+
+func cmdGet(e *env, args []string) error {
+	fs := e.newFlags("get")
+	ids, err := e.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usagef("нужен хотя бы один ID")
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	tasks := make([]client.Task, 0, len(ids))
+	for _, id := range ids {
+		t, err := c.GetTask(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		tasks = append(tasks, t)
+	}
+	return printTasks(e.stdout, e.output, tasks, len(ids) == 1)
+}
+
+func listFlags(fs *flag.FlagSet, o *client.ListOptions) *string {
+	status := fs.String("status", "", "статус: todo, in_progress, done")
+	fs.StringVar(&o.Project, "project", "", "проект")
+	fs.StringVar(&o.Assignee, "assignee", "", "исполнитель")
+	fs.StringVar(&o.ParentID, "parent", "", "ID родительской задачи")
+	return status
+}
+
+func cmdList(e *env, args []string) error {
+	fs := e.newFlags("list")
+	var opts client.ListOptions
+	status := listFlags(fs, &opts)
+	if _, err := e.parse(fs, args); err != nil {
+		return err
+	}
+	opts.Status = client.Status(*status)
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	tasks, err := c.ListTasks(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return printTasks(e.stdout, e.output, tasks, false)
+}
+
+func cmdUpdate(e *env, args []string) error {
+	fs := e.newFlags("update")
+	fs.String("title", "", "новое название")
+	fs.String("description", "", "новое описание")
+	fs.String("status", "", "новый статус")
+	fs.String("project", "", "новый проект")
+	fs.String("assignee", "", "новый исполнитель")
+	fs.String("parent", "", "новый родитель (пустая строка — отвязать)")
+	pos, err := e.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usagef("нужен ровно один ID")
+	}
+
+	// Меняются только явно переданные флаги.
+	var in client.UpdateTaskInput
+	changed := 0
+	fs.Visit(func(f *flag.Flag) {
+		v := f.Value.String()
+		switch f.Name {
+		case "title":
+			in.Title = &v
+		case "description":
+			in.Description = &v
+		case "status":
+			in.Status = client.Ptr(client.Status(v))
+		case "project":
+			in.Project = &v
+		case "assignee":
+			in.Assignee = &v
+		case "parent":
+			in.ParentID = &v
+		default:
+			return
+		}
+		changed++
+	})
+	if changed == 0 {
+		return usagef("не указано ни одного изменения")
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	t, err := c.UpdateTask(ctx, pos[0], in)
+	if err != nil {
+		return err
+	}
+	return printTasks(e.stdout, e.output, []client.Task{t}, true)
+}
+
+func cmdDelete(e *env, args []string) error {
+	fs := e.newFlags("delete")
+	ids, err := e.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usagef("нужен хотя бы один ID")
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	for _, id := range ids {
+		if err := c.DeleteTask(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		fmt.Fprintf(e.stderr, "удалена %s\n", id)
+	}
+	return nil
+}
+
+// cmdExport выгружает задачи JSON-массивом в файл или stdout. Формат всегда
+// JSON — его читает import; формат вывода из профиля не учитывается.
+func cmdExport(e *env, args []string) error {
+	fs := e.newFlags("export")
+	var opts client.ListOptions
+	status := listFlags(fs, &opts)
+	file := fs.String("file", "", "файл для записи (по умолчанию stdout)")
+	if _, err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if e.output != "" && e.output != "json" {
+		return usagef("export пишет только JSON, который читает import (получено -o %s)", e.output)
+	}
+	opts.Status = client.Status(*status)
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	tasks, err := c.ListTasks(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if tasks == nil {
+		tasks = []client.Task{}
+	}
+
+	w := e.stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := printJSON(w, tasks); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "выгружено задач: %d\n", len(tasks))
+	return nil
+}
+
+// cmdImport создает задачи из JSON-массива (формат export). Сервис выдает
+// новые ID, поэтому ссылки parent_id переназначаются: родители создаются
+// раньше подзадач.
+func cmdImport(e *env, args []string) error {
+	fs := e.newFlags("import")
+	file := fs.String("file", "-", "файл с задачами (- — stdin)")
+	dryRun := fs.Bool("dry-run", false, "только проверить файл")
+	if _, err := e.parse(fs, args); err != nil {
+		return err
+	}
+	r := e.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var tasks []client.Task
+	if err := json.NewDecoder(r).Decode(&tasks); err != nil {
+		return usagef("разбор файла: %v", err)
+	}
+	ordered, err := parentsFirst(tasks)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Fprintf(e.stderr, "задач к импорту: %d\n", len(ordered))
+		return nil
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := e.context()
+	defer cancel()
+	newIDs := make(map[string]string, len(ordered))
+	created := make([]client.Task, 0, len(ordered))
+	for _, t := range ordered {
+		in := client.CreateTaskInput{
+			Title:       t.Title,
+			Description: t.Description,
+			Status:      t.Status,
+			Project:     t.Project,
+			Assignee:    t.Assignee,
+		}
+		if t.ParentID != "" {
+			in.ParentID = newIDs[t.ParentID]
+			if in.ParentID == "" {
+				// Родитель не входит в файл — ссылка на уже существующую задачу.
+				in.ParentID = t.ParentID
+			}
+		}
+		nt, err := c.CreateTask(ctx, in)
+		if err != nil {
+			return fmt.Errorf("импорт %q (создано %d из %d): %w", t.Title, len(created), len(ordered), err)
+		}
+		if t.ID != "" {
+			newIDs[t.ID] = nt.ID
+		}
+		created = append(created, nt)
+	}
+	fmt.Fprintf(e.stderr, "импортировано задач: %d\n", len(created))
+	return printTasks(e.stdout, e.output, created, false)
+}
+
+// parentsFirst упорядочивает задачи так, чтобы родитель из того же файла
+// шел раньше подзадач; цикл в ссылках — ошибка.
+func parentsFirst(tasks []client.Task) ([]client.Task, error) {
+	byID := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		if t.ID != "" {
+			byID[t.ID] = i
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(tasks))
+	out := make([]client.Task, 0, len(tasks))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return usagef("цикл в parent_id у задачи %s", tasks[i].ID)
+		}
+		state[i] = visiting
+		if p, ok := byID[tasks[i].ParentID]; ok && tasks[i].ParentID != "" {
+			if err := visit(p); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		out = append(out, tasks[i])
+		return nil
+	}
+	for i := range tasks {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func cmdProfile(e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("profile: нужна подкоманда list, set, use или remove")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "list":
+		for _, name := range cfg.names() {
+			mark := " "
+			if name == cfg.Current {
+				mark = "*"
+			}
+			p := cfg.Profiles[name]
+			fmt.Fprintf(e.stdout, "%s %s\t%s\t%s\n", mark, name, p.Server, dash(p.Output))
+		}
+		return nil
+	case "set":
+		fs := flag.NewFlagSet("taskctl profile set", flag.ContinueOnError)
+		fs.SetOutput(e.stderr)
+		server := fs.String("server", "", "адрес сервиса")
+		output := fs.String("output", "", "формат вывода по умолчанию")
+		pos, err := e.parse(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(pos) != 1 {
+			return usagef("profile set NAME --server URL [--output FMT]")
+		}
+		p := cfg.Profiles[pos[0]]
+		if p == nil {
+			p = &Profile{}
+			cfg.Profiles[pos[0]] = p
+		}
+		if *server != "" {
+			if _, err := client.New(*server); err != nil {
+				return usageError{msg: err.Error()}
+			}
+			p.Server = *server
+		}
+		if *output != "" {
+			if !validOutput(*output) {
+				return usagef("неизвестный формат вывода %q", *output)
+			}
+			p.Output = *output
+		}
+		if p.Server == "" {
+			return usagef("для нового профиля нужен --server")
+		}
+		if cfg.Current == "" {
+			cfg.Current = pos[0]
+		}
+		return cfg.save()
+	case "use":
+		if len(args) != 1 {
+			return usagef("profile use NAME")
+		}
+		if _, ok := cfg.Profiles[args[0]]; !ok {
+			return usagef("профиль %q не найден", args[0])
+		}
+		cfg.Current = args[0]
+		return cfg.save()
+	case "remove":
+		if len(args) != 1 {
+			return usagef("profile remove NAME")
+		}
+		if _, ok := cfg.Profiles[args[0]]; !ok {
+			return usagef("профиль %q не найден", args[0])
+		}
+		delete(cfg.Profiles, args[0])
+		if cfg.Current == args[0] {
+			cfg.Current = ""
+		}
+		return cfg.save()
+	default:
+		return usagef("profile: неизвестная подкоманда %q", sub)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Скрипты только передают слова командной строки в `taskctl __complete`,
+// варианты вычисляет сам taskctl — так они не расходятся с флагами команд.
+const (
+	bashCompletion = `# bash: source <(taskctl completion bash)
+_taskctl() {
+	local IFS=$'\n'
+	COMPREPLY=($(taskctl __complete "${COMP_WORDS[@]:0:COMP_CWORD+1}" 2>/dev/null))
+}
+complete -o default -F _taskctl taskctl
+`
+	zshCompletion = `#compdef taskctl
+# zsh: source <(taskctl completion zsh)
+_taskctl() {
+	local -a candidates
+	candidates=(${(f)"$(taskctl __complete "${(@)words[1,CURRENT]}" 2>/dev/null)"})
+	compadd -a candidates
+}
+compdef _taskctl taskctl
+`
+	fishCompletion = `# fish: taskctl completion fish | source
+complete -c taskctl -f -a '(taskctl __complete (commandline -opc) (commandline -ct) 2>/dev/null)'
+`
+)
+
+var valueCompletions = map[string][]string{
+	"status": {"todo", "in_progress", "done"},
+	"output": outputFormats,
+	"o":      outputFormats,
+}
+
+func cmdCompletion(e *env, args []string) error {
+	if len(args) != 1 {
+		return usagef("completion bash|zsh|fish")
+	}
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return usagef("неизвестная оболочка %q", args[0])
+	}
+	_, err := io.WriteString(e.stdout, script)
+	return err
+}
+
+// cmdComplete печатает варианты для последнего слова; первое слово — имя
+// программы.
+func cmdComplete(e *env, args []string) error {
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	cur := args[len(args)-1]
+	for _, c := range completions(args[:len(args)-1], cur) {
+		if strings.HasPrefix(c, cur) {
+			fmt.Fprintln(e.stdout, c)
+		}
+	}
+	return nil
+}
+
+func completions(prev []string, cur string) []string {
+	if len(prev) == 0 {
+		names := []string{"help"}
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	name := prev[0]
+	cmd, ok := commands[name]
+	if !ok {
+		return nil
+	}
+	last := prev[len(prev)-1]
+	switch name {
+	case "completion":
+		if len(prev) == 1 {
+			return []string{"bash", "zsh", "fish"}
+		}
+		return nil
+	case "profile":
+		if len(prev) == 1 {
+			return []string{"list", "set", "use", "remove"}
+		}
+		if len(prev) == 2 && (prev[1] == "use" || prev[1] == "remove" || prev[1] == "set") {
+			return profileNames()
+		}
+		return nil
+	}
+
+	fs := describeFlags(cmd)
+	if fs == nil {
+		return nil
+	}
+	if strings.HasPrefix(last, "-") && !strings.Contains(last, "=") && len(prev) > 1 {
+		flagName := strings.TrimLeft(last, "-")
+		if f := fs.Lookup(flagName); f != nil && !isBoolFlag(f) {
+			if flagName == "profile" {
+				return profileNames()
+			}
+			return valueCompletions[flagName]
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		var out []string
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) > 1 {
+				out = append(out, "--"+f.Name)
+			}
+		})
+		return out
+	}
+	return nil
+}
+
+// describeFlags запускает команду в режиме описания и возвращает ее флаги.
+func describeFlags(cmd command) *flag.FlagSet {
+	e := &env{ctx: context.Background(), stdout: io.Discard, stderr: io.Discard, describe: true}
+	if err := cmd(e, nil); err != errDescribe {
+		return nil
+	}
+	return e.fs
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func profileNames() []string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	return cfg.names()
+}
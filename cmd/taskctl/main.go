@@ -0,0 +1,118 @@
+// taskctl — консольный клиент TaskAPI.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"taskapi/pkg/client"
+)
+
+// Коды выхода; совпадают с описанием в `taskctl help`.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitBadRequest  = 4
+	exitUnavailable = 5
+)
+
+const usage = `Использование: taskctl <команда> [флаги] [аргументы]
+
+Команды:
+  create      создать задачу
+  get         показать задачи по ID
+  list        список задач с фильтрами
+  update      изменить задачу
+  delete      удалить задачи по ID
+  export      выгрузить задачи в JSON
+  import      загрузить задачи из JSON
+  profile     профили серверов: list, set, use, remove
+  completion  скрипт автодополнения: bash, zsh, fish
+
+Общие флаги (для всех команд):
+  --profile NAME     профиль из конфигурации
+  --server URL       адрес сервиса, важнее профиля и TASKCTL_SERVER
+  -o, --output FMT   table, json или yaml
+
+Коды выхода: 0 — успех, 1 — ошибка, 2 — неверные аргументы,
+3 — не найдено, 4 — некорректный запрос, 5 — сервис недоступен.
+`
+
+var errUsage = errors.New("usage")
+
+// usageError — ошибка в аргументах команды.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+func (e usageError) Is(target error) bool {
+	return target == errUsage
+}
+
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		_, _ = io.WriteString(stdout, usage)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if args[0] == "__complete" {
+		cmd, ok = cmdComplete, true
+	}
+	if !ok {
+		fmt.Fprintf(stderr, "taskctl: неизвестная команда %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	env := &env{ctx: ctx, stdin: stdin, stdout: stdout, stderr: stderr}
+	err := cmd(env, args[1:])
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "taskctl: %v\n", err)
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, client.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, client.ErrBadRequest):
+		return exitBadRequest
+	case errors.Is(err, client.ErrUnavailable), isNetError(err):
+		return exitUnavailable
+	default:
+		return exitError
+	}
+}
+
+// isNetError — ответа от сервиса нет совсем.
+func isNetError(err error) bool {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return false
+	}
+	var netErr interface{ Timeout() bool }
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	httpHandler "taskapi/internal/handlers/http"
+	"taskapi/internal/logger"
+	"taskapi/internal/repository/memory"
+	"taskapi/internal/usecase"
+	"taskapi/pkg/client"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(logger.Entry)          {}
+func (nopLogger) Enabled(logger.Level) bool { return true }
+func (nopLogger) Stop()                     {}
+
+func newServer(t *testing.T) string {
+	t.Helper()
+	svc := usecase.NewService(memory.New(), nopLogger{})
+	srv := httptest.NewServer(httpHandler.NewRouter(svc, nopLogger{}).Handler())
+	t.Cleanup(srv.Close)
+	t.Setenv("TASKCTL_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	t.Setenv("TASKCTL_SERVER", "")
+	return srv.URL
+}
+
+func taskctl(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestTaskctl_CRUD(t *testing.T) {
+	url := newServer(t)
+
+	out, errOut, code := taskctl(t, "", "create", "--server", url, "--title", "Write docs", "--project", "core", "-o", "json")
+	if code != exitOK {
+		t.Fatalf("create failed (%d): %s", code, errOut)
+	}
+	var task client.Task
+	if err := json.Unmarshal([]byte(out), &task); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", out, err)
+	}
+
+	out, _, code = taskctl(t, "", "get", task.ID, "--server", url, "-o", "yaml")
+	if code != exitOK || !strings.Contains(out, "title: Write docs\n") || !strings.Contains(out, "status: todo\n") {
+		t.Errorf("unexpected yaml output (%d): %s", code, out)
+	}
+
+	_, _, code = taskctl(t, "", "update", task.ID, "--server", url, "--status", "done")
+	if code != exitOK {
+		t.Fatalf("update failed: %d", code)
+	}
+	out, _, _ = taskctl(t, "", "list", "--server", url, "--status", "done")
+	if !strings.HasPrefix(out, "ID ") || !strings.Contains(out, task.ID) || !strings.Contains(out, "core") {
+		t.Errorf("unexpected table output: %s", out)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"delete", []string{"delete", task.ID}, exitOK},
+		{"not found", []string{"get", task.ID}, exitNotFound},
+		{"bad request", []string{"list", "--status", "later"}, exitBadRequest},
+		{"no changes", []string{"update", task.ID}, exitUsage},
+		{"missing title", []string{"create"}, exitUsage},
+		{"unknown flag", []string{"list", "--nope"}, exitUsage},
+		{"bad output", []string{"list", "-o", "xml"}, exitUsage},
+		{"unavailable", []string{"create", "--title", "x", "--server", "http://127.0.0.1:1"}, exitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "--server", url)
+			if tt.name == "unavailable" {
+				args = tt.args
+			}
+			_, errOut, code := taskctl(t, "", args...)
+			if code != tt.want {
+				t.Errorf("expected exit code %d, got %d: %s", tt.want, code, errOut)
+			}
+		})
+	}
+}
+
+func TestTaskctl_ExportImport(t *testing.T) {
+	src := newServer(t)
+	parent, _, _ := taskctl(t, "", "create", "--server", src, "--title", "parent", "-o", "json")
+	var p client.Task
+	_ = json.Unmarshal([]byte(parent), &p)
+	taskctl(t, "", "create", "--server", src, "--title", "child", "--parent", p.ID)
+
+	dump := filepath.Join(t.TempDir(), "tasks.json")
+	if _, errOut, code := taskctl(t, "", "export", "--server", src, "--file", dump); code != exitOK {
+		t.Fatalf("export failed: %s", errOut)
+	}
+	data, _ := os.ReadFile(dump)
+	if _, _, code := taskctl(t, "", "export", "--server", src, "-o", "yaml"); code != exitUsage {
+		t.Errorf("expected usage error for yaml export, got %d", code)
+	}
+
+	dst := newServer(t)
+	if _, errOut, code := taskctl(t, string(data), "import", "--server", dst); code != exitOK {
+		t.Fatalf("import failed: %s", errOut)
+	}
+	out, _, _ := taskctl(t, "", "list", "--server", dst, "-o", "json")
+	var tasks []client.Task
+	if err := json.Unmarshal([]byte(out), &tasks); err != nil || len(tasks) != 2 {
+		t.Fatalf("unexpected imported tasks: %s", out)
+	}
+	ids := map[string]string{}
+	for _, task := range tasks {
+		ids[task.Title] = task.ID
+	}
+	for _, task := range tasks {
+		if task.Title == "child" && task.ParentID != ids["parent"] {
+			t.Errorf("parent link not remapped: %+v", task)
+		}
+	}
+
+	cyclic := `[{"id":"a","title":"A","parent_id":"b"},{"id":"b","title":"B","parent_id":"a"}]`
+	if _, _, code := taskctl(t, cyclic, "import", "--server", dst, "--dry-run"); code != exitUsage {
+		t.Errorf("expected usage error for cyclic parents, got %d", code)
+	}
+}
+
+func TestTaskctl_Profiles(t *testing.T) {
+	url := newServer(t)
+
+	if _, errOut, code := taskctl(t, "", "profile", "set", "local", "--server", url, "--output", "json"); code != exitOK {
+		t.Fatalf("profile set failed: %s", errOut)
+	}
+	taskctl(t, "", "profile", "set", "prod", "--server", "https://tasks.example.com")
+	out, _, _ := taskctl(t, "", "profile", "list")
+	if !strings.Contains(out, "* local") || !strings.Contains(out, "  prod") {
+		t.Errorf("unexpected profile list: %q", out)
+	}
+
+	out, _, code := taskctl(t, "", "create", "--title", "via profile")
+	if code != exitOK || !strings.HasPrefix(out, "{") {
+		t.Errorf("expected JSON output from profile defaults, got %q (%d)", out, code)
+	}
+	if _, _, code := taskctl(t, "", "list", "--profile", "missing"); code != exitUsage {
+		t.Errorf("expected usage error for unknown profile, got %d", code)
+	}
+
+	taskctl(t, "", "profile", "use", "prod")
+	taskctl(t, "", "profile", "remove", "prod")
+	cfg, err := loadConfig()
+	if err != nil || cfg.Current != "" || len(cfg.Profiles) != 1 {
+		t.Errorf("unexpected config after remove: %+v, %v", cfg, err)
+	}
+}
+
+func TestTaskctl_Completion(t *testing.T) {
+	newServer(t)
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out, _, code := taskctl(t, "", "completion", shell)
+		if code != exitOK || !strings.Contains(out, "taskctl __complete") {
+			t.Errorf("%s: unexpected script (%d)", shell, code)
+		}
+	}
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"taskctl", "up"}, "update\n"},
+		{[]string{"taskctl", "list", "--st"}, "--status\n"},
+		{[]string{"taskctl", "list", "--status", "in"}, "in_progress\n"},
+		{[]string{"taskctl", "get", "-o", ""}, "table\njson\nyaml\n"},
+		{[]string{"taskctl", "completion", "z"}, "zsh\n"},
+	}
+	for _, tt := range tests {
+		out, _, _ := taskctl(t, "", append([]string{"__complete"}, tt.words...)...)
+		if out != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.words, tt.want, out)
+		}
+	}
+}
+
+func TestPrintYAML(t *testing.T) {
+	var b bytes.Buffer
+	v := []any{
+		map[string]any{"a": "yes", "b": []string{}, "c": map[string]any{"d": 1.5}},
+		[]int{1, 2},
+	}
+	if err := printYAML(&b, v); err != nil {
+		t.Fatal(err)
+	}
+	want := "- a: \"yes\"\n  b: []\n  c:\n    d: 1.5\n- - 1\n  - 2\n"
+	if b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"taskapi/pkg/client"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+func validOutput(f string) bool {
+	for _, o := range outputFormats {
+		if o == f {
+			return true
+		}
+	}
+	return false
+}
+
+// printTasks печатает задачи в выбранном формате. single — вывести один
+// объект, а не список (для json/yaml).
+func printTasks(w io.Writer, format string, tasks []client.Task, single bool) error {
+	var v any = tasks
+	if single && len(tasks) == 1 {
+		v = tasks[0]
+	}
+	switch format {
+	case "json":
+		return printJSON(w, v)
+	case "yaml":
+		return printYAML(w, v)
+	default:
+		return printTable(w, tasks)
+	}
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printTable(w io.Writer, tasks []client.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tSTATUS\tPROJECT\tASSIGNEE\tPARENT\tUPDATED")
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, truncate(t.Title, 40), t.Status, dash(t.Project), dash(t.Assignee), dash(t.ParentID),
+			t.UpdatedAt.Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// printYAML выводит значение как YAML. Значение сначала кодируется в JSON,
+// чтобы учесть теги json и сохранить порядок полей структур.
+func printYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := readNode(dec)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeYAML(&b, node, 0, false)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// yamlMap сохраняет порядок ключей JSON-объекта.
+type yamlMap struct {
+	keys   []string
+	values []any
+}
+
+func readNode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			list := []any{}
+			for dec.More() {
+				item, err := readNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			_, err := dec.Token()
+			return list, err
+		}
+		m := &yamlMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := readNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, key.(string))
+			m.values = append(m.values, val)
+		}
+		_, err := dec.Token()
+		return m, err
+	default:
+		return t, nil
+	}
+}
+
+// writeYAML пишет узел с отступом indent. inline — узел продолжает строку
+// после "- ", поэтому первый ключ отображения не получает отступа.
+func writeYAML(b *strings.Builder, node any, indent int, inline bool) {
+	pad := strings.Repeat("  ", indent)
+	switch n := node.(type) {
+	case *yamlMap:
+		if len(n.keys) == 0 {
+			b.WriteString(pad + "{}\n")
+			return
+		}
+		for i, k := range n.keys {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			b.WriteString(yamlScalar(k) + ":")
+			writeNested(b, n.values[i], indent)
+		}
+	case []any:
+		if len(n) == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+		for i, item := range n {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			b.WriteString("- ")
+			switch item.(type) {
+			case *yamlMap, []any:
+				if isEmpty(item) {
+					writeYAML(b, item, 0, true)
+				} else {
+					writeYAML(b, item, indent+1, true)
+				}
+			default:
+				b.WriteString(yamlScalar(item) + "\n")
+			}
+		}
+	default:
+		b.WriteString(pad + yamlScalar(n) + "\n")
+	}
+}
+
+func writeNested(b *strings.Builder, v any, indent int) {
+	switch v.(type) {
+	case *yamlMap, []any:
+		if isEmpty(v) {
+			b.WriteString(" ")
+			writeYAML(b, v, 0, true)
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, v, indent+1, false)
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+func isEmpty(v any) bool {
+	switch n := v.(type) {
+	case *yamlMap:
+		return len(n.keys) == 0
+	case []any:
+		return len(n) == 0
+	}
+	return false
+}
+
+// yamlScalar кавычит строки, которые YAML прочитал бы иначе.
+func yamlScalar(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(s)
+	case json.Number:
+		return s.String()
+	case string:
+		if needsQuotes(s) {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return strings.Contains(s, ": ") || strings.Contains(s, " #")
+}
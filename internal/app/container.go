@@ -1,34 +1,277 @@
 package app
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
 	"taskapi/internal/config"
+	"taskapi/internal/events"
+	"taskapi/internal/graphql"
 	httpHandler "taskapi/internal/handlers/http"
+	"taskapi/internal/health"
 	"taskapi/internal/logger"
+	"taskapi/internal/metrics"
+	"taskapi/internal/repository"
+	"taskapi/internal/repository/eventstore"
 	"taskapi/internal/repository/memory"
+	"taskapi/internal/tracing"
 	"taskapi/internal/usecase"
+	"taskapi/internal/webhook"
+	"time"
 )
 
 type Container struct {
-	Config *config.Config
-	Logger *logger.Async
-	Repo   *memory.Repo
-	Svc    usecase.TaskService
-	Router httpHandler.Router
+	Config   *config.Config
+	Logger   *logger.Async
+	Repo     repository.TaskRepository
+	Svc      usecase.TaskService
+	Webhooks *webhook.Dispatcher
+	Events   *events.Hub
+	Router   httpHandler.Router
+	Metrics  *metrics.Registry
+	Tracer   *tracing.Tracer
+	Health   *health.Registry
+
+	closers []io.Closer
+}
+
+func NewContainer(cfg *config.Config) (*Container, error) {
+	c := &Container{Config: cfg, Health: health.NewRegistry()}
+
+	log, err := c.newLogger(cfg)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	repo, err := c.newRepo(cfg, log)
+	if err != nil {
+		log.Stop()
+		c.Close()
+		return nil, err
+	}
+	exp, err := newTraceExporter(cfg)
+	if err != nil {
+		log.Stop()
+		c.Close()
+		return nil, err
+	}
+	tracer := tracing.New(exp, tracing.Options{SampleRatio: float64(cfg.TraceSamplePercent) / 100})
+	c.Tracer = tracer
+	hookStore := webhook.NewStore()
+	hookStore.AllowLoopback = cfg.WebhookAllowLoopback
+	hooks := webhook.NewDispatcher(hookStore, log, webhook.Options{
+		Workers:       cfg.WebhookWorkers,
+		MaxAttempts:   cfg.WebhookMaxAttempts,
+		Timeout:       time.Duration(cfg.WebhookTimeout) * time.Second,
+		DisableAfter:  cfg.WebhookDisableAfter,
+		AllowLoopback: cfg.WebhookAllowLoopback,
+		StopTimeout:   time.Duration(cfg.ShutdownTime) * time.Second,
+	})
+	hub := events.NewHub(cfg.EventsReplay)
+	c.Health.Register(health.Check{Name: "repository", Fn: health.Repository(repo)})
+	c.Health.Register(health.Check{Name: "logger", Fn: log.Check})
+	c.Health.Register(health.Check{Name: "webhooks", Fn: hooks.Check})
+
+	svc := usecase.NewService(tracing.Repository(repo, tracer, cfg.Storage), log)
+	svc.Events = usecase.Publishers{hooks, hub}
+	svc.Tracer = tracer
+
+	reg := metrics.NewRegistry()
+	metrics.RegisterRuntime(reg)
+	metrics.RegisterLogger(reg, log)
+	metrics.RegisterWebhooks(reg, hooks)
+	metrics.RegisterTasks(reg, repo)
+	instrumented := metrics.InstrumentService(svc, reg)
+
+	routeTimeouts, err := config.ParseRouteTimeouts(cfg.HTTPRouteTimeouts)
+	if err != nil {
+		log.Stop()
+		c.Close()
+		return nil, err
+	}
+	origins, err := config.ParseCORSOrigins(cfg.CORSOrigins)
+	if err != nil {
+		log.Stop()
+		c.Close()
+		return nil, err
+	}
+	router := httpHandler.NewRouter(instrumented, log,
+		httpHandler.WithTimeouts(httpHandler.Timeouts{
+			Default: time.Duration(cfg.HTTPTimeout) * time.Second,
+			Max:     time.Duration(cfg.HTTPMaxTimeout) * time.Second,
+			Routes:  routeTimeouts,
+		}),
+		httpHandler.WithMetrics(reg),
+		httpHandler.WithRequestDump(cfg.AppEnv == "development"),
+		httpHandler.WithCompression(cfg.HTTPCompressMin),
+		httpHandler.WithCORS(httpHandler.CORS{
+			Origins:     origins,
+			Methods:     config.SplitList(cfg.CORSMethods),
+			Headers:     config.SplitList(cfg.CORSHeaders),
+			Credentials: cfg.CORSCredentials,
+			MaxAge:      time.Duration(cfg.CORSMaxAge) * time.Second,
+		}),
+		httpHandler.WithTracing(tracer),
+		httpHandler.WithHealth(c.Health),
+		httpHandler.WithWebhooks(hookStore),
+		httpHandler.WithEvents(hub, time.Duration(cfg.EventsHeartbeat)*time.Second),
+		httpHandler.WithGraphQL(graphql.Limits{
+			MaxDepth:      cfg.GraphQLMaxDepth,
+			MaxComplexity: cfg.GraphQLMaxComplexity,
+		}),
+	)
+
+	if err := router.CheckTimeouts(); err != nil {
+		log.Stop()
+		c.Close()
+		return nil, fmt.Errorf("HTTP_ROUTE_TIMEOUTS: %w", err)
+	}
+
+	c.Logger = log
+	c.Repo = repo
+	c.Svc = instrumented
+	c.Webhooks = hooks
+	c.Events = hub
+	c.Router = *router
+	c.Metrics = reg
+	return c, nil
+}
+
+func (c *Container) newRepo(cfg *config.Config, log logger.Logger) (repository.TaskRepository, error) {
+	switch cfg.Storage {
+	case "memory":
+		return memory.New(), nil
+	case "eventstore":
+		evLog, err := eventstore.OpenFileLog(filepath.Join(cfg.DataDir, "events.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		c.closers = append(c.closers, evLog)
+		c.Health.Register(health.Check{Name: "storage", Fn: evLog.Check})
+		snaps := eventstore.NewFileSnapshots(filepath.Join(cfg.DataDir, "snapshot.json"))
+		repo, err := eventstore.New(evLog, snaps, cfg.SnapshotEvery)
+		if err != nil {
+			return nil, err
+		}
+		repo.Log = log
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+func (c *Container) newLogger(cfg *config.Config) (*logger.Async, error) {
+	level, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	sampling, err := logger.ParseSampling(cfg.LogSampling)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := logger.ParseSinks(cfg.LogSinks)
+	if err != nil {
+		return nil, err
+	}
+	sinks := make([]logger.Sink, 0, len(specs))
+	for _, spec := range specs {
+		sink, closer, err := logger.OpenSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		if closer != nil {
+			c.closers = append(c.closers, closer)
+		}
+		sinks = append(sinks, sink)
+	}
+	policy, err := logger.ParsePolicy(cfg.LogOverflow)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := logger.ParseRedaction(cfg.LogRedact)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := logger.NewRedactor(rules, cfg.LogRedactSalt)
+	if err != nil {
+		return nil, err
+	}
+	return logger.New(logger.Options{
+		Buffer:          cfg.LogBuffer,
+		Level:           level,
+		Sinks:           sinks,
+		Sampling:        sampling,
+		Policy:          policy,
+		BlockTimeout:    time.Duration(cfg.LogBlockMS) * time.Millisecond,
+		SpillPath:       cfg.LogSpillPath,
+		SummaryInterval: time.Duration(cfg.LogSummary) * time.Second,
+		Redactor:        redactor,
+	})
+}
+
+func newTraceExporter(cfg *config.Config) (tracing.Exporter, error) {
+	switch cfg.TraceExporter {
+	case "none", "":
+		return nil, nil
+	case "otlp":
+		return tracing.NewOTLPExporter(cfg.TraceOTLPEndpoint, cfg.TraceServiceName), nil
+	case "file":
+		return tracing.NewFileExporter(cfg.TraceFile, cfg.TraceServiceName)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.TraceExporter)
+	}
 }
 
-func NewContainer() *Container {
-	cfg := config.Load()
-	log := logger.NewAsync(cfg.LogBuffer, os.Stdout)
-	repo := memory.New()
-	svc := usecase.NewService(repo, log)
-	router := httpHandler.NewRouter(svc, log)
-
-	return &Container{
-		Config: cfg,
-		Logger: log,
-		Repo:   repo,
-		Svc:    svc,
-		Router: *router,
+// Reload применяет настройки, которые можно менять без перезапуска: уровень,
+// прореживание и правила скрытия данных в журнале. Остальные отличия
+// возвращаются в Changes.Restart и не применяются.
+func (c *Container) Reload(next *config.Config) (config.Changes, error) {
+	changes := c.Config.Diff(next)
+	if len(changes.Reload) == 0 {
+		return changes, nil
+	}
+	// Конфигурация уже проверена config.Load, ошибки здесь не ожидаются.
+	level, err := logger.ParseLevel(next.LogLevel)
+	if err != nil {
+		return changes, err
+	}
+	sampling, err := logger.ParseSampling(next.LogSampling)
+	if err != nil {
+		return changes, err
+	}
+	rules, err := logger.ParseRedaction(next.LogRedact)
+	if err != nil {
+		return changes, err
+	}
+	redactor, err := logger.NewRedactor(rules, next.LogRedactSalt)
+	if err != nil {
+		return changes, err
+	}
+	c.Logger.SetLevel(level)
+	c.Logger.SetSampling(sampling)
+	c.Logger.SetRedactor(redactor)
+	c.Config.LogLevel = next.LogLevel
+	c.Config.LogSampling = next.LogSampling
+	c.Config.LogRedact = next.LogRedact
+	c.Config.LogRedactSalt = next.LogRedactSalt
+	return changes, nil
+}
+
+// Close останавливает фоновые воркеры, логгер и освобождает ресурсы хранилища.
+func (c *Container) Close() {
+	if c.Webhooks != nil {
+		c.Webhooks.Stop()
+	}
+	if c.Tracer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Config.ShutdownTime)*time.Second)
+		_ = c.Tracer.Shutdown(ctx)
+		cancel()
+	}
+	if c.Logger != nil {
+		c.Logger.Stop()
+	}
+	for _, cl := range c.closers {
+		_ = cl.Close()
 	}
 }
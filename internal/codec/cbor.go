@@ -0,0 +1,338 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// Основные типы CBOR (RFC 8949, раздел 3.1).
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+type cborEncoder struct{}
+
+// CBOR — двоичное представление по RFC 8949.
+var CBOR Encoder = cborEncoder{}
+
+func (cborEncoder) MediaType() string { return "application/cbor" }
+
+func (cborEncoder) Encode(w io.Writer, v any) error {
+	t, err := tree(v)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	b = appendCBOR(b, t)
+	_, err = w.Write(b)
+	return err
+}
+
+func appendCBOR(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xf6)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case json.Number:
+		switch n := number(v).(type) {
+		case int64:
+			if n < 0 {
+				return appendCBORHead(b, cborNegint, uint64(-(n + 1)))
+			}
+			return appendCBORHead(b, cborUint, uint64(n))
+		case uint64:
+			return appendCBORHead(b, cborUint, n)
+		case float64:
+			return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(n))
+		}
+	case string:
+		b = appendCBORHead(b, cborText, uint64(len(v)))
+		return append(b, v...)
+	case []any:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, x := range v {
+			b = appendCBOR(b, x)
+		}
+		return b
+	case object:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for _, f := range v {
+			b = appendCBOR(b, f.name)
+			b = appendCBOR(b, f.value)
+		}
+		return b
+	}
+	return append(b, 0xf6)
+}
+
+// appendCBORHead пишет начальный байт и аргумент в кратчайшей форме.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	m := major << 5
+	switch {
+	case n < 24:
+		return append(b, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, m|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, m|27), n)
+	}
+}
+
+// DecodeCBOR разбирает одно значение CBOR в nil, bool, int64, uint64,
+// float64, string, []any или map[string]any. Теги пропускаются, байтовые
+// строки отдаются строками, ключи словарей должны быть текстом.
+func DecodeCBOR(data []byte) (any, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("cbor: trailing data")
+	}
+	return v, nil
+}
+
+// errBreak — стоп-код 0xff вне неопределенной длины.
+var errBreak = errors.New("unexpected break")
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// head читает начальный байт и аргумент. indefinite — длина не указана
+// (дополнительная информация 31).
+func (d *cborDecoder) head() (major byte, arg uint64, indefinite bool, err error) {
+	hb, err := d.read(1)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	major, info := hb[0]>>5, hb[0]&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info <= 27:
+		b, err := d.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, false, err
+		}
+		for _, c := range b {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, arg, false, nil
+	case info == 31:
+		return major, 0, true, nil
+	}
+	return 0, 0, false, fmt.Errorf("reserved additional information %d", info)
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errTooDeep
+	}
+	start := d.pos
+	major, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major == cborUint || major == cborNegint || major == cborTag) {
+		return nil, fmt.Errorf("indefinite length for major type %d", major)
+	}
+	switch major {
+	case cborUint:
+		if arg <= math.MaxInt64 {
+			return int64(arg), nil
+		}
+		return arg, nil
+	case cborNegint:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("negative integer overflows int64")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		b, err := d.str(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText && !utf8.Valid(b) {
+			return nil, errors.New("invalid UTF-8 string")
+		}
+		return string(b), nil
+	case cborArray:
+		return d.array(arg, indefinite, depth)
+	case cborMap:
+		return d.mapOf(arg, indefinite, depth)
+	case cborTag:
+		// Семантика тегов (даты, большие числа) не нужна: берется содержимое.
+		return d.value(depth + 1)
+	}
+	return simple(d.data[start]&0x1f, arg, indefinite)
+}
+
+func simple(info byte, arg uint64, indefinite bool) (any, error) {
+	switch {
+	case indefinite:
+		return nil, errBreak
+	case info == 20:
+		return false, nil
+	case info == 21:
+		return true, nil
+	case info == 22, info == 23:
+		// null и undefined.
+		return nil, nil
+	case info == 25:
+		return halfFloat(uint16(arg)), nil
+	case info == 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case info == 27:
+		return math.Float64frombits(arg), nil
+	}
+	return nil, fmt.Errorf("unsupported simple value %d", arg)
+}
+
+// str читает строку; строка неопределенной длины — это цепочка кусков того
+// же типа до стоп-кода.
+func (d *cborDecoder) str(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return d.read(n)
+	}
+	var out []byte
+	for {
+		if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+			d.pos++
+			return out, nil
+		}
+		m, n, ind, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || ind {
+			return nil, errors.New("invalid chunk in indefinite-length string")
+		}
+		b, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+}
+
+// more сообщает, есть ли следующий элемент контейнера, и снимает стоп-код.
+func (d *cborDecoder) more(i int, n uint64, indefinite bool) (bool, error) {
+	if !indefinite {
+		return uint64(i) < n, nil
+	}
+	if d.pos >= len(d.data) {
+		return false, io.ErrUnexpectedEOF
+	}
+	if d.data[d.pos] == 0xff {
+		d.pos++
+		return false, nil
+	}
+	return true, nil
+}
+
+func (d *cborDecoder) array(n uint64, indefinite bool, depth int) (any, error) {
+	// Каждый элемент занимает хотя бы байт, так что длина больше остатка
+	// заведомо ошибочна.
+	if !indefinite && n > uint64(len(d.data)-d.pos) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	out := make([]any, 0, n)
+	for i := 0; ; i++ {
+		ok, err := d.more(i, n, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return out, nil
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
+
+func (d *cborDecoder) mapOf(n uint64, indefinite bool, depth int) (any, error) {
+	if !indefinite && n > uint64(len(d.data)-d.pos) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	out := make(map[string]any, n)
+	for i := 0; ; i++ {
+		ok, err := d.more(i, n, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return out, nil
+		}
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errors.New("map keys must be strings")
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+}
+
+// halfFloat переводит число половинной точности (IEEE 754 binary16).
+func halfFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+// Package codec — представления ответов (JSON, XML, CSV, MessagePack, CBOR),
+// разбор тел MessagePack и CBOR и выбор представления по Accept.
+//
+// Все кодеки, кроме JSON, работают с деревом значения, полученным через
+// encoding/json: теги `json`, omitempty и MarshalJSON действуют одинаково
+// для всех форматов, а порядок полей сохраняется.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrNotList — значение нельзя представить таблицей (CSV).
+var ErrNotList = errors.New("codec: value is not a list")
+
+// Encoder пишет значение в одном представлении.
+type Encoder interface {
+	// MediaType — значение Content-Type ответа.
+	MediaType() string
+	Encode(w io.Writer, v any) error
+}
+
+// field и object — объект JSON с сохраненным порядком полей.
+type field struct {
+	name  string
+	value any
+}
+
+type object []field
+
+// tree переводит значение в дерево из nil, bool, json.Number, string,
+// []any и object.
+func tree(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return readTree(dec)
+}
+
+func readTree(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch d {
+	case '{':
+		o := object{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, field{name: k.(string), value: v})
+		}
+		_, err = dec.Token()
+		return o, err
+	case '[':
+		arr := []any{}
+		for dec.More() {
+			v, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	return nil, fmt.Errorf("codec: unexpected delimiter %v", d)
+}
+
+// number разбирает json.Number в int64, uint64 или float64.
+func number(n json.Number) any {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// maxDepth — предел вложенности разбираемых тел.
+const maxDepth = 64
+
+var errTooDeep = errors.New("nesting too deep")
+
+type jsonEncoder struct{}
+
+// JSON — представление по умолчанию.
+var JSON Encoder = jsonEncoder{}
+
+func (jsonEncoder) MediaType() string { return "application/json" }
+
+func (jsonEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,205 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"taskapi/internal/codec"
+)
+
+type note struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Tags     []string   `json:"tags"`
+	Priority int        `json:"priority"`
+	Score    float64    `json:"score"`
+	Done     bool       `json:"done"`
+	Parent   *string    `json:"parent"`
+	Meta     *noteMeta  `json:"meta,omitempty"`
+	Due      *time.Time `json:"due,omitempty"`
+}
+
+type noteMeta struct {
+	Author string `json:"author"`
+}
+
+var notes = []note{
+	{ID: "1", Title: "Купить <молоко> & хлеб", Tags: []string{"home", "food"}, Priority: -3, Score: 1.5, Done: true},
+	{ID: "2", Title: "Отчет, \"квартал\"", Priority: 1 << 40, Meta: &noteMeta{Author: "ann"}},
+}
+
+// canonical приводит значение к JSON, чтобы сравнивать int64 и float64.
+func canonical(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var x any
+	if err := json.Unmarshal(b, &x); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = json.Marshal(x)
+	return string(b)
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		enc    codec.Encoder
+		decode func([]byte) (any, error)
+	}{
+		{"msgpack", codec.MessagePack, codec.DecodeMessagePack},
+		{"cbor", codec.CBOR, codec.DecodeCBOR},
+	}
+	long := strings.Repeat("x", 70000)
+	values := []any{notes, notes[0], map[string]any{"long": long, "n": []int{0, 255, 65536, -129, math.MaxInt32 + 1}}, "", nil}
+	for _, tt := range tests {
+		for i, v := range values {
+			var buf bytes.Buffer
+			if err := tt.enc.Encode(&buf, v); err != nil {
+				t.Fatalf("%s #%d: encode: %v", tt.name, i, err)
+			}
+			got, err := tt.decode(buf.Bytes())
+			if err != nil {
+				t.Fatalf("%s #%d: decode: %v", tt.name, i, err)
+			}
+			if canonical(t, got) != canonical(t, v) {
+				t.Errorf("%s #%d: round trip mismatch:\n got %s\nwant %s", tt.name, i, canonical(t, got), canonical(t, v))
+			}
+		}
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	// Примеры из RFC 8949, приложение A.
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		{"f93c00", "1"},
+		{"f9c400", "-4"},
+		{"fa47c35000", "100000"},
+		{"3903e7", "-1000"},
+		{"9f018202039f0405ffff", "[1,[2,3],[4,5]]"},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		{"c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+		{"f7", "null"},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		got, err := codec.DecodeCBOR(data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.hex, err)
+			continue
+		}
+		if c := canonical(t, got); c != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.hex, c, tt.want)
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) (any, error)
+		hex    string
+	}{
+		{"msgpack truncated", codec.DecodeMessagePack, "a3616263"[:6]},
+		{"msgpack huge array", codec.DecodeMessagePack, "ddffffffff"},
+		{"msgpack int key", codec.DecodeMessagePack, "810102"},
+		{"msgpack trailing", codec.DecodeMessagePack, "c0c0"},
+		{"msgpack ext", codec.DecodeMessagePack, "d40100"},
+		{"cbor truncated", codec.DecodeCBOR, "6261"},
+		{"cbor huge map", codec.DecodeCBOR, "bbffffffffffffffff"},
+		{"cbor unterminated", codec.DecodeCBOR, "9f01"},
+		{"cbor break", codec.DecodeCBOR, "ff"},
+		{"cbor bad utf8", codec.DecodeCBOR, "61ff"},
+		{"cbor deep", codec.DecodeCBOR, strings.Repeat("81", 100) + "01"},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		if v, err := tt.decode(data); err == nil {
+			t.Errorf("%s: expected an error, got %v", tt.name, v)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := codec.CSV.Encode(&buf, notes); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,title,tags,priority,score,done,parent,meta,due\n" +
+		"1,Купить <молоко> & хлеб,home;food,-3,1.5,true,,,\n" +
+		"2,\"Отчет, \"\"квартал\"\"\",,1099511627776,0,false,,\"{\"\"author\"\":\"\"ann\"\"}\",\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := codec.CSV.Encode(&buf, []note{}); err != nil || buf.String() != "id,title,tags,priority,score,done,parent,meta,due\n" {
+		t.Errorf("empty list: %q, %v", buf.String(), err)
+	}
+	if err := codec.CSV.Encode(&buf, notes[0]); !errors.Is(err, codec.ErrNotList) {
+		t.Errorf("expected ErrNotList, got %v", err)
+	}
+}
+
+func TestXML(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"list", notes[:1], `<notes><note><id>1</id><title>Купить &lt;молоко&gt; &amp; хлеб</title>` +
+			`<tags><tag>home</tag><tag>food</tag></tags><priority>-3</priority><score>1.5</score><done>true</done></note></notes>`},
+		{"item", &notes[1], `<note><id>2</id><title>Отчет, &#34;квартал&#34;</title>` +
+			`<priority>1099511627776</priority><score>0</score><done>false</done><meta><author>ann</author></meta></note>`},
+		{"map", map[string]any{"1st": "x"}, `<item><_st>x</_st></item>`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := codec.XML.Encode(&buf, tt.v); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + tt.want + "\n"
+		if buf.String() != want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, buf.String(), want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/csv", "application/msgpack"}
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", "application/json", true},
+		{"*/*", "application/json", true},
+		{"application/xml", "application/xml", true},
+		{"text/*", "text/csv", true},
+		{"application/xml;q=0.5, text/csv", "text/csv", true},
+		{"Application/MsgPack", "application/msgpack", true},
+		{"application/*;q=0.2, application/xml;q=0.9", "application/xml", true},
+		{"*/*;q=0.1, application/json;q=0", "application/xml", true},
+		{"text/html", "", false},
+		{"application/json;q=0", "", false},
+		{"application/xml;q=abc", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := codec.Negotiate(tt.accept, offers)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Negotiate(%q) = %q, %v; want %q, %v", tt.accept, got, ok, tt.want, tt.ok)
+		}
+	}
+}
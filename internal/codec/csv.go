@@ -0,0 +1,153 @@
+package codec
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+type csvEncoder struct{}
+
+// CSV — таблица для списков: строка заголовка с именами полей JSON, затем по
+// строке на элемент. Вложенные списки склеиваются через ';', объекты
+// пишутся как JSON. Значение, которое не является списком, дает ErrNotList.
+var CSV Encoder = csvEncoder{}
+
+func (csvEncoder) MediaType() string { return "text/csv; charset=utf-8" }
+
+func (csvEncoder) Encode(w io.Writer, v any) error {
+	t, err := tree(v)
+	if err != nil {
+		return err
+	}
+	rows, ok := t.([]any)
+	if !ok {
+		return ErrNotList
+	}
+	cols := columns(v, rows)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+	rec := make([]string, len(cols))
+	for _, row := range rows {
+		clear(rec)
+		o, ok := row.(object)
+		if !ok {
+			return ErrNotList
+		}
+		for _, f := range o {
+			for i, c := range cols {
+				if c == f.name {
+					rec[i] = cell(f.value)
+					break
+				}
+			}
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// columns — поля структуры элемента в порядке объявления, чтобы заголовок
+// был одинаковым и для пустого списка, плюс ключи, которых в ней нет.
+func columns(v any, rows []any) []string {
+	var cols []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			cols = append(cols, name)
+		}
+	}
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		for _, name := range jsonFields(t.Elem()) {
+			add(name)
+		}
+	}
+	for _, row := range rows {
+		if o, ok := row.(object); ok {
+			for _, f := range o {
+				add(f.name)
+			}
+		}
+	}
+	return cols
+}
+
+// jsonFields перечисляет имена полей структуры так, как их назовет
+// encoding/json, включая поля встроенных структур.
+func jsonFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			out = append(out, jsonFields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case []any:
+		parts := make([]string, len(v))
+		for i, x := range v {
+			parts[i] = cell(x)
+		}
+		return strings.Join(parts, ";")
+	case object:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return scalar(v)
+}
+
+// MarshalJSON нужен для ячеек с вложенными объектами.
+func (o object) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(f.name)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,277 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+type msgpackEncoder struct{}
+
+// MessagePack — компактное двоичное представление (msgpack.org).
+var MessagePack Encoder = msgpackEncoder{}
+
+func (msgpackEncoder) MediaType() string { return "application/msgpack" }
+
+func (msgpackEncoder) Encode(w io.Writer, v any) error {
+	t, err := tree(v)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	b = appendMsgpack(b, t)
+	_, err = w.Write(b)
+	return err
+}
+
+func appendMsgpack(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case json.Number:
+		switch n := number(v).(type) {
+		case int64:
+			return appendMsgpackInt(b, n)
+		case uint64:
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+		case float64:
+			return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(n))
+		}
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = append(b, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+		}
+		return append(b, v...)
+	case []any:
+		b = appendMsgpackLen(b, len(v), 0x90, 0xdc)
+		for _, x := range v {
+			b = appendMsgpack(b, x)
+		}
+		return b
+	case object:
+		b = appendMsgpackLen(b, len(v), 0x80, 0xde)
+		for _, f := range v {
+			b = appendMsgpack(b, f.name)
+			b = appendMsgpack(b, f.value)
+		}
+		return b
+	}
+	return append(b, 0xc0)
+}
+
+// appendMsgpackLen пишет заголовок массива (fix 0x90, 0xdc) или словаря
+// (fix 0x80, 0xde); код 32-битной длины следует сразу за 16-битным.
+func appendMsgpackLen(b []byte, n int, fix, wide byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, wide), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, wide+1), uint32(n))
+	}
+}
+
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128:
+		return append(b, byte(n))
+	case n < 0 && n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// DecodeMessagePack разбирает одно значение MessagePack в nil, bool, int64,
+// uint64, float64, string, []any или map[string]any. Ключи словарей должны
+// быть строками, расширения (ext) не поддерживаются.
+func DecodeMessagePack(data []byte) (any, error) {
+	d := &msgpackDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("msgpack: trailing data")
+	}
+	return v, nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (d *msgpackDecoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errTooDeep
+	}
+	hb, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	h := hb[0]
+	switch {
+	case h <= 0x7f:
+		return int64(h), nil
+	case h >= 0xe0:
+		return int64(int8(h)), nil
+	case h&0xf0 == 0x80:
+		return d.mapOf(int(h&0x0f), depth)
+	case h&0xf0 == 0x90:
+		return d.array(int(h&0x0f), depth)
+	case h&0xe0 == 0xa0:
+		return d.str(int(h & 0x1f))
+	}
+	switch h {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		// bin отдается строкой, как и байтовые строки CBOR.
+		n, err := d.uint(1 << (h - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(int(n))
+		return string(b), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (h - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce:
+		n, err := d.uint(1 << (h - 0xcc))
+		return int64(n), err
+	case 0xcf:
+		n, err := d.uint(8)
+		if n <= math.MaxInt64 {
+			return int64(n), err
+		}
+		return n, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (h - 0xd0)
+		n, err := d.uint(size)
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (h - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (h - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n), depth)
+	}
+	return nil, fmt.Errorf("unsupported type 0x%02x", h)
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(b) {
+		return nil, errors.New("invalid UTF-8 string")
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n, depth int) (any, error) {
+	// Каждый элемент занимает хотя бы байт: длина больше остатка — ошибка,
+	// а не огромная аллокация.
+	if n > len(d.data)-d.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	out := make([]any, n)
+	for i := range out {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (d *msgpackDecoder) mapOf(n, depth int) (any, error) {
+	if n > len(d.data)-d.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errors.New("map keys must be strings")
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Accept — один элемент заголовка Accept или Accept-Encoding.
+type Accept struct {
+	Value string
+	Q     float64
+}
+
+// ParseAccept разбирает список вида "text/html;q=0.8, */*;q=0.1".
+// Элементы с некорректным q получают q=0, параметры кроме q отбрасываются.
+func ParseAccept(header string) []Accept {
+	var out []Accept
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		a := Accept{Value: value, Q: 1}
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(p, "=")
+			if strings.TrimSpace(strings.ToLower(k)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			a.Q = q
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// Negotiate выбирает из offers (в порядке предпочтения сервера) значение с
+// наибольшим q по заголовку Accept. Для каждого предложения берется самый
+// точный подходящий элемент: type/subtype, затем type/*, затем */* (или * в
+// Accept-Encoding). Пустой заголовок принимает первое предложение; q=0
+// запрещает значение.
+func Negotiate(header string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0], true
+	}
+	accepts := ParseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, spec := 0.0, -1
+		for _, a := range accepts {
+			if s := specificity(a.Value, strings.ToLower(offer)); s > spec {
+				q, spec = a.Q, s
+			}
+		}
+		if spec >= 0 && q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// specificity — насколько точно шаблон из Accept описывает значение:
+// -1 не подходит, 0 — "*" или "*/*", 1 — "type/*", 2 — точное совпадение.
+func specificity(pattern, value string) int {
+	if pattern == "*" || pattern == "*/*" {
+		return 0
+	}
+	if pattern == value {
+		return 2
+	}
+	if typ, ok := strings.CutSuffix(pattern, "/*"); ok {
+		if strings.HasPrefix(value, typ+"/") {
+			return 1
+		}
+	}
+	return -1
+}
+
+// MediaType отбрасывает параметры и приводит тип к нижнему регистру:
+// "Application/JSON; charset=utf-8" -> "application/json".
+func MediaType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
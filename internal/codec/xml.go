@@ -0,0 +1,180 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+type xmlEncoder struct{}
+
+// XML строит документ из того же дерева, что и JSON: корень называется по
+// типу значения (task, а для списка — tasks с элементами task), поля
+// становятся элементами, null пропускается.
+var XML Encoder = xmlEncoder{}
+
+func (xmlEncoder) MediaType() string { return "application/xml" }
+
+func (xmlEncoder) Encode(w io.Writer, v any) error {
+	t, err := tree(v)
+	if err != nil {
+		return err
+	}
+	root, item := rootNames(v)
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	if arr, ok := t.([]any); ok {
+		writeXMLList(bw, root, item, arr)
+	} else {
+		writeXML(bw, root, t)
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// WithMediaType отдает то же представление под другим Content-Type, например
+// application/problem+xml.
+func WithMediaType(e Encoder, mediaType string) Encoder {
+	return typedEncoder{Encoder: e, mediaType: mediaType}
+}
+
+type typedEncoder struct {
+	Encoder
+	mediaType string
+}
+
+func (e typedEncoder) MediaType() string { return e.mediaType }
+
+func writeXML(w *bufio.Writer, name string, v any) {
+	switch v := v.(type) {
+	case nil:
+		return
+	case object:
+		w.WriteString("<" + name + ">")
+		for _, f := range v {
+			key := xmlName(f.name)
+			if arr, ok := f.value.([]any); ok {
+				writeXMLList(w, key, singular(key), arr)
+				continue
+			}
+			writeXML(w, key, f.value)
+		}
+		w.WriteString("</" + name + ">")
+	case []any:
+		writeXMLList(w, name, singular(name), v)
+	default:
+		w.WriteString("<" + name + ">")
+		xml.EscapeText(w, []byte(scalar(v)))
+		w.WriteString("</" + name + ">")
+	}
+}
+
+func writeXMLList(w *bufio.Writer, name, item string, arr []any) {
+	w.WriteString("<" + name + ">")
+	for _, x := range arr {
+		writeXML(w, item, x)
+	}
+	w.WriteString("</" + name + ">")
+}
+
+// scalar — текстовая форма листа дерева.
+func scalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	case string:
+		return v
+	}
+	return ""
+}
+
+// rootNames выводит имя корня и элемента списка из типа значения.
+func rootNames(v any) (root, item string) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "value", "item"
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		item = typeName(t.Elem())
+		return plural(item), item
+	}
+	return typeName(t), "item"
+}
+
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		return "item"
+	}
+	return xmlName(snake(t.Name()))
+}
+
+// snake переводит CamelCase в snake_case: TaskEvent -> task_event.
+func snake(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+	for i, c := range r {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && !strings.HasSuffix(s, "ey"):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
+
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "ses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return "item"
+}
+
+// xmlName заменяет недопустимые в имени элемента символы на '_'.
+func xmlName(s string) string {
+	var b strings.Builder
+	for i, c := range s {
+		ok := c == '_' || unicode.IsLetter(c) || i > 0 && (c == '-' || c == '.' || unicode.IsDigit(c))
+		if !ok {
+			c = '_'
+		}
+		b.WriteRune(c)
+	}
+	if b.Len() == 0 || strings.HasPrefix(strings.ToLower(b.String()), "xml") {
+		return "_" + b.String()
+	}
+	return b.String()
+}
@@ -1,41 +1,462 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"taskapi/internal/logger"
+	"time"
 )
 
 type Config struct {
-	HTTPPort     string
-	LogBuffer    int
-	ShutdownTime int
+	AppEnv            string
+	HTTPPort          string
+	HTTPTimeout       int
+	HTTPMaxTimeout    int
+	HTTPRouteTimeouts string
+	HTTPCompressMin   int
+	CORSOrigins       string
+	CORSMethods       string
+	CORSHeaders       string
+	CORSCredentials   bool
+	CORSMaxAge        int
+	LogBuffer         int
+	LogLevel          string
+	LogSinks          string
+	LogSampling       string
+	LogOverflow       string
+	LogBlockMS        int
+	LogSpillPath      string
+	LogSummary        int
+	LogRedact         string
+	LogRedactSalt     string
+	ShutdownTime      int
+	Storage           string
+	DataDir           string
+	SnapshotEvery     int
+
+	WebhookWorkers      int
+	WebhookMaxAttempts  int
+	WebhookTimeout      int
+	WebhookDisableAfter int
+	// WebhookAllowLoopback разрешает вебхуки на loopback и link-local адреса.
+	WebhookAllowLoopback bool
+
+	EventsReplay    int
+	EventsHeartbeat int
+
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+
+	TraceExporter      string
+	TraceOTLPEndpoint  string
+	TraceFile          string
+	TraceSamplePercent int
+	TraceServiceName   string
+}
+
+// field — одна настройка. Из имени переменной окружения выводятся ключ в
+// файле (log_level, вложенное log: {level: ...}) и флаг (--log-level).
+type field struct {
+	env   string
+	def   string
+	usage string
+	// secret не печатается в String.
+	secret bool
+	// reload применяется по SIGHUP без перезапуска.
+	reload bool
+	ptr    any // *string, *int или *bool
 }
 
-func Load() *Config {
-	cfg := &Config{
-		HTTPPort:     getEnv("HTTP_PORT", ":8080"),
-		LogBuffer:    getEnvInt("LOG_BUFFER", 256),
-		ShutdownTime: getEnvInt("SHUTDOWN_TIME", 10),
+func (c *Config) fields() []field {
+	return []field{
+		{env: "APP_ENV", def: "production", usage: "окружение: production или development", ptr: &c.AppEnv},
+		{env: "HTTP_PORT", def: ":8080", usage: "адрес HTTP-сервера", ptr: &c.HTTPPort},
+		{env: "HTTP_TIMEOUT", def: "10", usage: "таймаут обработки запроса, с", ptr: &c.HTTPTimeout},
+		{env: "HTTP_MAX_TIMEOUT", def: "60", usage: "предел X-Request-Timeout, с", ptr: &c.HTTPMaxTimeout},
+		{env: "HTTP_ROUTE_TIMEOUTS", def: "", usage: "таймауты маршрутов: \"GET /tasks=30s;GET /tasks/{id}=2s\"", ptr: &c.HTTPRouteTimeouts},
+		{env: "HTTP_COMPRESS_MIN_SIZE", def: "1024", usage: "минимальный размер сжимаемого ответа, байт", ptr: &c.HTTPCompressMin},
+		{env: "CORS_ORIGINS", def: "", usage: "источники для CORS через запятую: https://app.example.com, https://*.example.com или *", ptr: &c.CORSOrigins},
+		{env: "CORS_METHODS", def: "GET,POST,PUT,PATCH,DELETE", usage: "методы, разрешенные для CORS", ptr: &c.CORSMethods},
+		{env: "CORS_HEADERS", def: "Content-Type,Content-Encoding,X-Request-ID,X-Request-Timeout,If-None-Match,If-Modified-Since,traceparent,tracestate", usage: "заголовки запроса, разрешенные для CORS; * — любые", ptr: &c.CORSHeaders},
+		{env: "CORS_CREDENTIALS", def: "false", usage: "разрешить CORS-запросы с cookie и авторизацией", ptr: &c.CORSCredentials},
+		{env: "CORS_MAX_AGE", def: "600", usage: "время кэширования preflight-ответа, с", ptr: &c.CORSMaxAge},
+		{env: "LOG_BUFFER", def: "256", usage: "размер очереди логгера", ptr: &c.LogBuffer},
+		{env: "LOG_LEVEL", def: "info", usage: "минимальный уровень журнала", reload: true, ptr: &c.LogLevel},
+		{env: "LOG_SINKS", def: "stdout", usage: "получатели журнала", ptr: &c.LogSinks},
+		{env: "LOG_SAMPLING", def: "", usage: "прореживание событий журнала", reload: true, ptr: &c.LogSampling},
+		{env: "LOG_OVERFLOW", def: "drop_newest", usage: "поведение при переполненной очереди логгера", ptr: &c.LogOverflow},
+		{env: "LOG_BLOCK_TIMEOUT_MS", def: "100", usage: "ожидание места в очереди для block, мс", ptr: &c.LogBlockMS},
+		{env: "LOG_SPILL_PATH", def: "data/log-overflow.jsonl", usage: "файл переполнения для spill", ptr: &c.LogSpillPath},
+		{env: "LOG_DROP_SUMMARY", def: "10", usage: "интервал сводки о потерях, с", ptr: &c.LogSummary},
+		{env: "LOG_REDACT", def: "default", usage: "правила скрытия данных в журнале", reload: true, ptr: &c.LogRedact},
+		{env: "LOG_REDACT_SALT", def: "", usage: "ключ HMAC для стратегии hash", secret: true, reload: true, ptr: &c.LogRedactSalt},
+		{env: "SHUTDOWN_TIME", def: "10", usage: "время на остановку, с", ptr: &c.ShutdownTime},
+		{env: "STORAGE", def: "memory", usage: "хранилище: memory или eventstore", ptr: &c.Storage},
+		{env: "DATA_DIR", def: "data", usage: "каталог данных eventstore", ptr: &c.DataDir},
+		{env: "SNAPSHOT_EVERY", def: "1000", usage: "снимок eventstore каждые N событий", ptr: &c.SnapshotEvery},
+
+		{env: "WEBHOOK_WORKERS", def: "2", usage: "воркеров доставки вебхуков", ptr: &c.WebhookWorkers},
+		{env: "WEBHOOK_MAX_ATTEMPTS", def: "5", usage: "попыток доставки вебхука", ptr: &c.WebhookMaxAttempts},
+		{env: "WEBHOOK_TIMEOUT", def: "5", usage: "таймаут доставки вебхука, с", ptr: &c.WebhookTimeout},
+		{env: "WEBHOOK_DISABLE_AFTER", def: "10", usage: "отключить вебхук после N неудач подряд", ptr: &c.WebhookDisableAfter},
+		{env: "WEBHOOK_ALLOW_LOOPBACK", def: "false", usage: "разрешить вебхуки на loopback и link-local адреса (для разработки)", ptr: &c.WebhookAllowLoopback},
+
+		{env: "EVENTS_REPLAY", def: "1000", usage: "событий для повтора в SSE", ptr: &c.EventsReplay},
+		{env: "EVENTS_HEARTBEAT", def: "15", usage: "интервал heartbeat SSE, с", ptr: &c.EventsHeartbeat},
+
+		{env: "GRAPHQL_MAX_DEPTH", def: "10", usage: "максимальная глубина запроса GraphQL", ptr: &c.GraphQLMaxDepth},
+		{env: "GRAPHQL_MAX_COMPLEXITY", def: "1000", usage: "максимальная сложность запроса GraphQL", ptr: &c.GraphQLMaxComplexity},
+
+		{env: "TRACE_EXPORTER", def: "none", usage: "экспорт трасс: none, otlp или file", ptr: &c.TraceExporter},
+		{env: "TRACE_OTLP_ENDPOINT", def: "http://localhost:4318/v1/traces", usage: "адрес OTLP/HTTP", ptr: &c.TraceOTLPEndpoint},
+		{env: "TRACE_FILE", def: "traces.jsonl", usage: "файл трасс", ptr: &c.TraceFile},
+		{env: "TRACE_SAMPLE_PERCENT", def: "100", usage: "доля записываемых трасс, %", ptr: &c.TraceSamplePercent},
+		{env: "TRACE_SERVICE_NAME", def: "taskapi", usage: "имя сервиса в трассах", ptr: &c.TraceServiceName},
+	}
+}
+
+func (f field) key() string  { return strings.ToLower(f.env) }
+func (f field) flag() string { return strings.ReplaceAll(f.key(), "_", "-") }
+
+// Load собирает конфигурацию; источники по возрастанию приоритета:
+// значения по умолчанию, файл (--config или CONFIG_FILE), переменные
+// окружения, флаги. Значение любой настройки можно прочитать из файла,
+// указав путь в X_FILE (--x-file, x_file в файле конфигурации) — так
+// передаются секреты. Все ошибки собираются в одну; flag.ErrHelp
+// возвращается как есть.
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet("task-service", flag.ContinueOnError)
+	configPath := fs.String("config", "", "файл конфигурации (YAML или JSON)")
+	flagVals := make(map[string]*string, 2*len(fields))
+	for _, f := range fields {
+		flagVals[f.flag()] = fs.String(f.flag(), "", f.usage+" ("+f.env+")")
+		flagVals[f.flag()+"-file"] = fs.String(f.flag()+"-file", "", "прочитать "+f.env+" из файла")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	setFlags := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { setFlags[fl.Name] = true })
+
+	var errs []error
+	values := make(map[string]string, len(fields))
+	sources := make(map[string]string, len(fields))
+	for _, f := range fields {
+		values[f.env], sources[f.env] = f.def, "default"
+	}
+
+	path := os.Getenv("CONFIG_FILE")
+	if setFlags["config"] {
+		path = *configPath
+	}
+	if path != "" {
+		fileVals, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		known := make(map[string]bool, 2*len(fields))
+		for _, f := range fields {
+			known[f.key()], known[f.key()+"_file"] = true, true
+			source := "file " + path
+			if err := apply(values, sources, f, fileVals[f.key()], fileVals[f.key()+"_file"], source); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for k := range fileVals {
+			if !known[k] {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, k))
+			}
+		}
+	}
+	for _, f := range fields {
+		if err := apply(values, sources, f, os.Getenv(f.env), os.Getenv(f.env+"_FILE"), "env"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, f := range fields {
+		var v, file string
+		if setFlags[f.flag()] {
+			v = *flagVals[f.flag()]
+		}
+		if setFlags[f.flag()+"-file"] {
+			file = *flagVals[f.flag()+"-file"]
+		}
+		if err := apply(values, sources, f, v, file, "flag"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Настройки с неверным числом не проверяются дальше, чтобы не сообщать
+	// об одной ошибке дважды.
+	failed := make(map[string]bool)
+	for _, f := range fields {
+		v := values[f.env]
+		switch p := f.ptr.(type) {
+		case *string:
+			*p = v
+		case *int:
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid integer %q (from %s)", f.env, v, sources[f.env]))
+				failed[f.env] = true
+				continue
+			}
+			*p = n
+		case *bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid boolean %q (from %s)", f.env, v, sources[f.env]))
+				failed[f.env] = true
+				continue
+			}
+			*p = b
+		}
+	}
+	errs = append(errs, cfg.validate(failed)...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
 	}
-	log.Printf("config loaded: %+v", cfg)
-	return cfg
+	return cfg, nil
 }
 
-func getEnv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// apply переносит значение из одного источника; пустое значение означает,
+// что источник настройку не задает.
+func apply(values, sources map[string]string, f field, v, file, source string) error {
+	if v != "" && file != "" {
+		return fmt.Errorf("%s: both the value and %s_FILE are set (%s)", f.env, f.env, source)
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", f.env, err)
+		}
+		v = strings.TrimRight(string(data), "\r\n")
+		if v == "" {
+			return fmt.Errorf("%s_FILE: %s is empty", f.env, file)
+		}
+		source += " " + file
 	}
-	return def
+	if v != "" {
+		values[f.env], sources[f.env] = v, source
+	}
+	return nil
 }
 
-func getEnvInt(key string, def int) int {
-	if v := os.Getenv(key); v != "" {
-		var iv int
-		_, err := fmt.Sscanf(v, "%d", &iv)
-		if err == nil {
-			return iv
+func (c *Config) validate(skip map[string]bool) []error {
+	var errs []error
+	check := func(ok bool, env, format string, args ...any) {
+		if !ok && !skip[env] {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{env}, args...)...))
+		}
+	}
+	positive := func(env string, v int) { check(v > 0, env, "must be positive, got %d", v) }
+	nonNegative := func(env string, v int) { check(v >= 0, env, "must not be negative, got %d", v) }
+	parsed := func(env string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env, err))
+		}
+	}
+
+	check(c.AppEnv == "production" || c.AppEnv == "development", "APP_ENV", "expected production or development, got %q", c.AppEnv)
+	_, _, err := net.SplitHostPort(c.HTTPPort)
+	check(err == nil, "HTTP_PORT", "expected host:port, got %q", c.HTTPPort)
+	nonNegative("HTTP_TIMEOUT", c.HTTPTimeout)
+	positive("HTTP_MAX_TIMEOUT", c.HTTPMaxTimeout)
+	_, err = ParseRouteTimeouts(c.HTTPRouteTimeouts)
+	parsed("HTTP_ROUTE_TIMEOUTS", err)
+	nonNegative("HTTP_COMPRESS_MIN_SIZE", c.HTTPCompressMin)
+	origins, err := ParseCORSOrigins(c.CORSOrigins)
+	parsed("CORS_ORIGINS", err)
+	// Браузер не примет "*" в ответе на запрос с cookie.
+	check(!c.CORSCredentials || !slices.Contains(origins, "*"), "CORS_CREDENTIALS", "cannot be combined with CORS_ORIGINS=*, list the origins explicitly")
+	for _, m := range SplitList(c.CORSMethods) {
+		check(isToken(m), "CORS_METHODS", "invalid method %q", m)
+	}
+	for _, h := range SplitList(c.CORSHeaders) {
+		check(h == "*" || isToken(h), "CORS_HEADERS", "invalid header %q", h)
+	}
+	nonNegative("CORS_MAX_AGE", c.CORSMaxAge)
+	positive("LOG_BUFFER", c.LogBuffer)
+	_, err = logger.ParseLevel(c.LogLevel)
+	parsed("LOG_LEVEL", err)
+	_, err = logger.ParseSinks(c.LogSinks)
+	parsed("LOG_SINKS", err)
+	_, err = logger.ParseSampling(c.LogSampling)
+	parsed("LOG_SAMPLING", err)
+	policy, err := logger.ParsePolicy(c.LogOverflow)
+	parsed("LOG_OVERFLOW", err)
+	check(policy != logger.PolicySpill || c.LogSpillPath != "", "LOG_SPILL_PATH", "is required for the spill policy")
+	positive("LOG_BLOCK_TIMEOUT_MS", c.LogBlockMS)
+	positive("LOG_DROP_SUMMARY", c.LogSummary)
+	rules, err := logger.ParseRedaction(c.LogRedact)
+	if err == nil {
+		_, err = logger.NewRedactor(rules, c.LogRedactSalt)
+	}
+	parsed("LOG_REDACT", err)
+	positive("SHUTDOWN_TIME", c.ShutdownTime)
+
+	check(c.Storage == "memory" || c.Storage == "eventstore", "STORAGE", "expected memory or eventstore, got %q", c.Storage)
+	check(c.Storage != "eventstore" || c.DataDir != "", "DATA_DIR", "is required for eventstore")
+	nonNegative("SNAPSHOT_EVERY", c.SnapshotEvery)
+
+	positive("WEBHOOK_WORKERS", c.WebhookWorkers)
+	positive("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts)
+	positive("WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	nonNegative("WEBHOOK_DISABLE_AFTER", c.WebhookDisableAfter)
+	nonNegative("EVENTS_REPLAY", c.EventsReplay)
+	positive("EVENTS_HEARTBEAT", c.EventsHeartbeat)
+	positive("GRAPHQL_MAX_DEPTH", c.GraphQLMaxDepth)
+	positive("GRAPHQL_MAX_COMPLEXITY", c.GraphQLMaxComplexity)
+
+	switch c.TraceExporter {
+	case "none":
+	case "otlp":
+		u, err := url.Parse(c.TraceOTLPEndpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"TRACE_OTLP_ENDPOINT", "expected an http(s) URL, got %q", c.TraceOTLPEndpoint)
+	case "file":
+		check(c.TraceFile != "", "TRACE_FILE", "is required for the file exporter")
+	default:
+		check(false, "TRACE_EXPORTER", "expected none, otlp or file, got %q", c.TraceExporter)
+	}
+	check(c.TraceSamplePercent >= 0 && c.TraceSamplePercent <= 100, "TRACE_SAMPLE_PERCENT", "expected 0..100, got %d", c.TraceSamplePercent)
+	check(c.TraceServiceName != "", "TRACE_SERVICE_NAME", "must not be empty")
+	return errs
+}
+
+// String печатает настройки как переменные окружения; секреты скрыты.
+func (c *Config) String() string {
+	var b strings.Builder
+	for i, f := range c.fields() {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		v := f.value()
+		if f.secret && v != "" {
+			v = "***"
+		}
+		fmt.Fprintf(&b, "%s=%s", f.env, strconv.Quote(v))
+	}
+	return b.String()
+}
+
+func (f field) value() string {
+	switch p := f.ptr.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	}
+	return ""
+}
+
+// Changes — отличия новой конфигурации от текущей: что применяется по
+// SIGHUP и что требует перезапуска.
+type Changes struct {
+	Reload  []string
+	Restart []string
+}
+
+func (c *Config) Diff(next *Config) Changes {
+	var ch Changes
+	cur, nxt := c.fields(), next.fields()
+	for i, f := range cur {
+		if f.value() == nxt[i].value() {
+			continue
+		}
+		if f.reload {
+			ch.Reload = append(ch.Reload, f.env)
+		} else {
+			ch.Restart = append(ch.Restart, f.env)
+		}
+	}
+	return ch
+}
+
+// ParseRouteTimeouts разбирает HTTP_ROUTE_TIMEOUTS: пары "МЕТОД /путь=срок"
+// через ";", срок в формате time.ParseDuration, 0 снимает ограничение.
+// Путь записывается шаблоном, как в OpenAPI: "GET /tasks/{id}=2s".
+func ParseRouteTimeouts(spec string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration)
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		route, v, ok := strings.Cut(part, "=")
+		method, path, okRoute := strings.Cut(strings.TrimSpace(route), " ")
+		path = strings.TrimSpace(path)
+		if !ok || !okRoute || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q, expected \"METHOD /path=duration\"", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration in route timeout %q", part)
+		}
+		key := strings.ToUpper(method) + " " + path
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("duplicate route timeout for %q", key)
+		}
+		out[key] = d
+	}
+	return out, nil
+}
+
+// SplitList разбирает список через запятую, пропуская пустые элементы.
+func SplitList(spec string) []string {
+	var out []string
+	for _, v := range strings.Split(spec, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ParseCORSOrigins разбирает CORS_ORIGINS: источники вида scheme://host[:port]
+// через запятую. Хост может начинаться с "*." — тогда подходят любые его
+// поддомены, но не сам домен. Отдельная "*" разрешает любой источник.
+func ParseCORSOrigins(spec string) ([]string, error) {
+	var out []string
+	for _, origin := range SplitList(spec) {
+		origin = strings.ToLower(origin)
+		if origin != "*" {
+			u, err := url.Parse(origin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid origin %q, expected scheme://host[:port] or https://*.domain", origin)
+			}
+			host := strings.TrimPrefix(u.Host, "*.")
+			if (u.Scheme != "http" && u.Scheme != "https") || host == "" || strings.Contains(host, "*") ||
+				u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+				return nil, fmt.Errorf("invalid origin %q, expected scheme://host[:port] or https://*.domain", origin)
+			}
+		}
+		out = append(out, origin)
+	}
+	return out, nil
+}
+
+// isToken — имя метода или заголовка по RFC 9110.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > 0x7e || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
 		}
 	}
-	return def
+	return true
 }
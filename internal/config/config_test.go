@@ -0,0 +1,194 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"taskapi/internal/config"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	yaml := writeFile(t, "config.yaml", `
+# комментарий
+http_port: ":9090"
+log:
+  level: debug   # уровень
+  buffer: 64
+  redact_salt_file: `+writeFile(t, "salt", "s3cret\n")+`
+storage: 'memory'
+webhook:
+  workers: 4
+`)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("WEBHOOK_WORKERS", "6")
+
+	cfg, err := config.Load([]string{"--config", yaml, "--webhook-workers", "8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"default", cfg.ShutdownTime, 10},
+		{"file", cfg.HTTPPort, ":9090"},
+		{"nested file key", cfg.LogBuffer, 64},
+		{"env over file", cfg.LogLevel, "warn"},
+		{"flag over env", cfg.WebhookWorkers, 8},
+		{"secret from file", cfg.LogRedactSalt, "s3cret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, tt.got)
+			}
+		})
+	}
+	if s := cfg.String(); strings.Contains(s, "s3cret") || !strings.Contains(s, `LOG_REDACT_SALT="***"`) {
+		t.Errorf("secret must be masked: %s", s)
+	}
+}
+
+func TestLoad_JSONFile(t *testing.T) {
+	path := writeFile(t, "config.json", `{"log": {"level": "error", "buffer": 32}, "trace_sample_percent": 50, "cors": {"credentials": true}}`)
+	t.Setenv("CONFIG_FILE", path)
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "error" || cfg.LogBuffer != 32 || cfg.TraceSamplePercent != 50 || !cfg.CORSCredentials {
+		t.Errorf("unexpected config %s", cfg)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		file  string
+		args  []string
+		wants []string
+	}{
+		{
+			name:  "aggregated",
+			env:   map[string]string{"LOG_BUFFER": "many", "STORAGE": "redis", "TRACE_SAMPLE_PERCENT": "150"},
+			wants: []string{`LOG_BUFFER: invalid integer "many" (from env)`, "STORAGE: expected memory or eventstore", "TRACE_SAMPLE_PERCENT: expected 0..100"},
+		},
+		{
+			name:  "logger settings",
+			args:  []string{"--log-level", "loud", "--log-sinks", "kafka"},
+			wants: []string{"LOG_LEVEL: unknown log level", "LOG_SINKS: unknown log sink"},
+		},
+		{
+			name:  "app env",
+			args:  []string{"--app-env", "staging"},
+			wants: []string{`APP_ENV: expected production or development, got "staging"`},
+		},
+		{
+			name:  "route timeouts",
+			env:   map[string]string{"HTTP_ROUTE_TIMEOUTS": "GET /tasks=2s;/tasks/{id}=1s", "HTTP_MAX_TIMEOUT": "0"},
+			wants: []string{`HTTP_ROUTE_TIMEOUTS: invalid route timeout "/tasks/{id}=1s"`, "HTTP_MAX_TIMEOUT: must be positive"},
+		},
+		{
+			name:  "compress min size",
+			args:  []string{"--http-compress-min-size", "-1"},
+			wants: []string{"HTTP_COMPRESS_MIN_SIZE: must not be negative, got -1"},
+		},
+		{
+			name:  "cors origins",
+			args:  []string{"--cors-origins", "https://*.example.com, ftp://files.example.com", "--cors-max-age", "-1"},
+			wants: []string{`CORS_ORIGINS: invalid origin "ftp://files.example.com"`, "CORS_MAX_AGE: must not be negative"},
+		},
+		{
+			name:  "malformed cors origin",
+			env:   map[string]string{"CORS_ORIGINS": "http://a b"},
+			wants: []string{`CORS_ORIGINS: invalid origin "http://a b"`},
+		},
+		{
+			name:  "cors credentials with any origin",
+			args:  []string{"--cors-origins", "*", "--cors-credentials", "true"},
+			wants: []string{"CORS_CREDENTIALS: cannot be combined with CORS_ORIGINS=*"},
+		},
+		{
+			name:  "invalid boolean",
+			env:   map[string]string{"CORS_CREDENTIALS": "yes", "CORS_METHODS": "GET,PO ST"},
+			wants: []string{`CORS_CREDENTIALS: invalid boolean "yes" (from env)`, `CORS_METHODS: invalid method "PO ST"`},
+		},
+		{
+			name:  "unknown file key",
+			file:  "htp_port: 1\n",
+			wants: []string{`unknown setting "htp_port"`},
+		},
+		{
+			name:  "value and file",
+			env:   map[string]string{"LOG_REDACT_SALT": "a", "LOG_REDACT_SALT_FILE": "/nonexistent"},
+			wants: []string{"LOG_REDACT_SALT: both the value and LOG_REDACT_SALT_FILE are set"},
+		},
+		{
+			name:  "missing secret file",
+			args:  []string{"--log-redact-salt-file", "/nonexistent/salt"},
+			wants: []string{"LOG_REDACT_SALT_FILE: open /nonexistent/salt"},
+		},
+		{
+			name:  "bad yaml",
+			file:  "log:\n    level: info\n  buffer: 1\n",
+			wants: []string{"line 3: unexpected indentation"},
+		},
+		{
+			name:  "yaml list",
+			file:  "log:\n  - stdout\n",
+			wants: []string{"lists are not supported"},
+		},
+		{
+			name:  "unknown flag",
+			args:  []string{"--port", "1"},
+			wants: []string{"flag provided but not defined"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"--config", writeFile(t, "config.yaml", tt.file)}, args...)
+			}
+			_, err := config.Load(args)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.wants {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_Diff(t *testing.T) {
+	cur, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := config.Load([]string{"--log-level", "debug", "--http-port", ":9000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := cur.Diff(next)
+	if strings.Join(ch.Reload, ",") != "LOG_LEVEL" || strings.Join(ch.Restart, ",") != "HTTP_PORT" {
+		t.Errorf("unexpected changes %+v", ch)
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readFile читает файл конфигурации в плоский набор ключ → значение.
+// Вложенные разделы склеиваются через "_": log: {level: debug} дает
+// log_level. Формат определяется по расширению: .json или .yaml/.yml.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	var out map[string]string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		out, err = parseJSON(data)
+	case ".yaml", ".yml":
+		out, err = parseYAML(data)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension %q, expected .json, .yaml or .yml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+func joinKey(prefix, key string) string {
+	key = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+func parseJSON(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root map[string]any
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	out := make(map[string]string)
+	var walk func(prefix string, m map[string]any) error
+	walk = func(prefix string, m map[string]any) error {
+		for k, v := range m {
+			key := joinKey(prefix, k)
+			switch v := v.(type) {
+			case map[string]any:
+				if err := walk(key, v); err != nil {
+					return err
+				}
+			case string:
+				out[key] = v
+			case json.Number:
+				out[key] = v.String()
+			case bool:
+				out[key] = strconv.FormatBool(v)
+			case nil:
+				out[key] = ""
+			default:
+				return fmt.Errorf("%s: lists are not supported", key)
+			}
+		}
+		return nil
+	}
+	return out, walk("", root)
+}
+
+// parseYAML разбирает подмножество YAML, которого достаточно для
+// конфигурации: вложенные словари, скаляры в кавычках и без, комментарии.
+// Списки, многострочные значения и якоря не поддерживаются.
+func parseYAML(data []byte) (map[string]string, error) {
+	out := make(map[string]string)
+	// stack — открытые разделы: отступ их ключей и префикс.
+	type section struct {
+		indent int
+		prefix string
+	}
+	var stack []section
+	// pending — ключ без значения: раздел, если следующая строка глубже.
+	pending := ""
+	hasPending := false
+	for n, raw := range strings.Split(string(data), "\n") {
+		line := strings.TrimRight(raw, " \r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n+1)
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			return nil, fmt.Errorf("line %d: lists are not supported", n+1)
+		}
+		indent := len(line) - len(trimmed)
+		switch {
+		case len(stack) == 0:
+			stack = append(stack, section{indent: indent})
+		case hasPending && indent > stack[len(stack)-1].indent:
+			stack = append(stack, section{indent: indent, prefix: pending})
+		case hasPending:
+			out[pending] = ""
+		}
+		hasPending = false
+		for len(stack) > 1 && indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		if indent != stack[len(stack)-1].indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", n+1)
+		}
+
+		key, rest, ok := strings.Cut(trimmed, ":")
+		if !ok || key == "" || rest != "" && rest[0] != ' ' {
+			return nil, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		full := joinKey(stack[len(stack)-1].prefix, unquoteKey(key))
+		if stripComment(rest) == "" {
+			pending, hasPending = full, true
+			continue
+		}
+		value, err := yamlScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		out[full] = value
+	}
+	if hasPending {
+		out[pending] = ""
+	}
+	return out, nil
+}
+
+func unquoteKey(k string) string {
+	k = strings.TrimSpace(k)
+	if len(k) >= 2 && (k[0] == '"' || k[0] == '\'') && k[len(k)-1] == k[0] {
+		return k[1 : len(k)-1]
+	}
+	return k
+}
+
+// stripComment отрезает комментарий " #" у значения без кавычек.
+func stripComment(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || s[0] == '"' || s[0] == '\'' {
+		return s
+	}
+	if strings.HasPrefix(s, "#") {
+		return ""
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func yamlScalar(s string) (string, error) {
+	s = stripComment(s)
+	switch {
+	case s == "" || s == "~" || s == "null":
+		return "", nil
+	case s[0] == '"':
+		end := closingQuote(s)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		if rest := stripComment(s[end+1:]); rest != "" {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return strconv.Unquote(s[:end+1])
+	case s[0] == '\'':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			if rest := stripComment(s[i+1:]); rest != "" {
+				return "", fmt.Errorf("unexpected %q after string", rest)
+			}
+			return b.String(), nil
+		}
+		return "", fmt.Errorf("unterminated string %s", s)
+	case s[0] == '[' || s[0] == '{' || s[0] == '|' || s[0] == '>' || s[0] == '&' || s[0] == '*':
+		return "", fmt.Errorf("unsupported value %q", s)
+	}
+	return s, nil
+}
+
+// closingQuote — индекс закрывающей двойной кавычки с учетом экранирования.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
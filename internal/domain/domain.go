@@ -15,6 +15,25 @@ type Task struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description,omitempty"`
 	Status      Status    `json:"status"`
+	Project     string    `json:"project,omitempty"`
+	Assignee    string    `json:"assignee,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// Comment — комментарий к задаче; удаляется вместе с ней.
+type Comment struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Author    string    `json:"author,omitempty"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Revision — версия коллекции задач: меняется при каждом изменении любой
+// задачи. Modified — время последнего изменения, нулевое, если оно неизвестно.
+type Revision struct {
+	Version  uint64
+	Modified time.Time
+}
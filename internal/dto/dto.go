@@ -3,7 +3,42 @@ package dto
 import "taskapi/internal/domain"
 
 type CreateInput struct {
-	Title       string        `json:"title"`
+	Title       string        `json:"title" openapi:"required,minLength=1"`
 	Description string        `json:"description"`
 	Status      domain.Status `json:"status"`
+	Project     string        `json:"project"`
+	Assignee    string        `json:"assignee"`
+	ParentID    string        `json:"parent_id"`
+}
+
+// UpdateInput — частичное обновление: nil-поля не меняются.
+type UpdateInput struct {
+	Title       *string        `json:"title" openapi:"minLength=1"`
+	Description *string        `json:"description"`
+	Status      *domain.Status `json:"status"`
+	Project     *string        `json:"project"`
+	Assignee    *string        `json:"assignee"`
+	ParentID    *string        `json:"parent_id"`
+}
+
+type CommentInput struct {
+	Author string `json:"author"`
+	Body   string `json:"body" openapi:"required,minLength=1"`
+}
+
+// ListFilter — пустые поля не фильтруют. IDs и ParentIDs нужны для
+// пакетной загрузки связанных задач одним запросом.
+type ListFilter struct {
+	Status    *domain.Status
+	Project   string
+	Assignee  string
+	IDs       []string
+	ParentIDs []string
+}
+
+type WebhookInput struct {
+	URL    string   `json:"url" openapi:"required,format=uri"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
 }
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"taskapi/internal/usecase"
+)
+
+// Event — событие задачи с порядковым номером, по которому клиент
+// может продолжить поток после переподключения.
+type Event struct {
+	ID uint64
+	usecase.TaskEvent
+}
+
+type Subscriber struct {
+	C    chan Event
+	hub  *Hub
+	once sync.Once
+}
+
+// Close отписывает подписчика; канал C закрывается.
+func (s *Subscriber) Close() {
+	s.hub.remove(s)
+}
+
+// Hub рассылает события задач подписчикам и хранит последние
+// события в кольцевом буфере для повторной отправки.
+type Hub struct {
+	mu      sync.Mutex
+	seq     uint64
+	buf     []Event
+	size    int
+	subs    map[*Subscriber]struct{}
+	chanCap int
+	closed  bool
+}
+
+func NewHub(replay int) *Hub {
+	if replay <= 0 {
+		replay = 1
+	}
+	return &Hub{
+		size:    replay,
+		subs:    make(map[*Subscriber]struct{}),
+		chanCap: 64,
+	}
+}
+
+func (h *Hub) Publish(ctx context.Context, ev usecase.TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.seq++
+	e := Event{ID: h.seq, TaskEvent: ev}
+	if len(h.buf) == h.size {
+		copy(h.buf, h.buf[1:])
+		h.buf = h.buf[:len(h.buf)-1]
+	}
+	h.buf = append(h.buf, e)
+
+	for s := range h.subs {
+		select {
+		case s.C <- e:
+		default:
+			// Медленный клиент отключается и догонит по Last-Event-ID.
+			h.drop(s)
+		}
+	}
+}
+
+// Subscribe возвращает подписчика и события из буфера с номером больше
+// lastID (lastID == 0 — только новые события). complete=false означает,
+// что часть событий после lastID уже вытеснена из буфера и клиенту нужно
+// перечитать состояние целиком.
+func (h *Hub) Subscribe(lastID uint64) (sub *Subscriber, replay []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub = &Subscriber{C: make(chan Event, h.chanCap), hub: h}
+	if h.closed {
+		close(sub.C)
+		return sub, nil, true
+	}
+	h.subs[sub] = struct{}{}
+	if lastID == 0 {
+		return sub, nil, true
+	}
+
+	complete = true
+	switch {
+	case lastID > h.seq:
+		// Номер из прошлой жизни процесса — отдаем буфер целиком.
+		lastID = 0
+		complete = false
+	case len(h.buf) > 0 && h.buf[0].ID > lastID+1:
+		complete = false
+	}
+	for _, e := range h.buf {
+		if e.ID > lastID {
+			replay = append(replay, e)
+		}
+	}
+	return sub, replay, complete
+}
+
+// Close отключает всех подписчиков; используется при остановке сервера,
+// чтобы долгие соединения не держали graceful shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		h.drop(s)
+	}
+}
+
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+func (h *Hub) remove(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(s)
+}
+
+// drop вызывается под h.mu.
+func (h *Hub) drop(s *Subscriber) {
+	delete(h.subs, s)
+	s.once.Do(func() { close(s.C) })
+}
@@ -0,0 +1,74 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"taskapi/internal/domain"
+	"taskapi/internal/events"
+	"taskapi/internal/usecase"
+)
+
+func publishN(h *events.Hub, n int) {
+	for i := 0; i < n; i++ {
+		h.Publish(context.Background(), usecase.TaskEvent{Type: usecase.EventTaskCreated, Task: domain.Task{ID: "t"}})
+	}
+}
+
+func TestHub_Replay(t *testing.T) {
+	h := events.NewHub(3)
+	publishN(h, 5)
+
+	tests := []struct {
+		name         string
+		lastID       uint64
+		wantReplay   int
+		wantComplete bool
+	}{
+		{"live only", 0, 0, true},
+		{"up to date", 5, 0, true},
+		{"resume inside buffer", 3, 2, true},
+		{"resume with gap", 1, 3, false},
+		{"id from previous run", 42, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, complete := h.Subscribe(tt.lastID)
+			defer sub.Close()
+			if len(replay) != tt.wantReplay {
+				t.Errorf("expected %d replayed events, got %d", tt.wantReplay, len(replay))
+			}
+			if complete != tt.wantComplete {
+				t.Errorf("expected complete=%v, got %v", tt.wantComplete, complete)
+			}
+		})
+	}
+}
+
+func TestHub_SlowSubscriberIsDropped(t *testing.T) {
+	h := events.NewHub(10)
+	sub, _, _ := h.Subscribe(0)
+
+	publishN(h, 1000)
+	if h.Subscribers() != 0 {
+		t.Errorf("expected slow subscriber to be dropped")
+	}
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n == 0 || n >= 1000 {
+		t.Errorf("expected a partially filled closed channel, got %d events", n)
+	}
+	sub.Close()
+}
+
+func TestHub_CloseDisconnectsSubscribers(t *testing.T) {
+	h := events.NewHub(10)
+	sub, _, _ := h.Subscribe(0)
+	h.Close()
+	if _, ok := <-sub.C; ok {
+		t.Errorf("expected channel to be closed")
+	}
+}
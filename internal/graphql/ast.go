@@ -0,0 +1,119 @@
+package graphql
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+type Operation struct {
+	Kind      string // query | mutation
+	Name      string
+	Variables []*VariableDef
+	Selection []Selection
+	Loc       Location
+}
+
+type VariableDef struct {
+	Name    string
+	Type    TypeRef
+	Default *Value
+	Loc     Location
+}
+
+// TypeRef — ссылка на тип в определении переменной: Name, [T] или T!.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+type Selection interface {
+	isSelection()
+}
+
+type FieldNode struct {
+	Alias      string
+	Name       string
+	Arguments  []*Argument
+	Directives []*Directive
+	Selection  []Selection
+	Loc        Location
+}
+
+func (f *FieldNode) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Loc        Location
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	Selection     []Selection
+	Loc           Location
+}
+
+func (*FieldNode) isSelection()      {}
+func (*FragmentSpread) isSelection() {}
+func (*InlineFragment) isSelection() {}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	Selection     []Selection
+	Loc           Location
+}
+
+type Argument struct {
+	Name  string
+	Value Value
+	Loc   Location
+}
+
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Loc       Location
+}
+
+type ValueKind int
+
+const (
+	VariableKind ValueKind = iota
+	IntKind
+	FloatKind
+	StringKind
+	BooleanKind
+	NullKind
+	EnumKind
+	ListKind
+	ObjectKind
+)
+
+// Value — литерал в запросе. Raw хранит текст для скаляров, имя для
+// переменных и enum; List и Fields — для составных значений.
+type Value struct {
+	Kind   ValueKind
+	Raw    string
+	List   []Value
+	Fields []ObjectField
+	Loc    Location
+}
+
+type ObjectField struct {
+	Name  string
+	Value Value
+}
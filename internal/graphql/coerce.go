@@ -0,0 +1,266 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+func (s *Schema) resolveTypeRef(ref TypeRef) Type {
+	var t Type
+	if ref.Elem != nil {
+		elem := s.resolveTypeRef(*ref.Elem)
+		if elem == nil {
+			return nil
+		}
+		t = NewList(elem)
+	} else {
+		named, ok := s.types[ref.Name]
+		if !ok {
+			return nil
+		}
+		t = named
+	}
+	if ref.NonNull {
+		t = NewNonNull(t)
+	}
+	return t
+}
+
+func isInputType(t Type) bool {
+	switch unwrap(t).(type) {
+	case *Scalar, *Enum, *InputObject:
+		return true
+	}
+	return false
+}
+
+func (s *Schema) coerceVariables(defs []*VariableDef, input map[string]any) (map[string]any, []*Error) {
+	out := make(map[string]any, len(defs))
+	var errs []*Error
+	for _, def := range defs {
+		t := s.resolveTypeRef(def.Type)
+		v, present := input[def.Name]
+		switch {
+		case !present && def.Default != nil:
+			val, _, err := coerceLiteral(t, *def.Default, nil)
+			if err != nil {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" has invalid default value: %v", def.Name, err), Locations: []Location{def.Loc}})
+				continue
+			}
+			out[def.Name] = val
+		case !present:
+			if _, nn := t.(*NonNull); nn {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", def.Name, t.String()), Locations: []Location{def.Loc}})
+			}
+		default:
+			val, err := coerceInput(t, v)
+			if err != nil {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value: %v", def.Name, err), Locations: []Location{def.Loc}})
+				continue
+			}
+			out[def.Name] = val
+		}
+	}
+	return out, errs
+}
+
+// coerceInput приводит значение из JSON-переменных к типу схемы.
+func coerceInput(t Type, v any) (any, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null value of type %s", t)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch tt := t.(type) {
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			item, err := coerceInput(tt.Of, v)
+			if err != nil {
+				return nil, err
+			}
+			return []any{item}, nil
+		}
+		out := make([]any, len(items))
+		for i, it := range items {
+			c, err := coerceInput(tt.Of, it)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		return tt.ParseValue(v)
+	case *Enum:
+		name, ok := v.(string)
+		if ev := tt.byName(name); ok && ev != nil {
+			return ev.Value, nil
+		}
+		return nil, fmt.Errorf("value %v does not exist in %q enum", v, tt.Name)
+	case *InputObject:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object for %s", tt.Name)
+		}
+		for k := range m {
+			if inputField(tt, k) == nil {
+				return nil, fmt.Errorf("field %q is not defined by type %q", k, tt.Name)
+			}
+		}
+		out := make(map[string]any, len(tt.Fields))
+		for _, f := range tt.Fields {
+			fv, present := m[f.Name]
+			if !present {
+				if f.HasDefault {
+					out[f.Name] = f.DefaultValue
+				} else if _, nn := f.Type.(*NonNull); nn {
+					return nil, fmt.Errorf("field %s.%s of required type %s was not provided", tt.Name, f.Name, f.Type)
+				}
+				continue
+			}
+			c, err := coerceInput(f.Type, fv)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", tt.Name, f.Name, err)
+			}
+			out[f.Name] = c
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("type %s is not an input type", t)
+}
+
+// coerceLiteral приводит литерал из текста запроса; present=false —
+// значение ссылается на переменную, которую не передали.
+func coerceLiteral(t Type, v Value, vars map[string]any) (val any, present bool, err error) {
+	if v.Kind == VariableKind {
+		val, present = vars[v.Raw]
+		if _, nn := t.(*NonNull); nn && present && val == nil {
+			return nil, true, fmt.Errorf("expected non-null value of type %s, found null", t)
+		}
+		return val, present, nil
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if v.Kind == NullKind {
+			return nil, true, fmt.Errorf("expected value of type %s, found null", t)
+		}
+		return coerceLiteral(nn.Of, v, vars)
+	}
+	if v.Kind == NullKind {
+		return nil, true, nil
+	}
+	switch tt := t.(type) {
+	case *List:
+		if v.Kind != ListKind {
+			item, _, err := coerceLiteral(tt.Of, v, vars)
+			if err != nil {
+				return nil, true, err
+			}
+			return []any{item}, true, nil
+		}
+		out := make([]any, 0, len(v.List))
+		for _, it := range v.List {
+			c, _, err := coerceLiteral(tt.Of, it, vars)
+			if err != nil {
+				return nil, true, err
+			}
+			out = append(out, c)
+		}
+		return out, true, nil
+	case *Scalar:
+		out, err := tt.ParseLiteral(v)
+		return out, true, err
+	case *Enum:
+		if v.Kind == EnumKind {
+			if ev := tt.byName(v.Raw); ev != nil {
+				return ev.Value, true, nil
+			}
+		}
+		return nil, true, fmt.Errorf("value %q does not exist in %q enum", v.Raw, tt.Name)
+	case *InputObject:
+		if v.Kind != ObjectKind {
+			return nil, true, fmt.Errorf("expected an object for %s", tt.Name)
+		}
+		for _, of := range v.Fields {
+			if inputField(tt, of.Name) == nil {
+				return nil, true, fmt.Errorf("field %q is not defined by type %q", of.Name, tt.Name)
+			}
+		}
+		out := make(map[string]any, len(tt.Fields))
+		for _, f := range tt.Fields {
+			var node *Value
+			for i := range v.Fields {
+				if v.Fields[i].Name == f.Name {
+					node = &v.Fields[i].Value
+				}
+			}
+			var (
+				c       any
+				present bool
+				err     error
+			)
+			if node != nil {
+				c, present, err = coerceLiteral(f.Type, *node, vars)
+				if err != nil {
+					return nil, true, fmt.Errorf("field %s.%s: %w", tt.Name, f.Name, err)
+				}
+			}
+			switch {
+			case present:
+				out[f.Name] = c
+			case f.HasDefault:
+				out[f.Name] = f.DefaultValue
+			default:
+				if _, nn := f.Type.(*NonNull); nn {
+					return nil, true, fmt.Errorf("field %s.%s of required type %s was not provided", tt.Name, f.Name, f.Type)
+				}
+			}
+		}
+		return out, true, nil
+	}
+	return nil, true, fmt.Errorf("type %s is not an input type", t)
+}
+
+func coerceArgs(defs []*InputValue, nodes []*Argument, vars map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(defs))
+	for _, def := range defs {
+		var node *Argument
+		for _, a := range nodes {
+			if a.Name == def.Name {
+				node = a
+			}
+		}
+		present := false
+		if node != nil {
+			v, p, err := coerceLiteral(def.Type, node.Value, vars)
+			if err != nil {
+				return nil, fmt.Errorf("Argument %q has invalid value: %v", def.Name, err)
+			}
+			if p {
+				out[def.Name] = v
+				present = true
+			}
+		}
+		if present {
+			continue
+		}
+		if def.HasDefault {
+			out[def.Name] = def.DefaultValue
+		} else if _, nn := def.Type.(*NonNull); nn {
+			return nil, fmt.Errorf("Argument %q of required type %q was not provided.", def.Name, def.Type.String())
+		}
+	}
+	return out, nil
+}
+
+func inputField(t *InputObject, name string) *InputValue {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
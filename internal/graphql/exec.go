@@ -0,0 +1,499 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Execute разбирает, валидирует и выполняет запрос. Ошибки разбора и
+// валидации возвращаются без data; ошибки резолверов — вместе с частичным
+// результатом, как требует спецификация.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{toError(err)}}
+	}
+	op, gerr := selectOperation(doc, req.OperationName)
+	if gerr != nil {
+		return &Response{Errors: []*Error{gerr}}
+	}
+
+	var root *Object
+	switch op.Kind {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("Schema does not support %s operations.", op.Kind), Locations: []Location{op.Loc}}}}
+	}
+
+	v := &validator{schema: s, doc: doc, vars: make(map[string]*VariableDef)}
+	if errs := v.validate(op, root); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	vars, errs := s.coerceVariables(op.Variables, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	e := &executor{schema: s, doc: doc, vars: vars}
+	res, _ := e.execSelection(ctx, root, []any{nil}, op.Selection, [][]any{nil})
+	if res[0] == nil {
+		// Выполнение началось, поэтому "data": null должно попасть в ответ.
+		return &Response{Data: json.RawMessage("null"), Errors: e.errs}
+	}
+	return &Response{Data: res[0], Errors: e.errs}
+}
+
+func toError(err error) *Error {
+	if ge, ok := err.(*Error); ok {
+		return ge
+	}
+	return &Error{Message: err.Error()}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, *Error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+// Состояние позиции при выполнении: значение есть, null из-за уже
+// сообщенной ошибки, или null, который нужно поднять к ближайшему
+// nullable-родителю.
+const (
+	stateOK uint8 = iota
+	stateNullErr
+	stateFailed
+)
+
+type executor struct {
+	schema *Schema
+	doc    *Document
+	vars   map[string]any
+	errs   []*Error
+}
+
+func (e *executor) addError(msg string, loc Location, path []any) {
+	e.errs = append(e.errs, &Error{Message: msg, Locations: []Location{loc}, Path: path})
+}
+
+type fieldGroup struct {
+	key   string
+	nodes []*FieldNode
+}
+
+func (e *executor) collectFields(t *Object, sel []Selection, visited map[string]bool, groups *[]*fieldGroup, index map[string]*fieldGroup) {
+	for _, s := range sel {
+		switch n := s.(type) {
+		case *FieldNode:
+			if !e.included(n.Directives) {
+				continue
+			}
+			key := n.ResponseKey()
+			if g, ok := index[key]; ok {
+				g.nodes = append(g.nodes, n)
+				continue
+			}
+			g := &fieldGroup{key: key, nodes: []*FieldNode{n}}
+			index[key] = g
+			*groups = append(*groups, g)
+		case *InlineFragment:
+			if !e.included(n.Directives) || (n.TypeCondition != "" && n.TypeCondition != t.Name) {
+				continue
+			}
+			e.collectFields(t, n.Selection, visited, groups, index)
+		case *FragmentSpread:
+			if visited[n.Name] || !e.included(n.Directives) {
+				continue
+			}
+			visited[n.Name] = true
+			f := e.doc.Fragments[n.Name]
+			if f == nil || f.TypeCondition != t.Name {
+				continue
+			}
+			e.collectFields(t, f.Selection, visited, groups, index)
+		}
+	}
+}
+
+func (e *executor) included(dirs []*Directive) bool {
+	for _, d := range dirs {
+		var def *DirectiveDef
+		switch d.Name {
+		case "skip":
+			def = skipDirective
+		case "include":
+			def = includeDirective
+		default:
+			continue
+		}
+		args, err := coerceArgs(def.Args, d.Arguments, e.vars)
+		if err != nil {
+			continue
+		}
+		cond, _ := args["if"].(bool)
+		if (d.Name == "skip") == cond {
+			return false
+		}
+	}
+	return true
+}
+
+// execSelection выполняет набор полей сразу для всех sources одного
+// уровня: так поле с BatchFunc вызывается один раз на уровень, а не на
+// каждый объект.
+func (e *executor) execSelection(ctx context.Context, t *Object, sources []any, sel []Selection, paths [][]any) ([]any, []uint8) {
+	var groups []*fieldGroup
+	e.collectFields(t, sel, make(map[string]bool), &groups, make(map[string]*fieldGroup))
+
+	n := len(sources)
+	objs := make([]*orderedMap, n)
+	state := make([]uint8, n)
+	for i := range objs {
+		objs[i] = &orderedMap{}
+	}
+
+	for _, g := range groups {
+		node := g.nodes[0]
+		field := e.fieldDef(t, node.Name)
+		if field == nil {
+			continue
+		}
+		fieldPaths := make([][]any, n)
+		for i := range paths {
+			fieldPaths[i] = extendPath(paths[i], g.key)
+		}
+		if field == e.schema.typenameField {
+			for i := range objs {
+				objs[i].set(g.key, t.Name)
+			}
+			continue
+		}
+
+		vals := make([]any, n)
+		hadErr := make([]bool, n)
+		args, err := coerceArgs(field.Args, node.Arguments, e.vars)
+		switch {
+		case err != nil:
+			for i := range vals {
+				hadErr[i] = true
+				e.addError(err.Error(), node.Loc, fieldPaths[i])
+			}
+		case field.Batch != nil:
+			out, err := field.Batch(ctx, sources, args)
+			if err == nil && len(out) != n {
+				err = fmt.Errorf("batch resolver for %s.%s returned %d values for %d sources", t.Name, field.Name, len(out), n)
+			}
+			if err != nil {
+				for i := range vals {
+					hadErr[i] = true
+					e.addError(err.Error(), node.Loc, fieldPaths[i])
+				}
+			} else {
+				vals = out
+			}
+		default:
+			for i, src := range sources {
+				v, err := e.resolve(ctx, field, src, args)
+				if err != nil {
+					hadErr[i] = true
+					e.addError(err.Error(), node.Loc, fieldPaths[i])
+					continue
+				}
+				vals[i] = v
+			}
+		}
+
+		res, st := e.complete(ctx, field, field.Type, vals, hadErr, mergeSelections(g.nodes), node, fieldPaths)
+		for i := range res {
+			if st[i] == stateFailed {
+				state[i] = stateFailed
+				continue
+			}
+			objs[i].set(g.key, res[i])
+		}
+	}
+
+	out := make([]any, n)
+	for i := range objs {
+		if state[i] != stateFailed {
+			out[i] = objs[i]
+		}
+	}
+	return out, state
+}
+
+func (e *executor) fieldDef(t *Object, name string) *Field {
+	switch name {
+	case "__typename":
+		return e.schema.typenameField
+	case "__schema":
+		if t == e.schema.Query {
+			return e.schema.schemaField
+		}
+	case "__type":
+		if t == e.schema.Query {
+			return e.schema.typeField
+		}
+	}
+	return t.Field(name)
+}
+
+func (e *executor) resolve(ctx context.Context, f *Field, src any, args map[string]any) (any, error) {
+	if f.Resolve != nil {
+		return f.Resolve(ctx, src, args)
+	}
+	if m, ok := src.(map[string]any); ok {
+		return m[f.Name], nil
+	}
+	return nil, fmt.Errorf("no resolver for field %q", f.Name)
+}
+
+func mergeSelections(nodes []*FieldNode) []Selection {
+	if len(nodes) == 1 {
+		return nodes[0].Selection
+	}
+	var out []Selection
+	for _, n := range nodes {
+		out = append(out, n.Selection...)
+	}
+	return out
+}
+
+func (e *executor) complete(ctx context.Context, field *Field, t Type, vals []any, hadErr []bool, sel []Selection, node *FieldNode, paths [][]any) ([]any, []uint8) {
+	n := len(vals)
+	res := make([]any, n)
+	st := make([]uint8, n)
+	nullState := func(i int) uint8 {
+		if hadErr[i] {
+			return stateNullErr
+		}
+		return stateOK
+	}
+
+	switch tt := t.(type) {
+	case *NonNull:
+		res, st = e.complete(ctx, field, tt.Of, vals, hadErr, sel, node, paths)
+		for i := range res {
+			if res[i] != nil {
+				continue
+			}
+			if st[i] == stateOK {
+				e.addError(fmt.Sprintf("Cannot return null for non-nullable field %s.", field.Name), node.Loc, paths[i])
+			}
+			st[i] = stateFailed
+		}
+		return res, st
+
+	case *List:
+		var items []any
+		var itemPaths [][]any
+		offsets := make([]int, n+1)
+		for i, v := range vals {
+			offsets[i] = len(items)
+			if isNil(v) {
+				continue
+			}
+			list, ok := toSlice(v)
+			if !ok {
+				e.addError(fmt.Sprintf("Expected a list for field %s.", field.Name), node.Loc, paths[i])
+				hadErr[i] = true
+				vals[i] = nil
+				continue
+			}
+			for j, it := range list {
+				items = append(items, it)
+				itemPaths = append(itemPaths, extendPath(paths[i], j))
+			}
+		}
+		offsets[n] = len(items)
+		itemRes, itemSt := e.complete(ctx, field, tt.Of, items, make([]bool, len(items)), sel, node, itemPaths)
+		for i, v := range vals {
+			if isNil(v) {
+				st[i] = nullState(i)
+				continue
+			}
+			list := make([]any, 0, offsets[i+1]-offsets[i])
+			failed := false
+			for j := offsets[i]; j < offsets[i+1]; j++ {
+				if itemSt[j] == stateFailed {
+					failed = true
+					break
+				}
+				list = append(list, itemRes[j])
+			}
+			if failed {
+				st[i] = stateNullErr
+				continue
+			}
+			res[i] = list
+		}
+		return res, st
+
+	case *Scalar:
+		for i, v := range vals {
+			if isNil(v) {
+				st[i] = nullState(i)
+				continue
+			}
+			out, err := tt.Serialize(v)
+			if err != nil {
+				e.addError(err.Error(), node.Loc, paths[i])
+				st[i] = stateNullErr
+				continue
+			}
+			res[i] = out
+		}
+		return res, st
+
+	case *Enum:
+		for i, v := range vals {
+			if isNil(v) {
+				st[i] = nullState(i)
+				continue
+			}
+			ev := tt.byValue(v)
+			if ev == nil {
+				e.addError(fmt.Sprintf("Enum %q cannot represent value: %v", tt.Name, v), node.Loc, paths[i])
+				st[i] = stateNullErr
+				continue
+			}
+			res[i] = ev.Name
+		}
+		return res, st
+
+	case *Object:
+		var srcs []any
+		var srcPaths [][]any
+		var idx []int
+		for i, v := range vals {
+			if isNil(v) {
+				st[i] = nullState(i)
+				continue
+			}
+			srcs = append(srcs, v)
+			srcPaths = append(srcPaths, paths[i])
+			idx = append(idx, i)
+		}
+		if len(srcs) == 0 {
+			return res, st
+		}
+		objs, objSt := e.execSelection(ctx, tt, srcs, sel, srcPaths)
+		for k, i := range idx {
+			if objSt[k] == stateFailed {
+				st[i] = stateNullErr
+				continue
+			}
+			res[i] = objs[k]
+		}
+		return res, st
+	}
+	return res, st
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func toSlice(v any) ([]any, bool) {
+	if l, ok := v.([]any); ok {
+		return l, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
+
+func extendPath(path []any, elem any) []any {
+	out := make([]any, len(path)+1)
+	copy(out, path)
+	out[len(path)] = elem
+	return out
+}
+
+// orderedMap сохраняет порядок полей как в запросе.
+type orderedMap struct {
+	keys []string
+	vals []any
+}
+
+func (m *orderedMap) set(k string, v any) {
+	for i, key := range m.keys {
+		if key == k {
+			m.vals[i] = v
+			return
+		}
+	}
+	m.keys = append(m.keys, k)
+	m.vals = append(m.vals, v)
+}
+
+// Get возвращает значение поля ответа; удобно в тестах.
+func (m *orderedMap) Get(k string) any {
+	for i, key := range m.keys {
+		if key == k {
+			return m.vals[i]
+		}
+	}
+	return nil
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(m.vals[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,269 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"taskapi/internal/graphql"
+)
+
+type node struct {
+	ID       string
+	Children []string
+}
+
+func testSchema(t *testing.T, batches *int) *graphql.Schema {
+	t.Helper()
+	nodes := map[string]node{
+		"a": {ID: "a", Children: []string{"b", "c"}},
+		"b": {ID: "b", Children: []string{"d"}},
+		"c": {ID: "c"},
+		"d": {ID: "d"},
+	}
+	n := &graphql.Object{Name: "Node"}
+	n.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NewNonNull(graphql.ID), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return src.(node).ID, nil
+		}},
+		{Name: "children", Type: graphql.NewList(graphql.NewNonNull(n)), Batch: func(ctx context.Context, srcs []any, _ map[string]any) ([]any, error) {
+			*batches++
+			out := make([]any, len(srcs))
+			for i, s := range srcs {
+				var list []node
+				for _, id := range s.(node).Children {
+					list = append(list, nodes[id])
+				}
+				out[i] = list
+			}
+			return out, nil
+		}},
+		{Name: "fail", Type: graphql.NewNonNull(graphql.String), Resolve: func(context.Context, any, map[string]any) (any, error) {
+			return nil, errors.New("boom")
+		}},
+	}
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+		{
+			Name: "node",
+			Type: n,
+			Args: []*graphql.InputValue{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+				v, ok := nodes[args["id"].(string)]
+				if !ok {
+					return nil, nil
+				}
+				return v, nil
+			},
+		},
+		{
+			Name: "echo",
+			Type: graphql.String,
+			Args: []*graphql.InputValue{{Name: "msg", Type: graphql.String, DefaultValue: "hi", HasDefault: true}},
+			Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+				return args["msg"], nil
+			},
+		},
+	}}
+	s, err := graphql.NewSchema(query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSchema_Execute(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         graphql.Request
+		limits      graphql.Limits
+		wantData    string
+		wantErr     string
+		wantBatches int
+	}{
+		{
+			name:     "aliases and default args",
+			req:      graphql.Request{Query: `{ a: echo b: echo(msg: "yo") }`},
+			wantData: `{"a":"hi","b":"yo"}`,
+		},
+		{
+			name: "variables and fragments",
+			req: graphql.Request{
+				Query:     `query Q($id: ID!) { node(id: $id) { ...F } } fragment F on Node { id __typename }`,
+				Variables: map[string]any{"id": "c"},
+			},
+			wantData: `{"node":{"id":"c","__typename":"Node"}}`,
+		},
+		{
+			name:        "batch once per level",
+			req:         graphql.Request{Query: `{ node(id: "a") { children { id children { id children { id } } } } }`},
+			wantData:    `{"node":{"children":[{"id":"b","children":[{"id":"d","children":[]}]},{"id":"c","children":[]}]}}`,
+			wantBatches: 3,
+		},
+		{
+			name: "skip and include",
+			req: graphql.Request{
+				Query:     `query($s: Boolean!) { echo @skip(if: $s) b: echo @include(if: true) }`,
+				Variables: map[string]any{"s": true},
+			},
+			wantData: `{"b":"hi"}`,
+		},
+		{
+			name:     "non-null propagates to nullable parent",
+			req:      graphql.Request{Query: `{ node(id: "a") { id fail } echo }`},
+			wantData: `{"node":null,"echo":"hi"}`,
+			wantErr:  "boom",
+		},
+		{
+			name:     "introspection",
+			req:      graphql.Request{Query: `{ __type(name: "Node") { kind fields { name } } }`},
+			wantData: `{"__type":{"kind":"OBJECT","fields":[{"name":"id"},{"name":"children"},{"name":"fail"}]}}`,
+		},
+		{
+			name:    "parse error",
+			req:     graphql.Request{Query: `{ echo `},
+			wantErr: "Syntax Error",
+		},
+		{
+			name:    "unknown field",
+			req:     graphql.Request{Query: `{ nope }`},
+			wantErr: `Cannot query field "nope"`,
+		},
+		{
+			name:    "missing variable",
+			req:     graphql.Request{Query: `query($id: ID!) { node(id: $id) { id } }`},
+			wantErr: `"$id"`,
+		},
+		{
+			name:    "depth limit",
+			req:     graphql.Request{Query: `{ node(id: "a") { children { children { id } } } }`},
+			limits:  graphql.Limits{MaxDepth: 2},
+			wantErr: "depth",
+		},
+		{
+			name:    "complexity limit",
+			req:     graphql.Request{Query: `{ node(id: "a") { children { children { id } } } }`},
+			limits:  graphql.Limits{MaxComplexity: 20, ListFactor: 10},
+			wantErr: "complexity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches int
+			s := testSchema(t, &batches)
+			s.Limits = tt.limits
+			resp := s.Execute(context.Background(), tt.req)
+
+			if tt.wantData != "" {
+				data, err := json.Marshal(resp.Data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != tt.wantData {
+					t.Errorf("expected data %s, got %s", tt.wantData, data)
+				}
+			} else if resp.Data != nil {
+				t.Errorf("expected no data, got %v", resp.Data)
+			}
+			var msgs []string
+			for _, e := range resp.Errors {
+				msgs = append(msgs, e.Message)
+			}
+			if tt.wantErr == "" && len(msgs) > 0 {
+				t.Errorf("unexpected errors: %v", msgs)
+			}
+			if tt.wantErr != "" && !strings.Contains(strings.Join(msgs, "\n"), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, msgs)
+			}
+			if tt.wantBatches != 0 && batches != tt.wantBatches {
+				t.Errorf("expected %d batch calls, got %d", tt.wantBatches, batches)
+			}
+		})
+	}
+}
+
+// introspectionQuery — запрос GraphiQL и других стандартных клиентов.
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name } mutationType { name } subscriptionType { name }
+    types { ...FullType }
+    directives { name description isRepeatable locations args { ...InputValue } }
+  }
+}
+fragment FullType on __Type {
+  kind name description specifiedByURL
+  fields(includeDeprecated: true) { name description args { ...InputValue } type { ...TypeRef } isDeprecated deprecationReason }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+  possibleTypes { ...TypeRef }
+}
+fragment InputValue on __InputValue { name description type { ...TypeRef } defaultValue }
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } } } }
+}`
+
+func TestSchema_Limits(t *testing.T) {
+	// Цепочка фрагментов, каждый из которых дважды ссылается на следующий:
+	// без запоминания стоимости проверка занимает 2^n шагов.
+	var bomb strings.Builder
+	bomb.WriteString(`{ node(id: "a") { ...F0 } }`)
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&bomb, " fragment F%d on Node { id children { ...F%d ...F%d } }", i, i+1, i+1)
+	}
+	bomb.WriteString(" fragment F60 on Node { id }")
+
+	// Каждый уровень fields { type { ofType } } добавляет 3 к глубине, а
+	// псевдонимы a и b удваивают ответ.
+	nest := func(levels int, aliases ...string) string {
+		inner := "name"
+		for i := 0; i < levels; i++ {
+			var sel []string
+			for _, a := range aliases {
+				sel = append(sel, a+"type { ofType { "+inner+" } }")
+			}
+			inner = "fields { " + strings.Join(sel, " ") + " }"
+		}
+		return `{ __type(name: "Node") { ` + inner + ` } }`
+	}
+	nested, aliased := nest(6, ""), nest(4, "a: ", "b: ")
+
+	tests := []struct {
+		name    string
+		query   string
+		limits  graphql.Limits
+		wantErr string
+	}{
+		{"standard introspection under tight limits", introspectionQuery, graphql.Limits{MaxDepth: 2, MaxComplexity: 20}, ""},
+		{"fragment chain", bomb.String(), graphql.Limits{MaxComplexity: 1000}, "complexity"},
+		{"fragment chain without limits", bomb.String(), graphql.Limits{}, ""},
+		{"deep introspection", nested, graphql.Limits{}, "depth exceeds the maximum of 15"},
+		{"aliased introspection", aliased, graphql.Limits{}, "Introspection complexity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testSchema(t, new(int))
+			s.Limits = tt.limits
+			start := time.Now()
+			resp := s.Execute(context.Background(), graphql.Request{Query: tt.query})
+			if took := time.Since(start); took > time.Second {
+				t.Errorf("execution took %s", took)
+			}
+			var msgs []string
+			for _, e := range resp.Errors {
+				msgs = append(msgs, e.Message)
+			}
+			if tt.wantErr == "" && len(msgs) > 0 {
+				t.Errorf("unexpected errors: %v", msgs)
+			}
+			if tt.wantErr != "" && !strings.Contains(strings.Join(msgs, "\n"), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, msgs)
+			}
+		})
+	}
+}
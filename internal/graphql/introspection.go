@@ -0,0 +1,319 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func typeKind(t Type) string {
+	switch t.(type) {
+	case *Scalar:
+		return "SCALAR"
+	case *Object:
+		return "OBJECT"
+	case *Enum:
+		return "ENUM"
+	case *InputObject:
+		return "INPUT_OBJECT"
+	case *List:
+		return "LIST"
+	case *NonNull:
+		return "NON_NULL"
+	}
+	return ""
+}
+
+func deprecated(reason string) bool { return reason != "" }
+
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func includeDeprecated(args map[string]any) bool {
+	v, _ := args["includeDeprecated"].(bool)
+	return v
+}
+
+func (s *Schema) addIntrospection() error {
+	typeKindEnum := &Enum{Name: "__TypeKind", Description: "An enum describing what kind of type a given `__Type` is."}
+	for _, k := range []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"} {
+		typeKindEnum.Values = append(typeKindEnum.Values, &EnumValue{Name: k, Value: k})
+	}
+	locationEnum := &Enum{Name: "__DirectiveLocation", Description: "A Directive can be adjacent to many parts of the GraphQL language."}
+	for _, l := range []string{"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION",
+		"SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE", "UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION"} {
+		locationEnum.Values = append(locationEnum.Values, &EnumValue{Name: l, Value: l})
+	}
+
+	typeT := &Object{Name: "__Type", Description: "The fundamental unit of any GraphQL Schema is the type."}
+	fieldT := &Object{Name: "__Field", Description: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	inputValueT := &Object{Name: "__InputValue", Description: "Arguments provided to Fields or Directives and the input fields of an InputObject are represented as Input Values."}
+	enumValueT := &Object{Name: "__EnumValue", Description: "One possible value for a given Enum."}
+	directiveT := &Object{Name: "__Directive", Description: "A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document."}
+	schemaT := &Object{Name: "__Schema", Description: "A GraphQL Schema defines the capabilities of a GraphQL server."}
+
+	nnString := NewNonNull(String)
+	nnBool := NewNonNull(Boolean)
+	inclDep := []*InputValue{{Name: "includeDeprecated", Type: Boolean, DefaultValue: false, HasDefault: true}}
+
+	schemaT.Fields = []*Field{
+		{Name: "description", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return nil, nil }},
+		{Name: "types", Type: NewNonNull(NewList(NewNonNull(typeT))), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			sch := src.(*Schema)
+			out := make([]any, 0, len(sch.order))
+			for _, name := range sch.order {
+				out = append(out, sch.types[name])
+			}
+			return out, nil
+		}},
+		{Name: "queryType", Type: NewNonNull(typeT), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return src.(*Schema).Query, nil
+		}},
+		{Name: "mutationType", Type: typeT, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			if m := src.(*Schema).Mutation; m != nil {
+				return m, nil
+			}
+			return nil, nil
+		}},
+		{Name: "subscriptionType", Type: typeT, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return nil, nil }},
+		{Name: "directives", Type: NewNonNull(NewList(NewNonNull(directiveT))), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			out := []any{}
+			for _, d := range src.(*Schema).directives {
+				out = append(out, d)
+			}
+			return out, nil
+		}},
+	}
+
+	typeT.Fields = []*Field{
+		{Name: "kind", Type: NewNonNull(typeKindEnum), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return typeKind(src.(Type)), nil
+		}},
+		{Name: "name", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			if n, ok := src.(Named); ok {
+				return n.TypeName(), nil
+			}
+			return nil, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			if n, ok := src.(Named); ok {
+				return nullableString(n.TypeDescription()), nil
+			}
+			return nil, nil
+		}},
+		{Name: "specifiedByURL", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return nil, nil }},
+		{Name: "fields", Type: NewList(NewNonNull(fieldT)), Args: inclDep, Resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+			o, ok := src.(*Object)
+			if !ok {
+				return nil, nil
+			}
+			out := []any{}
+			for _, f := range o.Fields {
+				if !deprecated(f.DeprecationReason) || includeDeprecated(args) {
+					out = append(out, f)
+				}
+			}
+			return out, nil
+		}},
+		{Name: "interfaces", Type: NewList(NewNonNull(typeT)), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			if _, ok := src.(*Object); ok {
+				return []any{}, nil
+			}
+			return nil, nil
+		}},
+		{Name: "possibleTypes", Type: NewList(NewNonNull(typeT)), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return nil, nil }},
+		{Name: "enumValues", Type: NewList(NewNonNull(enumValueT)), Args: inclDep, Resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+			e, ok := src.(*Enum)
+			if !ok {
+				return nil, nil
+			}
+			out := []any{}
+			for _, v := range e.Values {
+				if !deprecated(v.DeprecationReason) || includeDeprecated(args) {
+					out = append(out, v)
+				}
+			}
+			return out, nil
+		}},
+		{Name: "inputFields", Type: NewList(NewNonNull(inputValueT)), Args: inclDep, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			io, ok := src.(*InputObject)
+			if !ok {
+				return nil, nil
+			}
+			out := []any{}
+			for _, f := range io.Fields {
+				out = append(out, f)
+			}
+			return out, nil
+		}},
+		{Name: "ofType", Type: typeT, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			switch t := src.(type) {
+			case *List:
+				return t.Of, nil
+			case *NonNull:
+				return t.Of, nil
+			}
+			return nil, nil
+		}},
+		{Name: "isOneOf", Type: Boolean, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			if _, ok := src.(*InputObject); ok {
+				return false, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	fieldT.Fields = []*Field{
+		{Name: "name", Type: nnString, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return src.(*Field).Name, nil }},
+		{Name: "description", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return nullableString(src.(*Field).Description), nil
+		}},
+		{Name: "args", Type: NewNonNull(NewList(NewNonNull(inputValueT))), Args: inclDep, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			out := []any{}
+			for _, a := range src.(*Field).Args {
+				out = append(out, a)
+			}
+			return out, nil
+		}},
+		{Name: "type", Type: NewNonNull(typeT), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return src.(*Field).Type, nil }},
+		{Name: "isDeprecated", Type: nnBool, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return deprecated(src.(*Field).DeprecationReason), nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return nullableString(src.(*Field).DeprecationReason), nil
+		}},
+	}
+
+	inputValueT.Fields = []*Field{
+		{Name: "name", Type: nnString, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return src.(*InputValue).Name, nil }},
+		{Name: "description", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return nullableString(src.(*InputValue).Description), nil
+		}},
+		{Name: "type", Type: NewNonNull(typeT), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return src.(*InputValue).Type, nil }},
+		{Name: "defaultValue", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			iv := src.(*InputValue)
+			if !iv.HasDefault {
+				return nil, nil
+			}
+			return printValue(iv.DefaultValue, iv.Type), nil
+		}},
+		{Name: "isDeprecated", Type: nnBool, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return false, nil }},
+		{Name: "deprecationReason", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return nil, nil }},
+	}
+
+	enumValueT.Fields = []*Field{
+		{Name: "name", Type: nnString, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return src.(*EnumValue).Name, nil }},
+		{Name: "description", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return nullableString(src.(*EnumValue).Description), nil
+		}},
+		{Name: "isDeprecated", Type: nnBool, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return deprecated(src.(*EnumValue).DeprecationReason), nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return nullableString(src.(*EnumValue).DeprecationReason), nil
+		}},
+	}
+
+	directiveT.Fields = []*Field{
+		{Name: "name", Type: nnString, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return src.(*DirectiveDef).Name, nil
+		}},
+		{Name: "description", Type: String, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return nullableString(src.(*DirectiveDef).Description), nil
+		}},
+		{Name: "isRepeatable", Type: nnBool, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) { return false, nil }},
+		{Name: "locations", Type: NewNonNull(NewList(NewNonNull(locationEnum))), Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			out := []any{}
+			for _, l := range src.(*DirectiveDef).Locations {
+				out = append(out, l)
+			}
+			return out, nil
+		}},
+		{Name: "args", Type: NewNonNull(NewList(NewNonNull(inputValueT))), Args: inclDep, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			out := []any{}
+			for _, a := range src.(*DirectiveDef).Args {
+				out = append(out, a)
+			}
+			return out, nil
+		}},
+	}
+
+	if err := s.collect(schemaT); err != nil {
+		return err
+	}
+
+	s.schemaField = &Field{Name: "__schema", Type: NewNonNull(schemaT), Resolve: func(ctx context.Context, _ any, _ map[string]any) (any, error) {
+		return s, nil
+	}}
+	s.typeField = &Field{
+		Name: "__type",
+		Type: typeT,
+		Args: []*InputValue{{Name: "name", Type: NewNonNull(String)}},
+		Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			if t, ok := s.types[args["name"].(string)]; ok {
+				return t, nil
+			}
+			return nil, nil
+		},
+	}
+	s.typenameField = &Field{Name: "__typename", Type: NewNonNull(String)}
+	return nil
+}
+
+// printValue печатает приведенное значение как литерал GraphQL
+// (нужно для __InputValue.defaultValue).
+func printValue(v any, t Type) string {
+	if v == nil {
+		return "null"
+	}
+	switch tt := t.(type) {
+	case *NonNull:
+		return printValue(v, tt.Of)
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			return printValue(v, tt.Of)
+		}
+		parts := make([]string, len(items))
+		for i, it := range items {
+			parts[i] = printValue(it, tt.Of)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *Enum:
+		if ev := tt.byValue(v); ev != nil {
+			return ev.Name
+		}
+	case *InputObject:
+		m, ok := v.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			for _, f := range tt.Fields {
+				if f.Name == k {
+					parts = append(parts, k+": "+printValue(m[k], f.Type))
+				}
+			}
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	return fmt.Sprint(v)
+}
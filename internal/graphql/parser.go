@@ -0,0 +1,429 @@
+package graphql
+
+import "fmt"
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// Parse разбирает исполняемый документ: операции и фрагменты.
+func Parse(src string) (*Document, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peekPunct("{"):
+			loc := p.tok.loc
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Kind: "query", Selection: sel, Loc: loc})
+		case p.peekName("query"), p.peekName("mutation"), p.peekName("subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peekName("fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.Fragments[f.Name]; dup {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one fragment named %q.", f.Name), Locations: []Location{f.Loc}}
+			}
+			doc.Fragments[f.Name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, &Error{Message: "Document contains no operations."}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) peekPunct(v string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == v
+}
+
+func (p *parser) peekName(v string) bool {
+	return p.tok.kind == tokName && p.tok.value == v
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return p.lex.errorf(p.tok.loc, "unexpected end of document")
+	}
+	return p.lex.errorf(p.tok.loc, "unexpected %q", p.tok.value)
+}
+
+func (p *parser) expectPunct(v string) error {
+	if !p.peekPunct(v) {
+		if p.tok.kind == tokEOF {
+			return p.lex.errorf(p.tok.loc, "expected %q, found end of document", v)
+		}
+		return p.lex.errorf(p.tok.loc, "expected %q, found %q", v, p.tok.value)
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	v := p.tok.value
+	return v, p.advance()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	op := &Operation{Kind: p.tok.value, Loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName {
+		op.Name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peekPunct("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peekPunct(")") {
+			v, err := p.variableDef()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = append(op.Variables, v)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selection = sel
+	return op, nil
+}
+
+func (p *parser) variableDef() (*VariableDef, error) {
+	v := &VariableDef{Loc: p.tok.loc}
+	if err := p.expectPunct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	v.Name = name
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	if v.Type, err = p.typeRef(); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("=") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		def, err := p.value(true)
+		if err != nil {
+			return nil, err
+		}
+		v.Default = &def
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (p *parser) typeRef() (TypeRef, error) {
+	var t TypeRef
+	if p.peekPunct("[") {
+		if err := p.advance(); err != nil {
+			return t, err
+		}
+		elem, err := p.typeRef()
+		if err != nil {
+			return t, err
+		}
+		t.Elem = &elem
+		if err := p.expectPunct("]"); err != nil {
+			return t, err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return t, err
+		}
+		t.Name = name
+	}
+	if p.peekPunct("!") {
+		t.NonNull = true
+		return t, p.advance()
+	}
+	return t, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var out []Selection
+	for !p.peekPunct("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil, p.lex.errorf(p.tok.loc, "selection set must not be empty")
+	}
+	return out, p.advance()
+}
+
+func (p *parser) selection() (Selection, error) {
+	if !p.peekPunct("...") {
+		return p.field()
+	}
+	loc := p.tok.loc
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName && p.tok.value != "on" {
+		name := p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		dirs, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		return &FragmentSpread{Name: name, Directives: dirs, Loc: loc}, nil
+	}
+	f := &InlineFragment{Loc: loc}
+	if p.peekName("on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		f.TypeCondition = name
+	}
+	var err error
+	if f.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if f.Selection, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) field() (*FieldNode, error) {
+	f := &FieldNode{Loc: p.tok.loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.peekPunct(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		f.Alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.Name = name
+	if f.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("{") {
+		if f.Selection, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*Argument, error) {
+	if !p.peekPunct("(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var out []*Argument
+	for !p.peekPunct(")") {
+		a := &Argument{Loc: p.tok.loc}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		a.Name = name
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if a.Value, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if len(out) == 0 {
+		return nil, p.lex.errorf(p.tok.loc, "argument list must not be empty")
+	}
+	return out, p.advance()
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+	var out []*Directive
+	for p.peekPunct("@") {
+		d := &Directive{Loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d.Name = name
+		if d.Arguments, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	f := &Fragment{Loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.lex.errorf(f.Loc, "fragment cannot be named \"on\"")
+	}
+	f.Name = name
+	if !p.peekName("on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if f.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if f.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if f.Selection, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) value(constant bool) (Value, error) {
+	v := Value{Loc: p.tok.loc}
+	switch p.tok.kind {
+	case tokInt:
+		v.Kind, v.Raw = IntKind, p.tok.value
+	case tokFloat:
+		v.Kind, v.Raw = FloatKind, p.tok.value
+	case tokString:
+		v.Kind, v.Raw = StringKind, p.tok.value
+	case tokName:
+		switch p.tok.value {
+		case "true", "false":
+			v.Kind, v.Raw = BooleanKind, p.tok.value
+		case "null":
+			v.Kind = NullKind
+		default:
+			v.Kind, v.Raw = EnumKind, p.tok.value
+		}
+	case tokPunct:
+		switch p.tok.value {
+		case "$":
+			if constant {
+				return v, p.lex.errorf(v.Loc, "unexpected variable in constant value")
+			}
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return v, err
+			}
+			v.Kind, v.Raw = VariableKind, name
+			return v, nil
+		case "[":
+			v.Kind = ListKind
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+			for !p.peekPunct("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return v, err
+				}
+				v.List = append(v.List, item)
+			}
+		case "{":
+			v.Kind = ObjectKind
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+			for !p.peekPunct("}") {
+				name, err := p.name()
+				if err != nil {
+					return v, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return v, err
+				}
+				fv, err := p.value(constant)
+				if err != nil {
+					return v, err
+				}
+				v.Fields = append(v.Fields, ObjectField{Name: name, Value: fv})
+			}
+		default:
+			return v, p.unexpected()
+		}
+	default:
+		return v, p.unexpected()
+	}
+	return v, p.advance()
+}
@@ -0,0 +1,413 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Type — тип схемы: *Scalar, *Enum, *Object, *InputObject, *List или *NonNull.
+type Type interface {
+	String() string
+}
+
+// Named — типы, у которых есть имя в схеме.
+type Named interface {
+	Type
+	TypeName() string
+	TypeDescription() string
+}
+
+type Scalar struct {
+	Name        string
+	Description string
+	// Serialize переводит значение резолвера в JSON-совместимое.
+	Serialize func(v any) (any, error)
+	// ParseValue разбирает значение из переменных (JSON).
+	ParseValue func(v any) (any, error)
+	// ParseLiteral разбирает литерал из текста запроса.
+	ParseLiteral func(v Value) (any, error)
+}
+
+type EnumValue struct {
+	Name              string
+	Description       string
+	Value             any
+	DeprecationReason string
+}
+
+type Enum struct {
+	Name        string
+	Description string
+	Values      []*EnumValue
+}
+
+// ResolveFunc вычисляет поле для одного родительского значения.
+type ResolveFunc func(ctx context.Context, source any, args map[string]any) (any, error)
+
+// BatchFunc вычисляет поле сразу для всех родителей на одном уровне
+// выполнения; результат должен совпадать по длине и порядку с sources.
+type BatchFunc func(ctx context.Context, sources []any, args map[string]any) ([]any, error)
+
+type Field struct {
+	Name              string
+	Description       string
+	Type              Type
+	Args              []*InputValue
+	Resolve           ResolveFunc
+	Batch             BatchFunc
+	DeprecationReason string
+}
+
+type InputValue struct {
+	Name        string
+	Description string
+	Type        Type
+	// DefaultValue — уже приведенное значение; HasDefault отличает
+	// отсутствие значения по умолчанию от null.
+	DefaultValue any
+	HasDefault   bool
+}
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*InputValue
+}
+
+type List struct {
+	Of Type
+}
+
+type NonNull struct {
+	Of Type
+}
+
+func (t *Scalar) String() string      { return t.Name }
+func (t *Enum) String() string        { return t.Name }
+func (t *Object) String() string      { return t.Name }
+func (t *InputObject) String() string { return t.Name }
+func (t *List) String() string        { return "[" + t.Of.String() + "]" }
+func (t *NonNull) String() string     { return t.Of.String() + "!" }
+
+func (t *Scalar) TypeName() string      { return t.Name }
+func (t *Enum) TypeName() string        { return t.Name }
+func (t *Object) TypeName() string      { return t.Name }
+func (t *InputObject) TypeName() string { return t.Name }
+
+func (t *Scalar) TypeDescription() string      { return t.Description }
+func (t *Enum) TypeDescription() string        { return t.Description }
+func (t *Object) TypeDescription() string      { return t.Description }
+func (t *InputObject) TypeDescription() string { return t.Description }
+
+func (o *Object) Field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (e *Enum) byName(name string) *EnumValue {
+	for _, v := range e.Values {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+func (e *Enum) byValue(val any) *EnumValue {
+	for _, v := range e.Values {
+		if v.Value == val {
+			return v
+		}
+	}
+	return nil
+}
+
+func NewNonNull(t Type) *NonNull { return &NonNull{Of: t} }
+func NewList(t Type) *List       { return &List{Of: t} }
+
+// unwrap снимает List и NonNull и возвращает именованный тип.
+func unwrap(t Type) Named {
+	for {
+		switch tt := t.(type) {
+		case *List:
+			t = tt.Of
+		case *NonNull:
+			t = tt.Of
+		default:
+			return t.(Named)
+		}
+	}
+}
+
+func isList(t Type) bool {
+	if nn, ok := t.(*NonNull); ok {
+		t = nn.Of
+	}
+	_, ok := t.(*List)
+	return ok
+}
+
+// DirectiveDef — директива, объявленная в схеме (встроенные @skip и @include).
+type DirectiveDef struct {
+	Name        string
+	Description string
+	Locations   []string
+	Args        []*InputValue
+}
+
+type Schema struct {
+	Query      *Object
+	Mutation   *Object
+	Limits     Limits
+	types      map[string]Named
+	order      []string
+	directives []*DirectiveDef
+
+	schemaField   *Field
+	typeField     *Field
+	typenameField *Field
+}
+
+// Limits ограничивают стоимость запроса; нулевые значения — без ограничений.
+// Поля внутри __schema и __type не учитываются, чтобы стандартный
+// introspection-запрос работал при любых настройках; для них действуют
+// собственные встроенные пределы.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+	// ListFactor — во сколько раз умножается стоимость выборки под полем-списком.
+	ListFactor int
+}
+
+// NewSchema собирает все именованные типы, достижимые из корней,
+// и добавляет встроенные скаляры, директивы и introspection.
+func NewSchema(query, mutation *Object) (*Schema, error) {
+	s := &Schema{Query: query, Mutation: mutation, types: make(map[string]Named)}
+	for _, t := range []Named{String, Int, Float, Boolean, ID} {
+		if err := s.collect(t); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.collect(query); err != nil {
+		return nil, err
+	}
+	if mutation != nil {
+		if err := s.collect(mutation); err != nil {
+			return nil, err
+		}
+	}
+	s.directives = []*DirectiveDef{skipDirective, includeDirective}
+	if err := s.addIntrospection(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) Type(name string) Named {
+	return s.types[name]
+}
+
+func (s *Schema) collect(t Type) error {
+	n := unwrap(t)
+	if existing, ok := s.types[n.TypeName()]; ok {
+		if existing != n {
+			return fmt.Errorf("graphql: duplicate type %q", n.TypeName())
+		}
+		return nil
+	}
+	s.types[n.TypeName()] = n
+	s.order = append(s.order, n.TypeName())
+	switch tt := n.(type) {
+	case *Object:
+		for _, f := range tt.Fields {
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+			for _, a := range f.Args {
+				if err := s.collect(a.Type); err != nil {
+					return err
+				}
+			}
+		}
+	case *InputObject:
+		for _, f := range tt.Fields {
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var skipDirective = &DirectiveDef{
+	Name:        "skip",
+	Description: "Directs the executor to skip this field or fragment when the `if` argument is true.",
+	Locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+	Args:        []*InputValue{{Name: "if", Type: NewNonNull(Boolean), Description: "Skipped when true."}},
+}
+
+var includeDirective = &DirectiveDef{
+	Name:        "include",
+	Description: "Directs the executor to include this field or fragment only when the `if` argument is true.",
+	Locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+	Args:        []*InputValue{{Name: "if", Type: NewNonNull(Boolean), Description: "Included when true."}},
+}
+
+var String = &Scalar{
+	Name:        "String",
+	Description: "UTF-8 character sequence.",
+	Serialize: func(v any) (any, error) {
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case fmt.Stringer:
+			return x.String(), nil
+		}
+		return fmt.Sprint(v), nil
+	},
+	ParseValue: func(v any) (any, error) {
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("String cannot represent a non string value: %v", v)
+	},
+	ParseLiteral: func(v Value) (any, error) {
+		if v.Kind == StringKind {
+			return v.Raw, nil
+		}
+		return nil, fmt.Errorf("String cannot represent a non string value: %s", v.Raw)
+	},
+}
+
+var ID = &Scalar{
+	Name:        "ID",
+	Description: "Unique identifier, serialized as a string.",
+	Serialize:   String.Serialize,
+	ParseValue: func(v any) (any, error) {
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case float64:
+			if x == math.Trunc(x) {
+				return strconv.FormatInt(int64(x), 10), nil
+			}
+		}
+		return nil, fmt.Errorf("ID cannot represent value: %v", v)
+	},
+	ParseLiteral: func(v Value) (any, error) {
+		if v.Kind == StringKind || v.Kind == IntKind {
+			return v.Raw, nil
+		}
+		return nil, fmt.Errorf("ID cannot represent value: %s", v.Raw)
+	},
+}
+
+var Int = &Scalar{
+	Name:        "Int",
+	Description: "Signed 32-bit integer.",
+	Serialize: func(v any) (any, error) {
+		switch x := v.(type) {
+		case int:
+			return x, nil
+		case int32:
+			return int(x), nil
+		case int64:
+			return int(x), nil
+		}
+		return nil, fmt.Errorf("Int cannot represent value: %v", v)
+	},
+	ParseValue: func(v any) (any, error) {
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32 {
+			return int(f), nil
+		}
+		return nil, fmt.Errorf("Int cannot represent value: %v", v)
+	},
+	ParseLiteral: func(v Value) (any, error) {
+		if v.Kind == IntKind {
+			if n, err := strconv.ParseInt(v.Raw, 10, 32); err == nil {
+				return int(n), nil
+			}
+		}
+		return nil, fmt.Errorf("Int cannot represent value: %s", v.Raw)
+	},
+}
+
+var Float = &Scalar{
+	Name:        "Float",
+	Description: "Double-precision floating point value.",
+	Serialize: func(v any) (any, error) {
+		switch x := v.(type) {
+		case float64:
+			return x, nil
+		case float32:
+			return float64(x), nil
+		case int:
+			return float64(x), nil
+		}
+		return nil, fmt.Errorf("Float cannot represent value: %v", v)
+	},
+	ParseValue: func(v any) (any, error) {
+		if f, ok := v.(float64); ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("Float cannot represent value: %v", v)
+	},
+	ParseLiteral: func(v Value) (any, error) {
+		if v.Kind == IntKind || v.Kind == FloatKind {
+			return strconv.ParseFloat(v.Raw, 64)
+		}
+		return nil, fmt.Errorf("Float cannot represent value: %s", v.Raw)
+	},
+}
+
+var Boolean = &Scalar{
+	Name:        "Boolean",
+	Description: "true or false.",
+	Serialize: func(v any) (any, error) {
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent value: %v", v)
+	},
+	ParseValue: func(v any) (any, error) {
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent value: %v", v)
+	},
+	ParseLiteral: func(v Value) (any, error) {
+		if v.Kind == BooleanKind {
+			return v.Raw == "true", nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent value: %s", v.Raw)
+	},
+}
+
+// Error — ошибка в формате ответа GraphQL.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	for _, l := range e.Locations {
+		fmt.Fprintf(&b, " (%d:%d)", l.Line, l.Column)
+	}
+	return b.String()
+}
@@ -0,0 +1,280 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type validator struct {
+	schema *Schema
+	doc    *Document
+	vars   map[string]*VariableDef
+	errs   []*Error
+
+	depthExceeded bool
+	// fragCost — стоимость уже проверенных фрагментов: без нее цепочка
+	// фрагментов, каждый из которых дважды ссылается на следующий,
+	// проверялась бы экспоненциально долго.
+	fragCost map[fragKey]int
+}
+
+type fragKey struct {
+	name          string
+	depth         int
+	introspection bool
+}
+
+// Пределы для __schema и __type. Стандартный introspection-запрос должен
+// проходить при любых Limits, но вложенные type { fields { type ... } }
+// с псевдонимами растут экспоненциально и ограничиваются отдельно.
+const (
+	introspectionMaxDepth      = 15
+	introspectionMaxComplexity = 100000
+)
+
+// maxCost — потолок оценки стоимости, чтобы она не переполнялась.
+const maxCost = 1 << 40
+
+func addCost(a, b int) int { return min(a+b, maxCost) }
+
+func mulCost(a, b int) int {
+	if a > 0 && b > maxCost/a {
+		return maxCost
+	}
+	return a * b
+}
+
+func (v *validator) errorf(loc Location, format string, args ...any) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// validate проверяет поля, аргументы, фрагменты и переменные по схеме,
+// а также глубину и сложность запроса.
+func (v *validator) validate(op *Operation, root *Object) []*Error {
+	for _, def := range op.Variables {
+		if _, dup := v.vars[def.Name]; dup {
+			v.errorf(def.Loc, "There can be only one variable named \"$%s\".", def.Name)
+		}
+		v.vars[def.Name] = def
+		t := v.schema.resolveTypeRef(def.Type)
+		if t == nil || !isInputType(t) {
+			v.errorf(def.Loc, "Variable \"$%s\" cannot be non-input type.", def.Name)
+		}
+	}
+	if op.Kind == "subscription" {
+		v.errorf(op.Loc, "Subscriptions are not supported.")
+		return v.errs
+	}
+
+	cost := v.selection(root, op.Selection, 1, map[string]bool{}, false)
+	limits := v.schema.Limits
+	if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+		v.errorf(op.Loc, "Query complexity %d exceeds the maximum of %d.", cost, limits.MaxComplexity)
+	}
+	return v.errs
+}
+
+func (v *validator) selection(t *Object, sel []Selection, depth int, fragStack map[string]bool, introspection bool) int {
+	limits := v.schema.Limits
+	cost := 0
+	for _, s := range sel {
+		switch n := s.(type) {
+		case *FieldNode:
+			cost = addCost(cost, v.field(t, n, depth, fragStack, introspection))
+		case *InlineFragment:
+			v.directives(n.Directives)
+			if n.TypeCondition != "" && n.TypeCondition != t.Name {
+				v.errorf(n.Loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", t.Name, n.TypeCondition)
+				continue
+			}
+			cost = addCost(cost, v.selection(t, n.Selection, depth, fragStack, introspection))
+		case *FragmentSpread:
+			v.directives(n.Directives)
+			f, ok := v.doc.Fragments[n.Name]
+			if !ok {
+				v.errorf(n.Loc, "Unknown fragment %q.", n.Name)
+				continue
+			}
+			if fragStack[n.Name] {
+				v.errorf(n.Loc, "Cannot spread fragment %q within itself.", n.Name)
+				continue
+			}
+			if f.TypeCondition != t.Name {
+				v.errorf(n.Loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q.", n.Name, t.Name, f.TypeCondition)
+				continue
+			}
+			key := fragKey{n.Name, depth, introspection}
+			c, done := v.fragCost[key]
+			if !done {
+				fragStack[n.Name] = true
+				c = v.selection(t, f.Selection, depth, fragStack, introspection)
+				delete(fragStack, n.Name)
+				if v.fragCost == nil {
+					v.fragCost = make(map[fragKey]int)
+				}
+				v.fragCost[key] = c
+			}
+			cost = addCost(cost, c)
+		}
+	}
+	maxDepth := limits.MaxDepth
+	if introspection {
+		maxDepth = introspectionMaxDepth
+	}
+	if maxDepth > 0 && depth > maxDepth && !v.depthExceeded {
+		v.depthExceeded = true
+		v.errs = append(v.errs, &Error{Message: fmt.Sprintf("Query depth exceeds the maximum of %d.", maxDepth)})
+	}
+	return cost
+}
+
+func (v *validator) field(t *Object, n *FieldNode, depth int, fragStack map[string]bool, introspection bool) int {
+	v.directives(n.Directives)
+	if n.Name == "__typename" {
+		if len(n.Selection) > 0 {
+			v.errorf(n.Loc, "Field \"__typename\" must not have a selection since type \"String!\" has no subfields.")
+		}
+		return 0
+	}
+
+	f := t.Field(n.Name)
+	root := false
+	if t == v.schema.Query {
+		switch n.Name {
+		case "__schema":
+			f, root = v.schema.schemaField, true
+		case "__type":
+			f, root = v.schema.typeField, true
+		}
+	}
+	if f == nil {
+		v.errorf(n.Loc, "Cannot query field %q on type %q.%s", n.Name, t.Name, suggest(n.Name, t))
+		return 0
+	}
+	v.arguments(f.Args, n.Arguments, n.Loc, fmt.Sprintf("%s.%s", t.Name, f.Name))
+
+	named := unwrap(f.Type)
+	obj, composite := named.(*Object)
+	switch {
+	case composite && len(n.Selection) == 0:
+		v.errorf(n.Loc, "Field %q of type %q must have a selection of subfields.", n.Name, f.Type.String())
+		return 0
+	case !composite && len(n.Selection) > 0:
+		v.errorf(n.Loc, "Field %q must not have a selection since type %q has no subfields.", n.Name, f.Type.String())
+		return 0
+	case !composite:
+		return 1
+	}
+
+	if root {
+		// Глубина introspection считается от __schema/__type, стоимость —
+		// отдельно от стоимости запроса.
+		cost := 1 + v.selection(obj, n.Selection, 1, fragStack, true)
+		if cost > introspectionMaxComplexity {
+			v.errorf(n.Loc, "Introspection complexity %d exceeds the maximum of %d.", cost, introspectionMaxComplexity)
+		}
+		return 0
+	}
+	child := v.selection(obj, n.Selection, depth+1, fragStack, introspection)
+	if isList(f.Type) {
+		factor := v.schema.Limits.ListFactor
+		if factor <= 0 || introspection {
+			factor = 10
+		}
+		child = mulCost(child, factor)
+	}
+	return addCost(1, child)
+}
+
+func (v *validator) arguments(defs []*InputValue, nodes []*Argument, loc Location, owner string) {
+	seen := make(map[string]bool, len(nodes))
+	for _, a := range nodes {
+		if seen[a.Name] {
+			v.errorf(a.Loc, "There can be only one argument named %q.", a.Name)
+		}
+		seen[a.Name] = true
+		var def *InputValue
+		for _, d := range defs {
+			if d.Name == a.Name {
+				def = d
+			}
+		}
+		if def == nil {
+			v.errorf(a.Loc, "Unknown argument %q on %s.", a.Name, owner)
+			continue
+		}
+		v.value(a.Value)
+		if !hasVariables(a.Value) {
+			if _, _, err := coerceLiteral(def.Type, a.Value, nil); err != nil {
+				v.errorf(a.Loc, "Argument %q has invalid value: %v", a.Name, err)
+			}
+		}
+	}
+	for _, d := range defs {
+		if _, nn := d.Type.(*NonNull); nn && !d.HasDefault && !seen[d.Name] {
+			v.errorf(loc, "Argument %q of type %q is required on %s, but it was not provided.", d.Name, d.Type.String(), owner)
+		}
+	}
+}
+
+func (v *validator) directives(dirs []*Directive) {
+	for _, d := range dirs {
+		switch d.Name {
+		case "skip":
+			v.arguments(skipDirective.Args, d.Arguments, d.Loc, "@skip")
+		case "include":
+			v.arguments(includeDirective.Args, d.Arguments, d.Loc, "@include")
+		default:
+			v.errorf(d.Loc, "Unknown directive \"@%s\".", d.Name)
+		}
+	}
+}
+
+// value проверяет, что все переменные в значении объявлены в операции.
+func (v *validator) value(val Value) {
+	switch val.Kind {
+	case VariableKind:
+		if _, ok := v.vars[val.Raw]; !ok {
+			v.errorf(val.Loc, "Variable \"$%s\" is not defined.", val.Raw)
+		}
+	case ListKind:
+		for _, it := range val.List {
+			v.value(it)
+		}
+	case ObjectKind:
+		for _, f := range val.Fields {
+			v.value(f.Value)
+		}
+	}
+}
+
+func hasVariables(val Value) bool {
+	switch val.Kind {
+	case VariableKind:
+		return true
+	case ListKind:
+		for _, it := range val.List {
+			if hasVariables(it) {
+				return true
+			}
+		}
+	case ObjectKind:
+		for _, f := range val.Fields {
+			if hasVariables(f.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func suggest(name string, t *Object) string {
+	lower := strings.ToLower(name)
+	for _, f := range t.Fields {
+		fl := strings.ToLower(f.Name)
+		if strings.Contains(fl, lower) || strings.Contains(lower, fl) {
+			return fmt.Sprintf(" Did you mean %q?", f.Name)
+		}
+	}
+	return ""
+}
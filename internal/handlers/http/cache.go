@@ -0,0 +1,90 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"taskapi/internal/codec"
+	"taskapi/internal/domain"
+	"time"
+)
+
+// cacheControl разрешает хранить ответ, но требует сверки перед каждым
+// использованием: задачи меняются в любой момент, а 304 почти ничего не
+// стоит.
+const cacheControl = "no-cache"
+
+// validators — ETag и Last-Modified одного представления.
+type validators struct {
+	etag     string
+	modified time.Time
+}
+
+// taskValidators — задача меняется только вместе с UpdatedAt.
+func taskValidators(r *http.Request, t domain.Task) validators {
+	return validators{
+		etag:     etag(r, strconv.FormatInt(t.UpdatedAt.UnixNano(), 36)),
+		modified: t.UpdatedAt,
+	}
+}
+
+// listValidators — ревизия коллекции меняется при любом изменении любой
+// задачи, поэтому подходит для списка с любым фильтром.
+func listValidators(r *http.Request, rev domain.Revision) validators {
+	return validators{
+		etag:     etag(r, "r"+strconv.FormatUint(rev.Version, 36)),
+		modified: rev.Modified,
+	}
+}
+
+// etag строит слабый тег: сжатие меняет байты ответа, но не его смысл.
+// Разные форматы (JSON, XML, CSV) получают разные теги.
+func etag(r *http.Request, version string) string {
+	if enc := encoderFrom(r); enc != nil {
+		_, sub, _ := strings.Cut(codec.MediaType(enc.MediaType()), "/")
+		version += "-" + sub
+	}
+	return `W/"` + version + `"`
+}
+
+// set пишет заголовки кэширования успешного ответа.
+func (v validators) set(w http.ResponseWriter) {
+	h := w.Header()
+	h.Set("ETag", v.etag)
+	h.Set("Cache-Control", cacheControl)
+	if !v.modified.IsZero() {
+		h.Set("Last-Modified", v.modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified отвечает 304, если копия клиента актуальна. If-None-Match
+// важнее If-Modified-Since (RFC 9110, 13.2.2).
+func (v validators) notModified(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	fresh := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		fresh = etagMatch(inm, v.etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !v.modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		fresh = err == nil && !v.modified.Truncate(time.Second).After(t)
+	}
+	if !fresh {
+		return false
+	}
+	v.set(w)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatch — слабое сравнение со списком тегов из If-None-Match.
+func etagMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
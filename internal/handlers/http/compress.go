@@ -0,0 +1,264 @@
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"taskapi/internal/codec"
+)
+
+// DefaultCompressMinSize — ответы короче не сжимаются: заголовки gzip и
+// работа процессора дороже выигрыша.
+const DefaultCompressMinSize = 1024
+
+// WithCompression задает минимальный размер ответа для сжатия.
+func WithCompression(minSize int) Option {
+	return func(rt *Router) { rt.compressMinSize = minSize }
+}
+
+// uncompressed — маршруты-потоки: буферизация ломает доставку событий, а
+// WebSocket забирает соединение целиком.
+var uncompressed = map[string]bool{
+	"GET /events": true,
+	"GET /ws":     true,
+}
+
+// encodings — поддерживаемые Content-Encoding в порядке предпочтения.
+var encodings = []string{"gzip", "deflate"}
+
+// Сжиматели переиспользуются: каждый держит окна и таблицы на сотни КБ.
+var (
+	gzipPool  = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	flatePool = sync.Pool{New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}}
+)
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func getCompressor(encoding string, w io.Writer) compressor {
+	var c compressor
+	if encoding == "gzip" {
+		c = gzipPool.Get().(*gzip.Writer)
+	} else {
+		c = flatePool.Get().(*flate.Writer)
+	}
+	c.Reset(w)
+	return c
+}
+
+func putCompressor(encoding string, c compressor) {
+	if encoding == "gzip" {
+		gzipPool.Put(c)
+	} else {
+		flatePool.Put(c)
+	}
+}
+
+// compress сжимает ответ кодировкой из Accept-Encoding и распаковывает
+// тело запроса с Content-Encoding: gzip на маршрутах с Consumes.
+func (rt *Router) compress(rd route, next http.Handler) http.Handler {
+	if uncompressed[rd.Method+" "+rd.Path] {
+		return next
+	}
+	gunzip := len(rd.Consumes) > 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if gunzip && r.Header.Get("Content-Encoding") != "" {
+			if err := gunzipBody(w, r); err != nil {
+				writeError(w, r, err)
+				return
+			}
+		}
+		// Без Accept-Encoding клиент не обязан понимать сжатие.
+		accept := r.Header.Get("Accept-Encoding")
+		encoding, ok := codec.Negotiate(accept, encodings)
+		if !ok || accept == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: rt.compressMinSize}
+		// Без defer: при панике недописанный ответ не должен уйти клиенту,
+		// его заменит recoverMiddleware.
+		next.ServeHTTP(cw, r)
+		_ = cw.Close()
+	})
+}
+
+// gunzipBody подменяет сжатое тело распакованным. Размер распакованного
+// тела ограничивают те, кто его читает (openapi.MaxBodySize).
+func gunzipBody(w http.ResponseWriter, r *http.Request) error {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "identity":
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return problemf(codeInvalidBody, "request body is not valid gzip: %v", err)
+		}
+		r.Body = gzipBody{Reader: zr, body: r.Body}
+		r.ContentLength = -1
+		r.Header.Del("Content-Length")
+	default:
+		// RFC 7694: 415 с перечнем поддерживаемых кодировок.
+		w.Header().Set("Accept-Encoding", "gzip")
+		return problemf(codeUnsupportedMedia, "content encoding %q is not supported, use gzip", r.Header.Get("Content-Encoding"))
+	}
+	r.Header.Del("Content-Encoding")
+	return nil
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (b gzipBody) Close() error {
+	_ = b.Reader.Close()
+	return b.body.Close()
+}
+
+// compressWriter копит начало ответа, пока не станет ясно, стоит ли его
+// сжимать: до minSize байт, явного Flush или конца обработки.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	c       compressor
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	// Информационные ответы и ответы без тела уходят сразу.
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.c != nil {
+			return w.c.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minSize {
+		if err := w.start(w.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// compressible — можно ли сжимать ответ по его заголовкам.
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < w.minSize {
+		return false
+	}
+	return !precompressed(codec.MediaType(h.Get("Content-Type")))
+}
+
+// precompressed — типы, которые уже сжаты и от gzip только растут.
+func precompressed(mt string) bool {
+	switch {
+	case strings.HasPrefix(mt, "image/") && mt != "image/svg+xml",
+		strings.HasPrefix(mt, "video/"), strings.HasPrefix(mt, "audio/"):
+		return true
+	}
+	switch mt {
+	case "application/gzip", "application/x-gzip", "application/zip", "application/zstd",
+		"application/x-bzip2", "application/x-xz", "application/x-7z-compressed",
+		"application/pdf", "font/woff", "font/woff2":
+		return true
+	}
+	return false
+}
+
+// decide отправляет заголовки: со сжатием или без.
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if compress {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.c = getCompressor(w.encoding, w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// start принимает решение и сбрасывает накопленное.
+func (w *compressWriter) start(compress bool) error {
+	w.decide(compress)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.c != nil {
+		_, err = w.c.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// Flush — признак потоковой передачи: решение принимается по уже
+// известным заголовкам, без учета размера.
+func (w *compressWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		_ = w.start(w.compressible())
+	}
+	if w.c != nil {
+		_ = w.c.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close дописывает ответ и возвращает сжиматель в пул.
+func (w *compressWriter) Close() error {
+	if w.status == 0 {
+		// Обработчик ничего не написал: статус 200 без тела.
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+	if w.c == nil {
+		return nil
+	}
+	err := w.c.Close()
+	putCompressor(w.encoding, w.c)
+	w.c = nil
+	return err
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS — доступ к API из браузера со страниц других источников.
+type CORS struct {
+	// Origins — точные источники ("https://board.example.com"), поддомены
+	// ("https://*.example.com") или "*" — любой источник.
+	Origins []string
+	// Methods — методы для preflight. GET, HEAD и POST браузер отправляет
+	// без preflight, поэтому они разрешены всегда.
+	Methods []string
+	// Headers — заголовки запроса; "*" — любые.
+	Headers []string
+	// Credentials разрешает запросы с cookie и авторизацией.
+	Credentials bool
+	// MaxAge — сколько браузер хранит ответ на preflight; 0 — не хранит.
+	MaxAge time.Duration
+}
+
+// corsExposed — заголовки ответа, которые видит скрипт сверх стандартных.
+const corsExposed = "ETag, X-Request-ID"
+
+// WithCORS включает CORS. Без него и при пустом Origins заголовки CORS не
+// отправляются и браузер не пускает запросы с чужих страниц.
+func WithCORS(c CORS) Option {
+	return func(rt *Router) {
+		if len(c.Origins) > 0 {
+			rt.cors = newCORSPolicy(c)
+		}
+	}
+}
+
+type corsPolicy struct {
+	CORS
+	anyOrigin bool
+	exact     map[string]bool
+	// wildcards — "https://*.example.com" хранится как {"https://", ".example.com"}.
+	wildcards [][2]string
+	methods   map[string]bool
+	anyHeader bool
+	headers   map[string]bool
+}
+
+func newCORSPolicy(c CORS) *corsPolicy {
+	p := &corsPolicy{CORS: c, exact: map[string]bool{}, methods: map[string]bool{}, headers: map[string]bool{}}
+	for _, o := range c.Origins {
+		o = strings.ToLower(o)
+		switch scheme, host, _ := strings.Cut(o, "://"); {
+		case o == "*":
+			p.anyOrigin = true
+		case strings.HasPrefix(host, "*."):
+			p.wildcards = append(p.wildcards, [2]string{scheme + "://", host[1:]})
+		default:
+			p.exact[o] = true
+		}
+	}
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPost} {
+		p.methods[m] = true
+	}
+	for _, m := range c.Methods {
+		p.methods[strings.ToUpper(m)] = true
+	}
+	for _, h := range c.Headers {
+		if h == "*" {
+			p.anyHeader = true
+		}
+		p.headers[strings.ToLower(h)] = true
+	}
+	return p
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.anyOrigin || p.exact[origin] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		rest, ok := strings.CutPrefix(origin, w[0])
+		sub, ok2 := strings.CutSuffix(rest, w[1])
+		// Поддомен — непустые метки без порта и учетных данных.
+		if ok && ok2 && sub != "" && !strings.ContainsAny(sub, ":/@") {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrigin — "*" подходит только для запросов без cookie; иначе
+// браузер требует точный источник.
+func (p *corsPolicy) setOrigin(h http.Header, origin string) {
+	if p.anyOrigin && !p.Credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.Credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// corsMiddleware отвечает на preflight для любого маршрута и добавляет
+// заголовки CORS к остальным ответам, включая ошибки.
+func (rt *Router) corsMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	p := rt.cors
+	if p == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if method := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && origin != "" && method != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			rt.preflight(w, r, mux, next, origin, method)
+			return
+		}
+		// Запрос с чужого источника не отклоняется: без заголовков CORS
+		// браузер сам не покажет ответ странице.
+		if origin != "" && p.allowOrigin(origin) {
+			p.setOrigin(h, origin)
+			h.Set("Access-Control-Expose-Headers", corsExposed)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rt *Router) preflight(w http.ResponseWriter, r *http.Request, mux *http.ServeMux, next http.Handler, origin, method string) {
+	p := rt.cors
+	probe := r.Clone(r.Context())
+	probe.Method = method
+	_, pattern := mux.Handler(probe)
+	if pattern == "" {
+		// Маршрута нет: ответ тот же, что получил бы сам запрос, 404 или 405.
+		next.ServeHTTP(w, probe)
+		return
+	}
+	// Шаблон маршрута — для метрик, журнала и трассировки.
+	r.Pattern = pattern
+
+	if !p.allowOrigin(origin) {
+		writeError(w, r, problemf(codeCORSForbidden, "origin %q is not allowed", origin))
+		return
+	}
+	if !p.methods[method] {
+		writeError(w, r, problemf(codeCORSForbidden, "method %s is not allowed for cross-origin requests", method))
+		return
+	}
+	requested := r.Header.Get("Access-Control-Request-Headers")
+	if !p.anyHeader {
+		for _, name := range strings.Split(requested, ",") {
+			if name = strings.TrimSpace(name); name != "" && !p.headers[strings.ToLower(name)] {
+				writeError(w, r, problemf(codeCORSForbidden, "header %q is not allowed for cross-origin requests", name))
+				return
+			}
+		}
+	}
+
+	h := w.Header()
+	p.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", method)
+	if requested != "" {
+		// Запрошенные заголовки уже проверены; "*" в ответе браузер не
+		// принимает для запросов с cookie, поэтому возвращается список.
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+	if p.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,334 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/graphql"
+	"taskapi/internal/usecase"
+	"time"
+)
+
+// GraphQL выполняет запросы к схеме задач: POST с JSON-телом
+// {"query", "operationName", "variables"}.
+func (rt *Router) GraphQL(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	var req graphql.Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, graphql.Response{Errors: []*graphql.Error{{Message: "invalid JSON body"}}})
+		return
+	}
+	resp := rt.gql.Execute(r.Context(), req)
+	// Без data — запрос не дошёл до выполнения (ошибка разбора или валидации).
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, resp)
+}
+
+// NewTaskSchema строит GraphQL-схему поверх TaskService. Связи parent,
+// subtasks и comments загружаются пакетно: один вызов сервиса на уровень
+// запроса.
+func (rt *Router) NewTaskSchema(limits graphql.Limits) (*graphql.Schema, error) {
+	statusEnum := &graphql.Enum{
+		Name: "TaskStatus",
+		Values: []*graphql.EnumValue{
+			{Name: string(domain.StatusTodo), Value: domain.StatusTodo},
+			{Name: string(domain.StatusInProgress), Value: domain.StatusInProgress},
+			{Name: string(domain.StatusDone), Value: domain.StatusDone},
+		},
+	}
+
+	task := &graphql.Object{Name: "Task"}
+	nnString := graphql.NewNonNull(graphql.String)
+	commentField := func(name string, t graphql.Type, get func(domain.Comment) any) *graphql.Field {
+		return &graphql.Field{Name: name, Type: t, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return get(src.(domain.Comment)), nil
+		}}
+	}
+	taskField := func(name string, t graphql.Type, get func(domain.Task) any) *graphql.Field {
+		return &graphql.Field{Name: name, Type: t, Resolve: func(ctx context.Context, src any, _ map[string]any) (any, error) {
+			return get(src.(domain.Task)), nil
+		}}
+	}
+	optional := func(s string) any {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	comment := &graphql.Object{Name: "Comment", Fields: []*graphql.Field{
+		commentField("id", graphql.NewNonNull(graphql.ID), func(c domain.Comment) any { return c.ID }),
+		commentField("author", graphql.String, func(c domain.Comment) any { return optional(c.Author) }),
+		commentField("body", nnString, func(c domain.Comment) any { return c.Body }),
+		commentField("createdAt", nnString, func(c domain.Comment) any { return c.CreatedAt.Format(time.RFC3339Nano) }),
+	}}
+	task.Fields = []*graphql.Field{
+		taskField("id", graphql.NewNonNull(graphql.ID), func(t domain.Task) any { return t.ID }),
+		taskField("title", nnString, func(t domain.Task) any { return t.Title }),
+		taskField("description", graphql.String, func(t domain.Task) any { return optional(t.Description) }),
+		taskField("status", graphql.NewNonNull(statusEnum), func(t domain.Task) any { return t.Status }),
+		taskField("project", graphql.String, func(t domain.Task) any { return optional(t.Project) }),
+		taskField("assignee", graphql.String, func(t domain.Task) any { return optional(t.Assignee) }),
+		taskField("parentId", graphql.ID, func(t domain.Task) any { return optional(t.ParentID) }),
+		taskField("createdAt", nnString, func(t domain.Task) any { return t.CreatedAt.Format(time.RFC3339Nano) }),
+		taskField("updatedAt", nnString, func(t domain.Task) any { return t.UpdatedAt.Format(time.RFC3339Nano) }),
+		{
+			Name:  "parent",
+			Type:  task,
+			Batch: rt.gqlParents,
+		},
+		{
+			Name:  "subtasks",
+			Type:  graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(task))),
+			Args:  []*graphql.InputValue{{Name: "status", Type: statusEnum}},
+			Batch: rt.gqlSubtasks,
+		},
+		{
+			Name:  "comments",
+			Type:  graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(comment))),
+			Batch: rt.gqlComments,
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{
+			{
+				Name: "task",
+				Type: task,
+				Args: []*graphql.InputValue{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+					t, err := rt.svc.Get(ctx, args["id"].(string))
+					if errors.Is(err, usecase.ErrNotFound) {
+						return nil, nil
+					}
+					return t, err
+				},
+			},
+			{
+				Name: "tasks",
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(task))),
+				Args: []*graphql.InputValue{
+					{Name: "status", Type: statusEnum},
+					{Name: "project", Type: graphql.String},
+					{Name: "assignee", Type: graphql.String},
+					{Name: "parentId", Type: graphql.ID},
+				},
+				Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+					var f dto.ListFilter
+					if st, ok := args["status"].(domain.Status); ok {
+						f.Status = &st
+					}
+					f.Project, _ = args["project"].(string)
+					f.Assignee, _ = args["assignee"].(string)
+					// parentId: null — только задачи верхнего уровня.
+					if v, ok := args["parentId"]; ok {
+						p, _ := v.(string)
+						f.ParentIDs = []string{p}
+					}
+					return rt.svc.List(ctx, f)
+				},
+			},
+		},
+	}
+
+	createInput := &graphql.InputObject{
+		Name: "CreateTaskInput",
+		Fields: []*graphql.InputValue{
+			{Name: "title", Type: nnString},
+			{Name: "description", Type: graphql.String},
+			{Name: "status", Type: statusEnum},
+			{Name: "project", Type: graphql.String},
+			{Name: "assignee", Type: graphql.String},
+			{Name: "parentId", Type: graphql.ID},
+		},
+	}
+	updateInput := &graphql.InputObject{
+		Name: "UpdateTaskInput",
+		Fields: []*graphql.InputValue{
+			{Name: "title", Type: graphql.String},
+			{Name: "description", Type: graphql.String},
+			{Name: "status", Type: statusEnum},
+			{Name: "project", Type: graphql.String},
+			{Name: "assignee", Type: graphql.String},
+			{Name: "parentId", Type: graphql.ID},
+		},
+	}
+	commentInput := &graphql.InputObject{
+		Name: "CommentInput",
+		Fields: []*graphql.InputValue{
+			{Name: "author", Type: graphql.String},
+			{Name: "body", Type: nnString},
+		},
+	}
+	mutation := &graphql.Object{
+		Name: "Mutation",
+		Fields: []*graphql.Field{
+			{
+				Name: "createTask",
+				Type: graphql.NewNonNull(task),
+				Args: []*graphql.InputValue{{Name: "input", Type: graphql.NewNonNull(createInput)}},
+				Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+					in := args["input"].(map[string]any)
+					var d dto.CreateInput
+					d.Title, _ = in["title"].(string)
+					d.Description, _ = in["description"].(string)
+					d.Status, _ = in["status"].(domain.Status)
+					d.Project, _ = in["project"].(string)
+					d.Assignee, _ = in["assignee"].(string)
+					d.ParentID, _ = in["parentId"].(string)
+					return rt.svc.Create(ctx, d)
+				},
+			},
+			{
+				Name: "updateTask",
+				Type: graphql.NewNonNull(task),
+				Args: []*graphql.InputValue{
+					{Name: "id", Type: graphql.NewNonNull(graphql.ID)},
+					{Name: "input", Type: graphql.NewNonNull(updateInput)},
+				},
+				Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+					in := args["input"].(map[string]any)
+					var d dto.UpdateInput
+					d.Title = gqlString(in, "title")
+					d.Description = gqlString(in, "description")
+					d.Project = gqlString(in, "project")
+					d.Assignee = gqlString(in, "assignee")
+					d.ParentID = gqlString(in, "parentId")
+					if v, ok := in["status"]; ok {
+						st, _ := v.(domain.Status)
+						d.Status = &st
+					}
+					return rt.svc.Update(ctx, args["id"].(string), d)
+				},
+			},
+			{
+				Name: "addComment",
+				Type: graphql.NewNonNull(comment),
+				Args: []*graphql.InputValue{
+					{Name: "taskId", Type: graphql.NewNonNull(graphql.ID)},
+					{Name: "input", Type: graphql.NewNonNull(commentInput)},
+				},
+				Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+					in := args["input"].(map[string]any)
+					var d dto.CommentInput
+					d.Author, _ = in["author"].(string)
+					d.Body, _ = in["body"].(string)
+					return rt.svc.AddComment(ctx, args["taskId"].(string), d)
+				},
+			},
+			{
+				Name: "deleteTask",
+				Type: graphql.NewNonNull(graphql.ID),
+				Args: []*graphql.InputValue{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+					id := args["id"].(string)
+					return id, rt.svc.Delete(ctx, id)
+				},
+			},
+		},
+	}
+
+	schema, err := graphql.NewSchema(query, mutation)
+	if err != nil {
+		return nil, err
+	}
+	schema.Limits = limits
+	return schema, nil
+}
+
+// gqlString: отсутствующее поле — nil (не менять), null — пустая строка.
+func gqlString(in map[string]any, key string) *string {
+	v, ok := in[key]
+	if !ok {
+		return nil
+	}
+	s, _ := v.(string)
+	return &s
+}
+
+func (rt *Router) gqlParents(ctx context.Context, sources []any, _ map[string]any) ([]any, error) {
+	var ids []string
+	for _, src := range sources {
+		if p := src.(domain.Task).ParentID; p != "" {
+			ids = append(ids, p)
+		}
+	}
+	out := make([]any, len(sources))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	parents, err := rt.svc.List(ctx, dto.ListFilter{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]domain.Task, len(parents))
+	for _, p := range parents {
+		byID[p.ID] = p
+	}
+	for i, src := range sources {
+		if p, ok := byID[src.(domain.Task).ParentID]; ok {
+			out[i] = p
+		}
+	}
+	return out, nil
+}
+
+func (rt *Router) gqlSubtasks(ctx context.Context, sources []any, args map[string]any) ([]any, error) {
+	ids := make([]string, len(sources))
+	for i, src := range sources {
+		ids[i] = src.(domain.Task).ID
+	}
+	f := dto.ListFilter{ParentIDs: ids}
+	if st, ok := args["status"].(domain.Status); ok {
+		f.Status = &st
+	}
+	children, err := rt.svc.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	byParent := make(map[string][]domain.Task)
+	for _, c := range children {
+		byParent[c.ParentID] = append(byParent[c.ParentID], c)
+	}
+	out := make([]any, len(sources))
+	for i, id := range ids {
+		list := byParent[id]
+		if list == nil {
+			list = []domain.Task{}
+		}
+		out[i] = list
+	}
+	return out, nil
+}
+
+func (rt *Router) gqlComments(ctx context.Context, sources []any, _ map[string]any) ([]any, error) {
+	ids := make([]string, len(sources))
+	for i, src := range sources {
+		ids[i] = src.(domain.Task).ID
+	}
+	comments, err := rt.svc.ListComments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byTask := make(map[string][]domain.Comment)
+	for _, c := range comments {
+		byTask[c.TaskID] = append(byTask[c.TaskID], c)
+	}
+	out := make([]any, len(sources))
+	for i, id := range ids {
+		list := byTask[id]
+		if list == nil {
+			list = []domain.Comment{}
+		}
+		out[i] = list
+	}
+	return out, nil
+}
@@ -2,67 +2,64 @@ package http
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"taskapi/internal/domain"
 	"taskapi/internal/dto"
 	"taskapi/internal/usecase"
+	"taskapi/internal/usecase/validation"
 )
 
-func (rt *Router) tasksCollection(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		rt.GetList(w, r)
-	case http.MethodPost:
-		rt.Create(w, r)
-	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
-	}
+func taskIDFromPath(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/tasks/"), "/")
+	return parts[0]
 }
 
 func (rt *Router) Get(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
-		return
-	}
-	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
-	id := parts[0]
+	id := taskIDFromPath(r.URL.Path)
 	if id == "" {
-		http.Error(w, "missing id", http.StatusBadRequest)
+		writeError(w, r, errMissingID)
 		return
 	}
-	reqID := requestIDFromCtx(r.Context())
-
-	t, err := rt.svc.Get(r.Context(), reqID, id)
+	t, err := rt.svc.Get(r.Context(), id)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, usecase.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeJSON(w, status, map[string]string{"error": err.Error()})
+		writeError(w, r, err)
+		return
+	}
+	v := taskValidators(r, t)
+	if v.notModified(w, r) {
 		return
 	}
-	writeJSON(w, http.StatusOK, t)
+	v.set(w)
+	respond(w, r, http.StatusOK, t)
 }
 
 func (rt *Router) GetList(w http.ResponseWriter, r *http.Request) {
-	reqID := requestIDFromCtx(r.Context())
-	var st *domain.Status
-	if q := strings.TrimSpace(r.URL.Query().Get("status")); q != "" {
-		s := domain.Status(q)
-		if s != domain.StatusTodo && s != domain.StatusInProgress && s != domain.StatusDone {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
-			return
-		}
-		st = &s
+	f, err := listFilterFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	// Ревизия читается до списка: если задачи изменятся между вызовами,
+	// старый ETag у свежих данных лишь вызовет лишнюю загрузку, а наоборот
+	// клиент получил бы 304 для устаревшей копии.
+	rev, err := rt.svc.Revision(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	v := listValidators(r, rev)
+	if v.notModified(w, r) {
+		return
 	}
-	list, err := rt.svc.List(r.Context(), reqID, st)
+	list, err := rt.svc.List(r.Context(), f)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writeError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, list)
+	v.set(w)
+	respond(w, r, http.StatusOK, list)
 }
 
 func (rt *Router) Create(w http.ResponseWriter, r *http.Request) {
@@ -71,20 +68,81 @@ func (rt *Router) Create(w http.ResponseWriter, r *http.Request) {
 	}()
 	var in dto.CreateInput
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		writeError(w, r, errInvalidJSON(err))
 		return
 	}
-	reqID := requestIDFromCtx(r.Context())
-	t, err := rt.svc.Create(r.Context(), reqID, in)
+	t, err := rt.svc.Create(r.Context(), in)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, usecase.ErrBadRequest) {
-			status = http.StatusBadRequest
-		}
-		writeJSON(w, status, map[string]string{"error": err.Error()})
+		writeError(w, r, err)
+		return
+	}
+	respond(w, r, http.StatusCreated, t)
+}
+
+func (rt *Router) Update(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	id := taskIDFromPath(r.URL.Path)
+	if id == "" {
+		writeError(w, r, errMissingID)
 		return
 	}
-	writeJSON(w, http.StatusCreated, t)
+	var in dto.UpdateInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, r, errInvalidJSON(err))
+		return
+	}
+	t, err := rt.svc.Update(r.Context(), id, in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	respond(w, r, http.StatusOK, t)
+}
+
+func (rt *Router) Delete(w http.ResponseWriter, r *http.Request) {
+	id := taskIDFromPath(r.URL.Path)
+	if id == "" {
+		writeError(w, r, errMissingID)
+		return
+	}
+	if err := rt.svc.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listFilterFromQuery разбирает ?status=&project=&assignee=&parent_id=.
+func listFilterFromQuery(r *http.Request) (dto.ListFilter, error) {
+	var f dto.ListFilter
+	q := r.URL.Query()
+	if v := strings.TrimSpace(q.Get("status")); v != "" {
+		s := domain.Status(v)
+		if !validation.IsValidStatus(s) {
+			return f, &usecase.Error{Code: usecase.CodeValidationFailed, Message: "invalid filter", Fields: []usecase.FieldError{
+				{Field: "query.status", Code: usecase.FieldInvalid, Message: fmt.Sprintf("unknown status %q", v)},
+			}}
+		}
+		f.Status = &s
+	}
+	f.Project = strings.TrimSpace(q.Get("project"))
+	f.Assignee = strings.TrimSpace(q.Get("assignee"))
+	if v := strings.TrimSpace(q.Get("parent_id")); v != "" {
+		f.ParentIDs = []string{v}
+	}
+	return f, nil
+}
+
+var errMissingID = problemf(usecase.CodeValidationFailed, "missing id in path")
+
+func errMethodNotAllowed(r *http.Request) error {
+	return problemf(codeMethodNotAllowed, "method %s is not allowed for %s", r.Method, r.URL.Path)
+}
+
+func errInvalidJSON(err error) error {
+	return problemf(codeInvalidBody, "request body is not valid JSON: %v", err)
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
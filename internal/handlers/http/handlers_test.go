@@ -1,36 +1,766 @@
 package http_test
 
 import (
+	"bufio"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"taskapi/internal/domain"
 	"taskapi/internal/dto"
+	"taskapi/internal/events"
+	"taskapi/internal/graphql"
 	httpHandler "taskapi/internal/handlers/http"
+	"taskapi/internal/health"
+	"taskapi/internal/logger"
+	"taskapi/internal/metrics"
+	"taskapi/internal/repository/memory"
+	"taskapi/internal/tracing"
 	"taskapi/internal/usecase"
+	"taskapi/internal/webhook"
+	"taskapi/internal/websocket"
 )
 
 type mockTaskService struct {
-	createFn func(ctx context.Context, reqID string, in dto.CreateInput) (domain.Task, error)
-	getFn    func(ctx context.Context, reqID, id string) (domain.Task, error)
-	listFn   func(ctx context.Context, reqID string, status *domain.Status) ([]domain.Task, error)
+	createFn func(ctx context.Context, in dto.CreateInput) (domain.Task, error)
+	getFn    func(ctx context.Context, id string) (domain.Task, error)
+	listFn   func(ctx context.Context, f dto.ListFilter) ([]domain.Task, error)
+	updateFn func(ctx context.Context, id string, in dto.UpdateInput) (domain.Task, error)
+	deleteFn func(ctx context.Context, id string) error
+
+	addCommentFn   func(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error)
+	listCommentsFn func(ctx context.Context, taskIDs []string) ([]domain.Comment, error)
+}
+
+func (m *mockTaskService) Create(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+	return m.createFn(ctx, in)
+}
+func (m *mockTaskService) Get(ctx context.Context, id string) (domain.Task, error) {
+	return m.getFn(ctx, id)
+}
+func (m *mockTaskService) List(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+	return m.listFn(ctx, f)
+}
+func (m *mockTaskService) Update(ctx context.Context, id string, in dto.UpdateInput) (domain.Task, error) {
+	return m.updateFn(ctx, id, in)
+}
+func (m *mockTaskService) Delete(ctx context.Context, id string) error {
+	return m.deleteFn(ctx, id)
 }
+func (m *mockTaskService) Revision(context.Context) (domain.Revision, error) {
+	return domain.Revision{}, nil
+}
+func (m *mockTaskService) AddComment(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error) {
+	return m.addCommentFn(ctx, taskID, in)
+}
+func (m *mockTaskService) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	return m.listCommentsFn(ctx, taskIDs)
+}
+
+func TestRouter_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		serviceErr error
+		wantCode   int
+	}{
+		{"success", "1", `{"status":"done"}`, nil, http.StatusOK},
+		{"bad json", "1", `{`, nil, http.StatusBadRequest},
+		{"bad request", "1", `{"title":""}`, usecase.ErrBadRequest, http.StatusBadRequest},
+		{"not found", "2", `{"status":"done"}`, usecase.ErrNotFound, http.StatusNotFound},
+		{"missing id", "", `{}`, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockTaskService{
+				updateFn: func(ctx context.Context, id string, in dto.UpdateInput) (domain.Task, error) {
+					return domain.Task{ID: id}, tt.serviceErr
+				},
+			}
+
+			rt := httpHandler.NewRouter(svc, nil)
+
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/"+tt.id, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			rt.Update(rr, req)
 
-func (m *mockTaskService) Create(ctx context.Context, reqID string, in dto.CreateInput) (domain.Task, error) {
-	return m.createFn(ctx, reqID, in)
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d", tt.wantCode, rr.Code)
+			}
+		})
+	}
 }
-func (m *mockTaskService) Get(ctx context.Context, reqID, id string) (domain.Task, error) {
-	return m.getFn(ctx, reqID, id)
+
+func TestRouter_Delete(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		serviceErr error
+		wantCode   int
+	}{
+		{"success", "1", nil, http.StatusNoContent},
+		{"not found", "2", usecase.ErrNotFound, http.StatusNotFound},
+		{"internal error", "3", errors.New("db error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockTaskService{
+				deleteFn: func(ctx context.Context, id string) error {
+					return tt.serviceErr
+				},
+			}
+
+			rt := httpHandler.NewRouter(svc, nil)
+
+			req := httptest.NewRequest(http.MethodDelete, "/tasks/"+tt.id, nil)
+			rr := httptest.NewRecorder()
+			rt.Delete(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d", tt.wantCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_Events(t *testing.T) {
+	hub := events.NewHub(10)
+	rt := httpHandler.NewRouter(&mockTaskService{}, nopLogger{}, httpHandler.WithEvents(hub, time.Hour))
+	srv := httptest.NewServer(rt.Handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?status=done", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	for hub.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	hub.Publish(ctx, usecase.TaskEvent{Type: usecase.EventTaskCreated, Task: domain.Task{ID: "skip", Status: domain.StatusTodo}})
+	hub.Publish(ctx, usecase.TaskEvent{Type: usecase.EventTaskUpdated, Task: domain.Task{ID: "1", Status: domain.StatusDone}})
+
+	sc := bufio.NewScanner(resp.Body)
+	var got []string
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "id: ") || strings.HasPrefix(line, "event: ") {
+			got = append(got, line)
+		}
+		if len(got) == 2 {
+			break
+		}
+	}
+	want := []string{"id: 2", "event: " + usecase.EventTaskUpdated}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	cancel()
+	for hub.Subscribers() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRouter_WebSocket(t *testing.T) {
+	hub := events.NewHub(10)
+	svc := &mockTaskService{
+		createFn: func(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+			switch in.Title {
+			case "":
+				return domain.Task{}, &usecase.Error{Code: usecase.CodeValidationFailed, Message: "invalid task",
+					Fields: []usecase.FieldError{{Field: "title", Code: usecase.FieldRequired, Message: "title is required"}}}
+			case "crash":
+				return domain.Task{}, errors.New("disk /var/lib/taskapi is full")
+			}
+			return domain.Task{ID: "1", Title: in.Title}, nil
+		},
+	}
+	rt := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithEvents(hub, time.Hour))
+	srv := httptest.NewServer(rt.Handler())
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	// Страница с чужого сайта не может открыть канал от имени посетителя.
+	if c, err := websocket.Dial(context.Background(), wsURL, http.Header{"Origin": {"https://evil.example.org"}}); err == nil {
+		c.Close()
+		t.Fatal("expected cross-origin handshake to be rejected")
+	}
+	conn, err := websocket.Dial(context.Background(), wsURL, http.Header{"Origin": {srv.URL}})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	type reply struct {
+		ID     string               `json:"id"`
+		Type   string               `json:"type"`
+		Seq    uint64               `json:"seq"`
+		Data   json.RawMessage      `json:"data"`
+		Code   usecase.Code         `json:"code"`
+		Error  string               `json:"error"`
+		Errors []usecase.FieldError `json:"errors"`
+	}
+	roundTrip := func(cmd string) reply {
+		t.Helper()
+		if err := conn.WriteMessage(websocket.OpText, []byte(cmd)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		var r reply
+		_ = json.Unmarshal(msg, &r)
+		return r
+	}
+
+	if r := roundTrip(`{"id":"1","type":"create","data":{"title":"Task"}}`); r.ID != "1" || r.Type != "result" {
+		t.Errorf("unexpected create reply: %+v", r)
+	}
+	if r := roundTrip(`{"id":"2","type":"create","data":{}}`); r.Type != "error" || r.Code != usecase.CodeValidationFailed ||
+		len(r.Errors) != 1 || r.Errors[0].Field != "title" {
+		t.Errorf("unexpected error reply: %+v", r)
+	}
+	// Текст внутренней ошибки остается в журнале, клиент видит только код.
+	if r := roundTrip(`{"id":"2","type":"create","data":{"title":"crash"}}`); r.Code != usecase.CodeInternal || strings.Contains(r.Error, "disk") {
+		t.Errorf("internal error must not leak: %+v", r)
+	}
+	if r := roundTrip(`{"id":"3","type":"nope"}`); r.Type != "error" || r.Code != "unknown_command" {
+		t.Errorf("expected error for unknown command, got %+v", r)
+	}
+	if r := roundTrip(`{"id":"3","type":"list","status":"later"}`); r.Code != usecase.CodeValidationFailed || len(r.Errors) != 1 {
+		t.Errorf("expected validation error for unknown status, got %+v", r)
+	}
+	if r := roundTrip(`{"id":"4","type":"subscribe","status":"done"}`); r.Type != "subscribed" {
+		t.Fatalf("unexpected subscribe reply: %+v", r)
+	}
+
+	hub.Publish(context.Background(), usecase.TaskEvent{Type: usecase.EventTaskUpdated, Task: domain.Task{ID: "skip", Status: domain.StatusTodo}})
+	hub.Publish(context.Background(), usecase.TaskEvent{Type: usecase.EventTaskUpdated, Task: domain.Task{ID: "1", Status: domain.StatusDone}})
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var r reply
+	_ = json.Unmarshal(msg, &r)
+	if r.Type != "event" || r.Seq != 2 {
+		t.Errorf("expected filtered event with seq 2, got %+v", r)
+	}
+}
+
+func TestRouter_RPC(t *testing.T) {
+	svc := &mockTaskService{
+		createFn: func(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+			switch in.Title {
+			case "":
+				return domain.Task{}, &usecase.Error{Code: usecase.CodeValidationFailed, Message: "invalid task",
+					Fields: []usecase.FieldError{{Field: "title", Code: usecase.FieldRequired, Message: "title is required"}}}
+			case "crash":
+				return domain.Task{}, errors.New("disk /var/lib/taskapi is full")
+			}
+			return domain.Task{ID: "1", Title: in.Title}, nil
+		},
+		getFn: func(ctx context.Context, id string) (domain.Task, error) {
+			return domain.Task{}, usecase.ErrNotFound
+		},
+		listFn: func(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+			if f.Assignee != "" || f.ParentIDs != nil {
+				return []domain.Task{{ID: f.Assignee + "/" + strings.Join(f.ParentIDs, ",")}}, nil
+			}
+			return []domain.Task{{ID: "1"}}, nil
+		},
+		addCommentFn: func(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error) {
+			if taskID != "1" {
+				return domain.Comment{}, usecase.ErrNotFound
+			}
+			return domain.Comment{ID: "c1", TaskID: taskID, Author: in.Author, Body: in.Body}, nil
+		},
+		listCommentsFn: func(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+			if len(taskIDs) == 1 && taskIDs[0] == "1" {
+				return []domain.Comment{{ID: "c1", TaskID: "1", Body: "hi"}}, nil
+			}
+			return nil, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"list filters", `{"jsonrpc":"2.0","method":"tasks.list","params":{"assignee":"alice","parent_id":"p1"},"id":1}`, http.StatusOK, `"result":[{"id":"alice/p1"`},
+		{"add comment", `{"jsonrpc":"2.0","method":"tasks.addComment","params":{"task_id":"1","author":"bob","body":"hi"},"id":1}`, http.StatusOK, `"result":{"id":"c1","task_id":"1","author":"bob","body":"hi"`},
+		{"add comment to unknown task", `{"jsonrpc":"2.0","method":"tasks.addComment","params":{"task_id":"x","body":"hi"},"id":1}`, http.StatusOK, `"code":-32001`},
+		{"add comment without task", `{"jsonrpc":"2.0","method":"tasks.addComment","params":{"body":"hi"},"id":1}`, http.StatusOK, `"code":-32602`},
+		{"list comments", `{"jsonrpc":"2.0","method":"tasks.listComments","params":{"task_ids":["1"]},"id":1}`, http.StatusOK, `"result":[{"id":"c1"`},
+		{"no comments", `{"jsonrpc":"2.0","method":"tasks.listComments","params":{"task_ids":["2"]},"id":1}`, http.StatusOK, `"result":[]`},
+		{"list comments without tasks", `{"jsonrpc":"2.0","method":"tasks.listComments","params":{},"id":1}`, http.StatusOK, `"code":-32602`},
+		{"create", `{"jsonrpc":"2.0","method":"tasks.create","params":{"title":"T"},"id":1}`, http.StatusOK, `"result":{"id":"1"`},
+		{"bad request", `{"jsonrpc":"2.0","method":"tasks.create","params":{},"id":2}`, http.StatusOK, `"code":-32002`},
+		{"not found", `{"jsonrpc":"2.0","method":"tasks.get","params":{"id":"x"},"id":"a"}`, http.StatusOK, `"code":-32001`},
+		{"missing id param", `{"jsonrpc":"2.0","method":"tasks.get","params":{},"id":3}`, http.StatusOK, `"code":-32602`},
+		{"positional params", `{"jsonrpc":"2.0","method":"tasks.get","params":["x"],"id":3}`, http.StatusOK, `"code":-32602`},
+		{"unknown method", `{"jsonrpc":"2.0","method":"tasks.nope","id":4}`, http.StatusOK, `"code":-32601`},
+		{"invalid version", `{"jsonrpc":"1.0","method":"tasks.list","id":5}`, http.StatusOK, `"code":-32600`},
+		{"parse error", `{"jsonrpc":`, http.StatusOK, `"code":-32700`},
+		{"empty batch", `[]`, http.StatusOK, `"code":-32600`},
+		{"notification", `{"jsonrpc":"2.0","method":"tasks.list"}`, http.StatusNoContent, ``},
+		{"batch", `[{"jsonrpc":"2.0","method":"tasks.list","id":1},{"jsonrpc":"2.0","method":"tasks.list"},1]`, http.StatusOK, `[{"jsonrpc":"2.0","result":[{"id":"1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := httpHandler.NewRouter(svc, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			rt.RPC(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d", tt.wantCode, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_GraphQL(t *testing.T) {
+	tasks := []domain.Task{
+		{ID: "1", Title: "root A", Status: domain.StatusTodo},
+		{ID: "2", Title: "root B", Status: domain.StatusTodo},
+		{ID: "3", Title: "child A1", Status: domain.StatusDone, ParentID: "1"},
+		{ID: "4", Title: "child A2", Status: domain.StatusTodo, ParentID: "1"},
+		{ID: "5", Title: "child B1", Status: domain.StatusDone, ParentID: "2"},
+	}
+	comments := []domain.Comment{
+		{ID: "c1", TaskID: "3", Author: "ann", Body: "done"},
+		{ID: "c2", TaskID: "1", Body: "split it"},
+	}
+	var lists int
+	svc := &mockTaskService{
+		listFn: func(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+			lists++
+			var out []domain.Task
+			for _, task := range tasks {
+				if f.Status != nil && task.Status != *f.Status {
+					continue
+				}
+				if f.IDs != nil && !slices.Contains(f.IDs, task.ID) {
+					continue
+				}
+				if f.ParentIDs != nil && !slices.Contains(f.ParentIDs, task.ParentID) {
+					continue
+				}
+				out = append(out, task)
+			}
+			return out, nil
+		},
+		getFn: func(ctx context.Context, id string) (domain.Task, error) {
+			for _, task := range tasks {
+				if task.ID == id {
+					return task, nil
+				}
+			}
+			return domain.Task{}, usecase.ErrNotFound
+		},
+		createFn: func(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+			if in.Title == "" {
+				return domain.Task{}, usecase.ErrBadRequest
+			}
+			return domain.Task{ID: "9", Title: in.Title, Status: domain.StatusTodo, ParentID: in.ParentID}, nil
+		},
+		listCommentsFn: func(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+			lists++
+			var out []domain.Comment
+			for _, c := range comments {
+				if slices.Contains(taskIDs, c.TaskID) {
+					out = append(out, c)
+				}
+			}
+			return out, nil
+		},
+		addCommentFn: func(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error) {
+			return domain.Comment{ID: "c9", TaskID: taskID, Author: in.Author, Body: in.Body}, nil
+		},
+	}
+	rt := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithGraphQL(graphql.Limits{MaxDepth: 4}))
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantBody  string
+		wantLists int
+	}{
+		{
+			"subtasks batched per level",
+			`{"query":"{ tasks(parentId: null) { id subtasks(status: done) { id parent { id } } } }"}`,
+			http.StatusOK,
+			`{"data":{"tasks":[{"id":"1","subtasks":[{"id":"3","parent":{"id":"1"}}]},{"id":"2","subtasks":[{"id":"5","parent":{"id":"2"}}]}]}}`,
+			3,
+		},
+		{
+			"variables",
+			`{"query":"query($id: ID!) { task(id: $id) { title status } }","variables":{"id":"4"}}`,
+			http.StatusOK,
+			`{"data":{"task":{"title":"child A2","status":"todo"}}}`,
+			0,
+		},
+		{
+			"comments batched per level",
+			`{"query":"{ tasks(parentId: null) { id comments { body } subtasks { id comments { author } } } }"}`,
+			http.StatusOK,
+			`{"data":{"tasks":[{"id":"1","comments":[{"body":"split it"}],"subtasks":[{"id":"3","comments":[{"author":"ann"}]},{"id":"4","comments":[]}]},{"id":"2","comments":[],"subtasks":[{"id":"5","comments":[]}]}]}}`,
+			4,
+		},
+		{
+			"add comment",
+			`{"query":"mutation { addComment(taskId: \"1\", input: {body: \"ok\"}) { id author body } }"}`,
+			http.StatusOK,
+			`{"data":{"addComment":{"id":"c9","author":null,"body":"ok"}}}`,
+			0,
+		},
+		{
+			"unknown task is null",
+			`{"query":"{ task(id: \"42\") { title } }"}`,
+			http.StatusOK,
+			`{"data":{"task":null}}`,
+			0,
+		},
+		{
+			"mutation",
+			`{"query":"mutation { createTask(input: {title: \"new\", parentId: \"1\"}) { id parentId } }"}`,
+			http.StatusOK,
+			`{"data":{"createTask":{"id":"9","parentId":"1"}}}`,
+			0,
+		},
+		{
+			"resolver error",
+			`{"query":"mutation { createTask(input: {title: \"\"}) { id } }"}`,
+			http.StatusOK,
+			`"path":["createTask"]`,
+			0,
+		},
+		{
+			"depth limit",
+			`{"query":"{ tasks { subtasks { subtasks { subtasks { subtasks { id } } } } } }"}`,
+			http.StatusBadRequest,
+			`depth`,
+			0,
+		},
+		{
+			"invalid JSON",
+			`{"query":`,
+			http.StatusBadRequest,
+			`invalid JSON`,
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lists = 0
+			req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			rt.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.wantBody, rr.Body.String())
+			}
+			if lists != tt.wantLists {
+				t.Errorf("expected %d List calls, got %d", tt.wantLists, lists)
+			}
+		})
+	}
+}
+
+func TestRouter_OpenAPI(t *testing.T) {
+	var created int
+	svc := &mockTaskService{
+		createFn: func(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+			created++
+			return domain.Task{ID: "1", Title: in.Title, Status: domain.StatusTodo}, nil
+		},
+	}
+	rt := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithWebhooks(webhook.NewStore()))
+	h := rt.Handler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("expected openapi 3.1.0, got %q", doc.OpenAPI)
+	}
+	for path, methods := range map[string][]string{
+		"/tasks":      {"get", "post"},
+		"/tasks/{id}": {"get", "patch", "delete"},
+		"/webhooks":   {"get", "post"},
+		"/rpc":        {"post"},
+	} {
+		for _, m := range methods {
+			if _, ok := doc.Paths[path][m]; !ok {
+				t.Errorf("spec is missing %s %s", m, path)
+			}
+		}
+	}
+	if _, ok := doc.Paths["/graphql"]; ok {
+		t.Error("disabled GraphQL must not be in the spec")
+	}
+	spec := rr.Body.String()
+	for _, mt := range []string{`"text/csv"`, `"application/xml"`, `"application/msgpack"`, `"application/cbor"`, `"application/x-www-form-urlencoded"`} {
+		if !strings.Contains(spec, mt) {
+			t.Errorf("spec does not mention %s", mt)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"/openapi.json"`) {
+		t.Errorf("unexpected docs page: %d", rr.Code)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"valid create", http.MethodPost, "/tasks", `{"title":"T"}`, http.StatusCreated, `"id":"1"`},
+		{"missing title", http.MethodPost, "/tasks", `{"description":"d"}`, http.StatusBadRequest, `{"field":"body.title","code":"required","message":"is required"}`},
+		{"unknown status", http.MethodPost, "/tasks", `{"title":"T","status":"later"}`, http.StatusBadRequest, `{"field":"body.status","code":"invalid","message":"must be one of todo, in_progress, done"}`},
+		{"patch wrong type", http.MethodPatch, "/tasks/1", `{"title":5}`, http.StatusBadRequest, `{"field":"body.title","code":"invalid","message":"must be string or null"}`},
+		{"list bad status", http.MethodGet, "/tasks?status=nope", ``, http.StatusBadRequest, `query.status`},
+		{"webhook url", http.MethodPost, "/webhooks", `{"url":"ftp"}`, http.StatusBadRequest, `{"field":"body.url","code":"invalid","message":"must be an absolute URI"}`},
+		{"rpc keeps its errors", http.MethodPost, "/rpc", `{"jsonrpc":`, http.StatusOK, `"code":-32700`},
+		{"method not allowed", http.MethodPut, "/tasks", ``, http.StatusMethodNotAllowed, `"code":"method_not_allowed"`},
+		{"unknown path", http.MethodGet, "/tasks/1/extra", ``, http.StatusNotFound, `"code":"route_not_found"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created = 0
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.wantBody, rr.Body.String())
+			}
+			if rr.Code == http.StatusBadRequest && created != 0 {
+				t.Error("handler must not run for an invalid request")
+			}
+			if ct := rr.Header().Get("Content-Type"); rr.Code >= 400 && ct != "application/problem+json" {
+				t.Errorf("expected application/problem+json, got %q", ct)
+			}
+		})
+	}
+}
+
+func TestRouter_Metrics(t *testing.T) {
+	svc := &mockTaskService{
+		getFn: func(ctx context.Context, id string) (domain.Task, error) {
+			return domain.Task{}, usecase.ErrNotFound
+		},
+	}
+	reg := metrics.NewRegistry()
+	h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithMetrics(reg)).Handler()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/43", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`)))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected metrics response: %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`taskapi_http_requests_total{route="/tasks/{id}",method="GET",status="404"} 2`,
+		`taskapi_http_requests_total{route="unmatched",method="GET",status="404"} 1`,
+		`taskapi_http_requests_total{route="/tasks",method="POST",status="400"} 1`,
+		`taskapi_http_request_duration_seconds_count{route="/tasks/{id}",method="GET"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output is missing %s\n%s", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	httpHandler.NewRouter(svc, nopLogger{}).Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without metrics, got %d", rr.Code)
+	}
+}
+
+func TestRouter_Health(t *testing.T) {
+	reg := health.NewRegistry()
+	reg.Register(health.Check{Name: "logger", Fn: func(context.Context) error { return nil }})
+	h := httpHandler.NewRouter(&mockTaskService{}, nopLogger{}, httpHandler.WithHealth(reg)).Handler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr
+	}
+	for _, target := range []string{"/livez", "/readyz", "/health"} {
+		if rr := get(target); rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+			t.Errorf("%s: expected 200 ok, got %d %q", target, rr.Code, rr.Body.String())
+		}
+	}
+
+	reg.SetShuttingDown()
+	if rr := get("/livez"); rr.Code != http.StatusOK {
+		t.Errorf("liveness must not depend on shutdown, got %d", rr.Code)
+	}
+	for _, target := range []string{"/readyz", "/health?verbose"} {
+		if rr := get(target); rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503 during shutdown, got %d", target, rr.Code)
+		}
+	}
+}
+
+type spanRecorder struct{ spans []tracing.SpanData }
+
+func (r *spanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+type entryRecorder struct{ entries []logger.Entry }
+
+func (r *entryRecorder) Log(e logger.Entry)        { r.entries = append(r.entries, e) }
+func (r *entryRecorder) Enabled(logger.Level) bool { return true }
+func (r *entryRecorder) Stop()                     {}
+
+func TestRouter_Tracing(t *testing.T) {
+	rec := &spanRecorder{}
+	tr := tracing.New(rec, tracing.Options{SampleRatio: 1})
+	log := &entryRecorder{}
+	svc := usecase.NewService(tracing.Repository(memory.New(), tr, "memory"), log)
+	svc.Tracer = tr
+	h := httpHandler.NewRouter(svc, log, httpHandler.WithTracing(tr)).Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	_ = tr.Shutdown(context.Background())
+
+	names := make([]string, len(rec.spans))
+	for i, s := range rec.spans {
+		names[i] = s.Name
+		if s.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("span %s is outside the caller's trace", s.Name)
+		}
+	}
+	if want := []string{"repo.GetByID", "usecase.Get", "GET /tasks/{id}"}; !slices.Equal(names, want) {
+		t.Fatalf("expected spans %v, got %v", want, names)
+	}
+	server := rec.spans[2]
+	if server.Parent.String() != "00f067aa0ba902b7" || server.Attributes["http.response.status_code"] != 404 {
+		t.Errorf("unexpected server span: %+v", server)
+	}
+	if len(log.entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(log.entries))
+	}
+	for _, e := range log.entries {
+		if e.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || e.SpanID == "" {
+			t.Errorf("entry %s has no trace ids: %+v", e.Event, e)
+		}
+	}
+
+	log.entries = nil
+	req = httptest.NewRequest(http.MethodGet, "/tasks/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	httpHandler.NewRouter(usecase.NewService(memory.New(), log), log).Handler().ServeHTTP(httptest.NewRecorder(), req)
+	for _, e := range log.entries {
+		if e.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("without tracing the caller's trace id must still be logged: %+v", e)
+		}
+	}
 }
-func (m *mockTaskService) List(ctx context.Context, reqID string, status *domain.Status) ([]domain.Task, error) {
-	return m.listFn(ctx, reqID, status)
+
+func TestRouter_RequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"gateway id", "gw-7f3a.1:2", true},
+		{"missing", "", false},
+		{"invalid charset", "bad id\r\n", false},
+		{"too long", strings.Repeat("a", 200), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &entryRecorder{}
+			h := httpHandler.NewRouter(usecase.NewService(memory.New(), log), log).Handler()
+			req := httptest.NewRequest(http.MethodGet, "/tasks/42", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			got := w.Header().Get("X-Request-ID")
+			if tt.keep && got != tt.incoming || !tt.keep && (got == tt.incoming || len(got) != 26) {
+				t.Errorf("unexpected response request ID %q", got)
+			}
+			for _, e := range log.entries {
+				if e.RequestID != got {
+					t.Errorf("entry %s logged request ID %q, want %q", e.Event, e.RequestID, got)
+				}
+			}
+		})
+	}
 }
 
+type nopLogger struct{}
+
+func (nopLogger) Log(logger.Entry)          {}
+func (nopLogger) Enabled(logger.Level) bool { return true }
+func (nopLogger) Stop()                     {}
+
 func TestRouter_Get(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -48,7 +778,7 @@ func TestRouter_Get(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := &mockTaskService{
-				getFn: func(ctx context.Context, reqID, id string) (domain.Task, error) {
+				getFn: func(ctx context.Context, id string) (domain.Task, error) {
 					return tt.serviceRes, tt.serviceErr
 				},
 			}
@@ -83,7 +813,7 @@ func TestRouter_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := &mockTaskService{
-				createFn: func(ctx context.Context, reqID string, in dto.CreateInput) (domain.Task, error) {
+				createFn: func(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
 					return tt.serviceRes, tt.serviceErr
 				},
 			}
@@ -125,7 +855,7 @@ func TestRouter_GetList(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := &mockTaskService{
-				listFn: func(ctx context.Context, reqID string, status *domain.Status) ([]domain.Task, error) {
+				listFn: func(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
 					return tt.serviceRes, tt.serviceErr
 				},
 			}
@@ -142,3 +872,685 @@ func TestRouter_GetList(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_Webhooks(t *testing.T) {
+	rt := httpHandler.NewRouter(&mockTaskService{}, nopLogger{}, httpHandler.WithWebhooks(webhook.NewStore()))
+	h := rt.Handler()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+	}{
+		{"create invalid url", http.MethodPost, "/webhooks", `{"url":"not-a-url"}`, http.StatusBadRequest},
+		{"create bad json", http.MethodPost, "/webhooks", `{`, http.StatusBadRequest},
+		{"list", http.MethodGet, "/webhooks", "", http.StatusOK},
+		{"get missing", http.MethodGet, "/webhooks/missing", "", http.StatusNotFound},
+		{"deliveries missing", http.MethodGet, "/webhooks/missing/deliveries", "", http.StatusNotFound},
+		{"delete missing", http.MethodDelete, "/webhooks/missing", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d", tt.wantCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_Timeouts(t *testing.T) {
+	// Сервис ждет отмены контекста и возвращает ошибку, как хранилище,
+	// прервавшее долгий обход.
+	svc := &mockTaskService{
+		getFn: func(ctx context.Context, id string) (domain.Task, error) {
+			<-ctx.Done()
+			return domain.Task{}, ctx.Err()
+		},
+		listFn: func(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+			if _, ok := ctx.Deadline(); ok {
+				return nil, errors.New("unexpected deadline")
+			}
+			return []domain.Task{}, nil
+		},
+	}
+	h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithTimeouts(httpHandler.Timeouts{
+		Default: time.Minute,
+		Max:     50 * time.Millisecond,
+		Routes:  map[string]time.Duration{"GET /tasks/{id}": 20 * time.Millisecond, "GET /tasks": 0},
+	})).Handler()
+
+	tests := []struct {
+		name        string
+		path        string
+		header      string
+		wantStatus  int
+		wantCode    string
+		wantTimeout string
+	}{
+		{"route timeout", "/tasks/1", "", http.StatusGatewayTimeout, "request_timeout", "20ms"},
+		{"client deadline", "/tasks/1", "30ms", http.StatusGatewayTimeout, "request_timeout", "30ms"},
+		{"client deadline capped", "/tasks/1", "1h", http.StatusGatewayTimeout, "request_timeout", "50ms"},
+		{"client deadline exhausted", "/tasks/1", "0s", http.StatusServiceUnavailable, "deadline_exceeded", ""},
+		{"invalid header", "/tasks/1", "soon", http.StatusBadRequest, "invalid_header", ""},
+		{"no limit", "/tasks", "", http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(httpHandler.HeaderRequestTimeout, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body["code"] != tt.wantCode || tt.wantTimeout != "" && body["timeout"] != tt.wantTimeout {
+				t.Errorf("unexpected body %v", body)
+			}
+		})
+	}
+
+	r := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithTimeouts(httpHandler.Timeouts{
+		Routes: map[string]time.Duration{"GET /task/{id}": time.Second},
+	}))
+	if err := r.CheckTimeouts(); err == nil || !strings.Contains(err.Error(), "GET /task/{id}") {
+		t.Errorf("expected unknown route error, got %v", err)
+	}
+}
+
+func TestRouter_Recover(t *testing.T) {
+	svc := &mockTaskService{
+		getFn: func(ctx context.Context, id string) (domain.Task, error) {
+			panic("boom " + id)
+		},
+		createFn: func(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+			var m map[string]int
+			m["x"]++
+			return domain.Task{}, nil
+		},
+	}
+	tests := []struct {
+		name     string
+		dump     bool
+		req      func() *http.Request
+		wantDump bool
+	}{
+		{
+			name: "panic value",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/tasks/42", nil) },
+		},
+		{
+			name: "runtime error with dump",
+			dump: true,
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"t"}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			},
+			wantDump: true,
+		},
+		{
+			name: "no dump in production",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"t"}`))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &entryRecorder{}
+			reg := metrics.NewRegistry()
+			h := httpHandler.NewRouter(svc, log, httpHandler.WithMetrics(reg), httpHandler.WithRequestDump(tt.dump)).Handler()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.req())
+
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("expected 500, got %d", w.Code)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if id := w.Header().Get("X-Request-ID"); body["request_id"] != id || body["code"] != "internal_error" {
+				t.Errorf("unexpected body %v for request %s", body, id)
+			}
+
+			var panicEntry *logger.Entry
+			for i, e := range log.entries {
+				if e.Event == "panic_recovered" {
+					panicEntry = &log.entries[i]
+				}
+			}
+			if panicEntry == nil {
+				t.Fatalf("no panic_recovered entry in %+v", log.entries)
+			}
+			if stack, _ := panicEntry.Data["stack"].(string); !strings.Contains(stack, "goroutine") {
+				t.Errorf("expected a stack trace, got %q", stack)
+			}
+			req, ok := panicEntry.Data["request"].(map[string]any)
+			if ok != tt.wantDump {
+				t.Fatalf("request dump present = %v, want %v", ok, tt.wantDump)
+			}
+			if tt.wantDump {
+				if b, _ := req["body"].(map[string]any); b["title"] != "t" {
+					t.Errorf("unexpected dumped body %v", req["body"])
+				}
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			if !strings.Contains(rr.Body.String(), "taskapi_http_panics_total{route=") {
+				t.Errorf("panic is not counted:\n%s", rr.Body)
+			}
+		})
+	}
+
+	t.Run("response already started", func(t *testing.T) {
+		svc := &mockTaskService{
+			listFn: func(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+				return []domain.Task{{ID: "1"}}, nil
+			},
+		}
+		log := &entryRecorder{}
+		h := httpHandler.NewRouter(svc, log).Handler()
+		w := &panickingWriter{ResponseRecorder: httptest.NewRecorder()}
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler, got %v", p)
+			}
+			if len(log.entries) == 0 || log.entries[0].Event != "panic_recovered" {
+				t.Errorf("expected panic_recovered entry, got %+v", log.entries)
+			}
+		}()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	})
+}
+
+// panickingWriter паникует на записи тела, когда заголовок ответа уже ушел.
+type panickingWriter struct{ *httptest.ResponseRecorder }
+
+func (w *panickingWriter) Write([]byte) (int, error) { panic("write failed") }
+
+func TestRouter_Problems(t *testing.T) {
+	h := httpHandler.NewRouter(usecase.NewService(memory.New(), nopLogger{}), nopLogger{},
+		httpHandler.WithWebhooks(webhook.NewStore()),
+		httpHandler.WithEvents(events.NewHub(10), time.Second),
+	).Handler()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantField  string
+		wantAllow  string
+	}{
+		{"task not found", http.MethodGet, "/tasks/42", "", http.StatusNotFound, "task_not_found", "", ""},
+		{"unknown parent", http.MethodPost, "/tasks", `{"title":"T","parent_id":"nope"}`, http.StatusBadRequest, "validation_failed", "parent_id", ""},
+		{"invalid json", http.MethodPatch, "/tasks/42", `{"title":`, http.StatusBadRequest, "validation_failed", "body", ""},
+		{"method not allowed", http.MethodPut, "/tasks/42", "", http.StatusMethodNotAllowed, "method_not_allowed", "", "DELETE, GET, HEAD, PATCH"},
+		{"route not found", http.MethodGet, "/nope", "", http.StatusNotFound, "route_not_found", "", ""},
+		{"webhook not found", http.MethodGet, "/webhooks/w1", "", http.StatusNotFound, "webhook_not_found", "", ""},
+		{"websocket handshake", http.MethodGet, "/ws", "", http.StatusBadRequest, "websocket_handshake_failed", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("expected application/problem+json, got %q", ct)
+			}
+			if tt.wantAllow != "" && w.Header().Get("Allow") != tt.wantAllow {
+				t.Errorf("expected Allow %q, got %q", tt.wantAllow, w.Header().Get("Allow"))
+			}
+			var p struct {
+				Type      string `json:"type"`
+				Title     string `json:"title"`
+				Status    int    `json:"status"`
+				Detail    string `json:"detail"`
+				Instance  string `json:"instance"`
+				Code      string `json:"code"`
+				RequestID string `json:"request_id"`
+				Errors    []struct {
+					Field string `json:"field"`
+				} `json:"errors"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if p.Code != tt.wantCode || p.Type != "urn:taskapi:problem:"+tt.wantCode || p.Status != tt.wantStatus {
+				t.Errorf("unexpected problem %+v", p)
+			}
+			if p.Title == "" || p.Detail == "" || p.Instance != req.URL.Path || p.RequestID != w.Header().Get("X-Request-ID") {
+				t.Errorf("incomplete problem %+v", p)
+			}
+			if tt.wantField != "" && (len(p.Errors) == 0 || p.Errors[0].Field != tt.wantField) {
+				t.Errorf("expected an error for field %s, got %+v", tt.wantField, p.Errors)
+			}
+		})
+	}
+}
+
+func TestRouter_ContentNegotiation(t *testing.T) {
+	svc := usecase.NewService(memory.New(), nopLogger{})
+	if _, err := svc.Create(context.Background(), dto.CreateInput{Title: "Первая"}); err != nil {
+		t.Fatal(err)
+	}
+	h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithWebhooks(webhook.NewStore())).Handler()
+
+	msgpackBody, _ := hex.DecodeString("82a57469746c65a74d73675061636ba6737461747573a4646f6e65") // {"title":"MsgPack","status":"done"}
+	cborBody, _ := hex.DecodeString("a1657469746c6563414243")                                    // {"title":"ABC"}
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		accept      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantType    string
+		wantBody    string
+	}{
+		{"json by default", http.MethodGet, "/tasks", "", "", "", http.StatusOK, "application/json", `"title":"Первая"`},
+		{"xml list", http.MethodGet, "/tasks", "application/xml", "", "", http.StatusOK, "application/xml", "<tasks><task><id>"},
+		{"text/xml alias", http.MethodGet, "/tasks", "text/xml", "", "", http.StatusOK, "application/xml", "<title>Первая</title>"},
+		{"csv list", http.MethodGet, "/tasks", "text/csv", "", "", http.StatusOK, "text/csv; charset=utf-8", "id,title,"},
+		{"msgpack list", http.MethodGet, "/webhooks", "application/x-msgpack", "", "", http.StatusOK, "application/msgpack", "\x90"},
+		{"cbor list", http.MethodGet, "/tasks", "application/cbor", "", "", http.StatusOK, "application/cbor", "\x81"},
+		{"q-values", http.MethodGet, "/tasks", "application/json;q=0.5, application/xml", "", "", http.StatusOK, "application/xml", "<tasks>"},
+		{"csv only for lists", http.MethodGet, "/tasks/1", "text/csv", "", "", http.StatusNotAcceptable, "application/problem+json", `"code":"not_acceptable"`},
+		{"not acceptable", http.MethodGet, "/tasks", "text/html", "", "", http.StatusNotAcceptable, "application/problem+json", `"code":"not_acceptable"`},
+		{"xml problem", http.MethodGet, "/tasks/missing", "application/xml", "", "", http.StatusNotFound, "application/problem+xml", "<code>task_not_found</code>"},
+		{"form create", http.MethodPost, "/tasks", "", "application/x-www-form-urlencoded", "title=%D0%A4%D0%BE%D1%80%D0%BC%D0%B0&status=todo", http.StatusCreated, "application/json", `"title":"Форма"`},
+		{"form validation", http.MethodPost, "/tasks", "", "application/x-www-form-urlencoded", "status=todo", http.StatusBadRequest, "application/problem+json", `"field":"body.title"`},
+		{"form webhook", http.MethodPost, "/webhooks", "", "application/x-www-form-urlencoded", "url=http%3A%2F%2Fexample.com&events=task_created&events[]=task_deleted&active=false",
+			http.StatusCreated, "application/json", `"events":["task_created","task_deleted"],"active":false`},
+		{"msgpack create", http.MethodPost, "/tasks", "application/xml", "application/msgpack", string(msgpackBody), http.StatusCreated, "application/xml", "<status>done</status>"},
+		{"cbor create", http.MethodPost, "/tasks", "", "application/cbor", string(cborBody), http.StatusCreated, "application/json", `"title":"ABC"`},
+		{"broken msgpack", http.MethodPost, "/tasks", "", "application/msgpack", "\x82", http.StatusBadRequest, "application/problem+json", `"code":"invalid_body"`},
+		{"unsupported media type", http.MethodPost, "/tasks", "", "text/plain", "title", http.StatusUnsupportedMediaType, "application/problem+json", `"code":"unsupported_media_type"`},
+		{"rpc stays json", http.MethodPost, "/rpc", "application/xml", "application/json", `{"jsonrpc":"2.0","id":1,"method":"tasks.list"}`, http.StatusOK, "application/json", `"jsonrpc":"2.0"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantType, ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %s", tt.wantBody, w.Body)
+			}
+			if tt.target != "/rpc" && !slices.Contains(w.Header().Values("Vary"), "Accept") {
+				t.Errorf("expected Vary: Accept, got %v", w.Header().Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestRouter_Compression(t *testing.T) {
+	svc := usecase.NewService(memory.New(), nopLogger{})
+	for i := 0; i < 50; i++ {
+		if _, err := svc.Create(context.Background(), dto.CreateInput{Title: "Задача для проверки сжатия", Project: "compression"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithEvents(events.NewHub(10), time.Second)).Handler()
+
+	gzipped := func(s string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.String()
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		acceptEncoding  string
+		contentEncoding string
+		body            string
+		wantStatus      int
+		wantEncoding    string
+	}{
+		{"gzip", http.MethodGet, "/tasks", "gzip, deflate", "", "", http.StatusOK, "gzip"},
+		{"deflate by q-value", http.MethodGet, "/tasks", "gzip;q=0.5, deflate", "", "", http.StatusOK, "deflate"},
+		{"wildcard", http.MethodGet, "/tasks", "*", "", "", http.StatusOK, "gzip"},
+		{"gzip refused", http.MethodGet, "/tasks", "gzip;q=0, identity", "", "", http.StatusOK, ""},
+		{"no header", http.MethodGet, "/tasks", "", "", "", http.StatusOK, ""},
+		{"below threshold", http.MethodGet, "/tasks?project=none", "gzip", "", "", http.StatusOK, ""},
+		{"head", http.MethodHead, "/tasks", "gzip", "", "", http.StatusOK, ""},
+		{"gzip request body", http.MethodPost, "/tasks", "", "gzip", gzipped(`{"title":"Сжатая"}`), http.StatusCreated, ""},
+		{"broken gzip body", http.MethodPost, "/tasks", "", "gzip", `{"title":"T"}`, http.StatusBadRequest, ""},
+		{"unknown body encoding", http.MethodPost, "/tasks", "", "br", `{"title":"T"}`, http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			if tt.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", tt.contentEncoding)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if ce := w.Header().Get("Content-Encoding"); ce != tt.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.wantEncoding, ce)
+			}
+			if !slices.Contains(w.Header().Values("Vary"), "Accept-Encoding") {
+				t.Errorf("expected Vary: Accept-Encoding, got %v", w.Header().Values("Vary"))
+			}
+			var body io.Reader = w.Body
+			switch tt.wantEncoding {
+			case "gzip":
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			case "deflate":
+				body = flate.NewReader(w.Body)
+			}
+			raw, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+			if tt.method == http.MethodGet && tt.wantStatus == http.StatusOK {
+				var list []domain.Task
+				if err := json.Unmarshal(raw, &list); err != nil {
+					t.Fatalf("decode list: %v", err)
+				}
+				if tt.target == "/tasks" && len(list) != 50 {
+					t.Errorf("expected 50 tasks, got %d", len(list))
+				}
+			}
+			if tt.wantStatus == http.StatusCreated && !strings.Contains(string(raw), `"title":"Сжатая"`) {
+				t.Errorf("unexpected body %s", raw)
+			}
+		})
+	}
+
+	// Поток событий не буферизуется и не сжимается.
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := http.DefaultTransport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ce := resp.Header.Get("Content-Encoding"); ce != "" || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("event stream must not be compressed: %v", resp.Header)
+	}
+}
+
+func TestRouter_Caching(t *testing.T) {
+	svc := usecase.NewService(memory.New(), nopLogger{})
+	task, err := svc.Create(context.Background(), dto.CreateInput{Title: "Кэш"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := httpHandler.NewRouter(svc, nopLogger{}).Handler()
+
+	do := func(method, target string, header map[string]string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	item := "/tasks/" + task.ID
+	first := map[string]*httptest.ResponseRecorder{"/tasks": do(http.MethodGet, "/tasks", nil, ""), item: do(http.MethodGet, item, nil, "")}
+	for target, w := range first {
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("ETag"), `W/"`) || w.Header().Get("Last-Modified") == "" {
+			t.Fatalf("%s: expected validators, got %v", target, w.Header())
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+			t.Errorf("%s: expected Cache-Control no-cache, got %q", target, cc)
+		}
+	}
+	listTag, itemTag := first["/tasks"].Header().Get("ETag"), first[item].Header().Get("ETag")
+	lastModified := first[item].Header().Get("Last-Modified")
+	earlier := task.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name       string
+		target     string
+		header     map[string]string
+		wantStatus int
+	}{
+		{"list etag", "/tasks", map[string]string{"If-None-Match": listTag}, http.StatusNotModified},
+		{"list strong form", "/tasks", map[string]string{"If-None-Match": strings.TrimPrefix(listTag, "W/")}, http.StatusNotModified},
+		{"list tag among others", "/tasks", map[string]string{"If-None-Match": `"other", ` + listTag}, http.StatusNotModified},
+		{"list filter shares revision", "/tasks?status=todo", map[string]string{"If-None-Match": listTag}, http.StatusNotModified},
+		{"list other format", "/tasks", map[string]string{"If-None-Match": listTag, "Accept": "application/xml"}, http.StatusOK},
+		{"list compressed", "/tasks", map[string]string{"If-None-Match": listTag, "Accept-Encoding": "gzip"}, http.StatusNotModified},
+		{"item etag", item, map[string]string{"If-None-Match": itemTag}, http.StatusNotModified},
+		{"item wildcard", item, map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"item stale etag", item, map[string]string{"If-None-Match": `W/"stale"`}, http.StatusOK},
+		{"item modified since", item, map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"item modified after", item, map[string]string{"If-Modified-Since": earlier}, http.StatusOK},
+		{"etag wins over date", item, map[string]string{"If-None-Match": `W/"stale"`, "If-Modified-Since": lastModified}, http.StatusOK},
+		{"missing item", "/tasks/missing", map[string]string{"If-None-Match": "*"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(http.MethodGet, tt.target, tt.header, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			switch tt.wantStatus {
+			case http.StatusNotModified:
+				if w.Body.Len() != 0 || w.Header().Get("ETag") == "" || w.Header().Get("Content-Encoding") != "" {
+					t.Errorf("unexpected 304 response: %v %q", w.Header(), w.Body)
+				}
+			case http.StatusNotFound:
+				if w.Header().Get("ETag") != "" {
+					t.Errorf("error response must not carry ETag")
+				}
+			}
+		})
+	}
+
+	if tag := do(http.MethodGet, "/tasks", map[string]string{"Accept": "application/xml"}, "").Header().Get("ETag"); tag == listTag {
+		t.Errorf("expected XML representation to have its own ETag, got %s", tag)
+	}
+
+	// После изменения старые теги устаревают.
+	if w := do(http.MethodPatch, item, map[string]string{"Content-Type": "application/json"}, `{"title":"Новый"}`); w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body)
+	}
+	for target, tag := range map[string]string{"/tasks": listTag, item: itemTag} {
+		w := do(http.MethodGet, target, map[string]string{"If-None-Match": tag}, "")
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == tag {
+			t.Errorf("%s: expected fresh 200 after update, got %d with ETag %s", target, w.Code, w.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestRouter_CORS(t *testing.T) {
+	svc := usecase.NewService(memory.New(), nopLogger{})
+	task, err := svc.Create(context.Background(), dto.CreateInput{Title: "CORS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := httpHandler.CORS{
+		Origins: []string{"https://board.example.com", "https://*.apps.example.com"},
+		Methods: []string{http.MethodPatch, http.MethodDelete},
+		Headers: []string{"Content-Type", "X-Request-ID"},
+		MaxAge:  10 * time.Minute,
+	}
+	h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithCORS(policy), httpHandler.WithWebhooks(webhook.NewStore()),
+		httpHandler.WithEvents(events.NewHub(10), time.Hour)).Handler()
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		origin       string
+		reqMethod    string
+		reqHeaders   string
+		wantStatus   int
+		wantOrigin   string
+		wantAllowHdr string
+	}{
+		{"preflight", http.MethodOptions, "/tasks/" + task.ID, "https://board.example.com", http.MethodPatch, "content-type, x-request-id", http.StatusNoContent, "https://board.example.com", "content-type, x-request-id"},
+		{"preflight subdomain", http.MethodOptions, "/tasks", "https://kanban.apps.example.com", http.MethodPost, "Content-Type", http.StatusNoContent, "https://kanban.apps.example.com", "Content-Type"},
+		{"preflight webhooks", http.MethodOptions, "/webhooks/123", "https://board.example.com", http.MethodDelete, "", http.StatusNoContent, "https://board.example.com", ""},
+		{"preflight rpc", http.MethodOptions, "/rpc", "https://board.example.com", http.MethodPost, "Content-Type", http.StatusNoContent, "https://board.example.com", "Content-Type"},
+		{"wildcard excludes parent domain", http.MethodOptions, "/tasks", "https://apps.example.com", http.MethodPost, "", http.StatusForbidden, "", ""},
+		{"wildcard checks port", http.MethodOptions, "/tasks", "https://a.apps.example.com:8443", http.MethodPost, "", http.StatusForbidden, "", ""},
+		{"wildcard checks scheme", http.MethodOptions, "/tasks", "http://a.apps.example.com", http.MethodPost, "", http.StatusForbidden, "", ""},
+		{"unknown origin", http.MethodOptions, "/tasks", "https://evil.example.org", http.MethodPost, "", http.StatusForbidden, "", ""},
+		{"method not allowed for cors", http.MethodOptions, "/webhooks/123", "https://board.example.com", http.MethodPut, "", http.StatusForbidden, "", ""},
+		{"header not allowed", http.MethodOptions, "/tasks", "https://board.example.com", http.MethodPost, "X-Custom", http.StatusForbidden, "", ""},
+		{"method without route", http.MethodOptions, "/tasks", "https://board.example.com", http.MethodDelete, "", http.StatusMethodNotAllowed, "", ""},
+		{"path without route", http.MethodOptions, "/nope", "https://board.example.com", http.MethodGet, "", http.StatusNotFound, "", ""},
+		{"actual request", http.MethodGet, "/tasks", "https://board.example.com", "", "", http.StatusOK, "https://board.example.com", ""},
+		{"actual error", http.MethodGet, "/tasks/missing", "https://kanban.apps.example.com", "", "", http.StatusNotFound, "https://kanban.apps.example.com", ""},
+		{"foreign origin not rejected", http.MethodGet, "/tasks", "https://evil.example.org", "", "", http.StatusOK, "", ""},
+		{"same origin", http.MethodGet, "/tasks", "", "", "", http.StatusOK, "", ""},
+		{"plain options", http.MethodOptions, "/tasks", "https://board.example.com", "", "", http.StatusMethodNotAllowed, "https://board.example.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.reqMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.reqMethod)
+			}
+			if tt.reqHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.reqHeaders)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("expected Access-Control-Allow-Origin %q, got %q", tt.wantOrigin, got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != tt.wantAllowHdr {
+				t.Errorf("expected Access-Control-Allow-Headers %q, got %q", tt.wantAllowHdr, got)
+			}
+			if !slices.Contains(w.Header().Values("Vary"), "Origin") {
+				t.Errorf("expected Vary: Origin, got %v", w.Header().Values("Vary"))
+			}
+			if w.Code == http.StatusForbidden && !strings.Contains(w.Body.String(), `"code":"cors_forbidden"`) {
+				t.Errorf("unexpected body %s", w.Body)
+			}
+			if w.Code == http.StatusNoContent {
+				if got := w.Header().Get("Access-Control-Allow-Methods"); got != tt.reqMethod {
+					t.Errorf("expected Access-Control-Allow-Methods %q, got %q", tt.reqMethod, got)
+				}
+				if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+					t.Errorf("expected Access-Control-Max-Age 600, got %q", got)
+				}
+			}
+			if tt.method != http.MethodOptions && tt.wantOrigin != "" && w.Header().Get("Access-Control-Expose-Headers") == "" {
+				t.Errorf("expected Access-Control-Expose-Headers")
+			}
+		})
+	}
+
+	// Рукопожатие WebSocket проверяет тот же список источников.
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	for origin, allowed := range map[string]bool{
+		"https://board.example.com":       true,
+		"https://kanban.apps.example.com": true,
+		srv.URL:                           true,
+		"https://evil.example.org":        false,
+	} {
+		conn, err := websocket.Dial(context.Background(), wsURL, http.Header{"Origin": {origin}})
+		if err == nil {
+			conn.Close()
+		}
+		if (err == nil) != allowed {
+			t.Errorf("origin %s: expected allowed=%v, got err=%v", origin, allowed, err)
+		}
+	}
+
+	// Любой источник: "*" без cookie, точный источник — с ними.
+	for _, credentials := range []bool{false, true} {
+		h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithCORS(httpHandler.CORS{Origins: []string{"*"}, Credentials: credentials})).Handler()
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Origin", "https://any.example.net")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		want, wantCred := "*", ""
+		if credentials {
+			want, wantCred = "https://any.example.net", "true"
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("credentials=%v: expected origin %q, got %q", credentials, want, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != wantCred {
+			t.Errorf("credentials=%v: expected Access-Control-Allow-Credentials %q, got %q", credentials, wantCred, got)
+		}
+	}
+
+	// Без WithCORS заголовков нет.
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("Origin", "https://board.example.com")
+	w := httptest.NewRecorder()
+	httpHandler.NewRouter(svc, nopLogger{}).Handler().ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" || slices.Contains(w.Header().Values("Vary"), "Origin") {
+		t.Errorf("CORS must be disabled by default: %v", w.Header())
+	}
+}
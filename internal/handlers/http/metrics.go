@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"taskapi/internal/metrics"
+	"time"
+)
+
+type httpMetrics struct {
+	reg      *metrics.Registry
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	panics   *metrics.CounterVec
+}
+
+func newHTTPMetrics(reg *metrics.Registry) *httpMetrics {
+	return &httpMetrics{
+		reg: reg,
+		requests: reg.NewCounterVec("taskapi_http_requests_total",
+			"HTTP-запросы по шаблону маршрута, методу и коду ответа.", "route", "method", "status"),
+		duration: reg.NewHistogramVec("taskapi_http_request_duration_seconds",
+			"Длительность обработки HTTP-запросов.", nil, "route", "method"),
+		panics: reg.NewCounterVec("taskapi_http_panics_total",
+			"Паники обработчиков HTTP по шаблону маршрута.", "route"),
+	}
+}
+
+// observe записывает запрос под шаблоном маршрута (/tasks/{id}), а не
+// фактическим путем, чтобы число рядов не росло с числом задач.
+func (m *httpMetrics) observe(r *http.Request, status int, took time.Duration) {
+	route := routeOf(r)
+	if route == "" {
+		route = "unmatched"
+	}
+	method := r.Method
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		method = "OTHER"
+	}
+	m.requests.With(route, method, strconv.Itoa(status)).Inc()
+	m.duration.With(route, method).Observe(took.Seconds())
+}
+
+func (m *httpMetrics) panic(route string) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.panics.With(route).Inc()
+}
@@ -1,36 +1,27 @@
 package http
 
 import (
-	"context"
 	"net/http"
+	"strings"
 	"taskapi/internal/logger"
+	"taskapi/internal/requestid"
+	"taskapi/internal/tracing"
 	"time"
 )
 
-type ctxKey string
-
-const requestIDKey ctxKey = "req_id"
-
+// requestIDMiddleware принимает X-Request-ID от шлюза, если он проходит
+// requestid.Valid, иначе выдает новый.
 func requestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		reqID := newReqID()
-		ctx := context.WithValue(r.Context(), requestIDKey, reqID)
-		w.Header().Set("X-Request-ID", reqID)
-		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		defer cancel()
-		next.ServeHTTP(w, r.WithContext(ctx))
+		reqID := r.Header.Get(requestid.Header)
+		if !requestid.Valid(reqID) {
+			reqID = requestid.New()
+		}
+		w.Header().Set(requestid.Header, reqID)
+		next.ServeHTTP(w, r.WithContext(requestid.WithContext(r.Context(), reqID)))
 	})
 }
 
-func newReqID() string {
-	return time.Now().UTC().Format("20060102T150405.000000000")
-}
-
-func requestIDFromCtx(ctx context.Context) string {
-	v, _ := ctx.Value(requestIDKey).(string)
-	return v
-}
-
 func (rt *Router) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -39,21 +30,72 @@ func (rt *Router) loggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(ww, r)
 
-		reqID := requestIDFromCtx(r.Context())
-		rt.log.Log(logger.Entry{
+		if rt.metrics != nil {
+			rt.metrics.observe(r, ww.statusCode, time.Since(start))
+		}
+		level := logger.LevelInfo
+		switch {
+		case ww.statusCode >= 500:
+			level = logger.LevelError
+		case ww.statusCode >= 400:
+			level = logger.LevelWarn
+		}
+		if !rt.log.Enabled(level) {
+			return
+		}
+		data := map[string]any{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"status": ww.statusCode,
+			"took":   time.Since(start).String(),
+		}
+		// Строка запроса пишется как есть: чувствительные параметры скрывает
+		// логгер.
+		if r.URL.RawQuery != "" {
+			data["query"] = r.URL.RawQuery
+		}
+		rt.log.Log(tracing.Annotate(r.Context(), logger.Entry{
 			Time:      start.UTC(),
+			Level:     level,
 			Event:     "http_request",
-			RequestID: reqID,
-			Data: map[string]any{
-				"method": r.Method,
-				"path":   r.URL.Path,
-				"status": ww.statusCode,
-				"took":   time.Since(start).String(),
-			},
-		})
+			RequestID: requestid.FromContext(r.Context()),
+			Data:      data,
+		}))
+	})
+}
+
+// tracingMiddleware продолжает трассу вызывающего сервиса из traceparent или
+// начинает новую. Спан называется по шаблону маршрута, который становится
+// известен только после выбора обработчика.
+func (rt *Router) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := rt.tracer.Start(tracing.Extract(r.Context(), r.Header), r.Method, tracing.KindServer)
+		defer span.End()
+		span.SetAttr("http.request.method", r.Method)
+		span.SetAttr("url.path", r.URL.Path)
+
+		ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(ww, r)
+
+		if route := routeOf(r); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttr("http.route", route)
+		}
+		span.SetAttr("http.response.status_code", ww.statusCode)
+		if ww.statusCode >= 500 {
+			span.SetError(http.StatusText(ww.statusCode))
+		}
 	})
 }
 
+// routeOf — шаблон пути выбранного маршрута без метода; пусто, если
+// маршрут не найден.
+func routeOf(r *http.Request) string {
+	_, path, _ := strings.Cut(r.Pattern, " ")
+	return path
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -63,3 +105,7 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
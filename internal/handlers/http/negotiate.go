@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"taskapi/internal/codec"
+	"taskapi/internal/openapi"
+)
+
+// Типы содержимого, которые понимают маршруты с Produces и Consumes.
+const (
+	mediaJSON    = "application/json"
+	mediaXML     = "application/xml"
+	mediaCSV     = "text/csv"
+	mediaMsgpack = "application/msgpack"
+	mediaCBOR    = "application/cbor"
+	mediaForm    = "application/x-www-form-urlencoded"
+)
+
+// Представления ответов. Для списков добавляется CSV.
+var (
+	itemMedia = []string{mediaXML, mediaMsgpack, mediaCBOR}
+	listMedia = []string{mediaXML, mediaCSV, mediaMsgpack, mediaCBOR}
+	bodyMedia = []string{mediaForm, mediaMsgpack, mediaCBOR}
+)
+
+var encoders = map[string]codec.Encoder{
+	mediaJSON:    codec.JSON,
+	mediaXML:     codec.XML,
+	mediaCSV:     codec.CSV,
+	mediaMsgpack: codec.MessagePack,
+	mediaCBOR:    codec.CBOR,
+}
+
+// mediaAliases — другие распространенные имена тех же форматов.
+var mediaAliases = map[string][]string{
+	mediaXML:     {"text/xml"},
+	mediaMsgpack: {"application/x-msgpack", "application/vnd.msgpack"},
+}
+
+// bodyDecoders разбирают двоичные тела запросов.
+var bodyDecoders = map[string]func([]byte) (any, error){
+	mediaMsgpack: codec.DecodeMessagePack,
+	mediaCBOR:    codec.DecodeCBOR,
+}
+
+// canonical заменяет синоним типа основным именем.
+func canonical(mt string) string {
+	for name, aliases := range mediaAliases {
+		if slices.Contains(aliases, mt) {
+			return name
+		}
+	}
+	return mt
+}
+
+type encoderKey struct{}
+
+// encoderFrom — представление, выбранное negotiate; nil — JSON.
+func encoderFrom(r *http.Request) codec.Encoder {
+	enc, _ := r.Context().Value(encoderKey{}).(codec.Encoder)
+	return enc
+}
+
+// negotiate выбирает представление ответа по Accept (406, если ни одно не
+// подходит) и переводит тело запроса из формы, MessagePack или CBOR в JSON
+// (415 для прочих типов), так что валидатор и обработчики видят только JSON.
+// Маршруты без Produces и Consumes проходят как есть.
+func (rt *Router) negotiate(rd route, next http.Handler) http.Handler {
+	if len(rd.Produces) == 0 && len(rd.Consumes) == 0 {
+		return next
+	}
+	offers := []string{mediaJSON}
+	for _, mt := range rd.Produces {
+		offers = append(offers, mt)
+		offers = append(offers, mediaAliases[mt]...)
+	}
+	consumes := append([]string{mediaJSON}, rd.Consumes...)
+	op := (*rt.spec.Paths[rd.Path])[strings.ToLower(rd.Method)]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		mt, ok := codec.Negotiate(r.Header.Get("Accept"), offers)
+		if !ok {
+			writeError(w, r, problemf(codeNotAcceptable, "none of the acceptable media types is available, supported: %s", strings.Join(append([]string{mediaJSON}, rd.Produces...), ", ")))
+			return
+		}
+		if enc := encoders[canonical(mt)]; enc != codec.JSON {
+			r = r.WithContext(context.WithValue(r.Context(), encoderKey{}, enc))
+		}
+		if op.RequestBody != nil && r.Header.Get("Content-Type") != "" {
+			mt := canonical(codec.MediaType(r.Header.Get("Content-Type")))
+			if !slices.Contains(consumes, mt) {
+				writeError(w, r, problemf(codeUnsupportedMedia, "content type %q is not supported, use one of: %s", mt, strings.Join(consumes, ", ")))
+				return
+			}
+			if mt != mediaJSON {
+				if err := rt.decodeBody(r, op, mt); err != nil {
+					writeError(w, r, err)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeBody подменяет тело запроса типа mt его JSON-эквивалентом.
+func (rt *Router) decodeBody(r *http.Request, op *openapi.Operation, mt string) error {
+	raw, err := io.ReadAll(io.LimitReader(r.Body, openapi.MaxBodySize+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return problemf(codeInvalidBody, "request body cannot be read")
+	}
+	if len(raw) > openapi.MaxBodySize {
+		return problemf(codeInvalidBody, "request body exceeds %d bytes", openapi.MaxBodySize)
+	}
+	var val any
+	if mt == mediaForm {
+		form, err := url.ParseQuery(string(raw))
+		if err != nil {
+			return problemf(codeInvalidBody, "request body is not a valid form: %v", err)
+		}
+		val = rt.spec.FormValue(op, form)
+	} else if val, err = bodyDecoders[mt](raw); err != nil {
+		return problemf(codeInvalidBody, "request body is not valid %s: %v", mt, err)
+	}
+	body, err := json.Marshal(val)
+	if err != nil {
+		return problemf(codeInvalidBody, "request body cannot be represented as JSON: %v", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Type", mediaJSON)
+	return nil
+}
+
+// respond пишет ответ в представлении, выбранном negotiate.
+func respond(w http.ResponseWriter, r *http.Request, status int, v any) {
+	enc := encoderFrom(r)
+	if enc == nil {
+		writeJSON(w, status, v)
+		return
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", enc.MediaType())
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/graphql"
+	"taskapi/internal/health"
+	"taskapi/internal/openapi"
+	"taskapi/internal/webhook"
+)
+
+// APIVersion — версия API в спецификации.
+const APIVersion = "1.0.0"
+
+// route связывает обработчик с его описанием: по одной таблице
+// регистрируются маршруты в ServeMux и строится OpenAPI-документ.
+type route struct {
+	openapi.Route
+	handler http.HandlerFunc
+}
+
+var listQuery = []*openapi.Parameter{
+	{Name: "status", Description: "Статус задачи", Schema: &openapi.Schema{Ref: "#/components/schemas/Status"}},
+	{Name: "project", Description: "Проект", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "assignee", Description: "Исполнитель", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "parent_id", Description: "ID родительской задачи", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var healthQuery = []*openapi.Parameter{
+	{Name: "verbose", Description: "Вернуть JSON со статусом и длительностью каждой проверки", Schema: &openapi.Schema{Type: "string"}},
+}
+
+func (rt *Router) routes() []route {
+	rs := []route{
+		{openapi.Route{Method: http.MethodGet, Path: "/tasks", ID: "listTasks", Summary: "Список задач", Tag: "tasks",
+			Query:     listQuery,
+			Produces:  listMedia,
+			Responses: map[int]any{200: []domain.Task{}, 304: nil, 400: problem{}}}, rt.GetList},
+		{openapi.Route{Method: http.MethodPost, Path: "/tasks", ID: "createTask", Summary: "Создание задачи", Tag: "tasks",
+			Body:      dto.CreateInput{},
+			Consumes:  bodyMedia,
+			Produces:  itemMedia,
+			Responses: map[int]any{201: domain.Task{}, 400: problem{}}}, rt.Create},
+		{openapi.Route{Method: http.MethodGet, Path: "/tasks/{id}", ID: "getTask", Summary: "Задача по ID", Tag: "tasks",
+			Produces:  itemMedia,
+			Responses: map[int]any{200: domain.Task{}, 304: nil, 404: problem{}}}, rt.Get},
+		{openapi.Route{Method: http.MethodPatch, Path: "/tasks/{id}", ID: "updateTask", Summary: "Частичное обновление задачи", Tag: "tasks",
+			Body:      dto.UpdateInput{},
+			Consumes:  bodyMedia,
+			Produces:  itemMedia,
+			Responses: map[int]any{200: domain.Task{}, 400: problem{}, 404: problem{}}}, rt.Update},
+		{openapi.Route{Method: http.MethodDelete, Path: "/tasks/{id}", ID: "deleteTask", Summary: "Удаление задачи", Tag: "tasks",
+			Produces:  itemMedia,
+			Responses: map[int]any{204: nil, 404: problem{}, 409: problem{}}}, rt.Delete},
+		{openapi.Route{Method: http.MethodPost, Path: "/rpc", ID: "rpc", Summary: "JSON-RPC 2.0: вызов или пакет", Tag: "rpc",
+			Body:      json.RawMessage{},
+			Responses: map[int]any{200: json.RawMessage{}, 204: nil}}, rt.RPC},
+	}
+	if rt.gql != nil {
+		rs = append(rs, route{openapi.Route{Method: http.MethodPost, Path: "/graphql", ID: "graphql", Summary: "GraphQL-запрос", Tag: "graphql",
+			Body:      graphql.Request{},
+			Responses: map[int]any{200: graphql.Response{}, 400: graphql.Response{}}}, rt.GraphQL})
+	}
+	if rt.hooks != nil {
+		rs = append(rs,
+			route{openapi.Route{Method: http.MethodGet, Path: "/webhooks", ID: "listWebhooks", Summary: "Список подписок", Tag: "webhooks",
+				Produces:  listMedia,
+				Responses: map[int]any{200: []webhook.Subscription{}}}, rt.webhooksCollection},
+			route{openapi.Route{Method: http.MethodPost, Path: "/webhooks", ID: "createWebhook", Summary: "Создание подписки", Tag: "webhooks",
+				Body:      dto.WebhookInput{},
+				Consumes:  bodyMedia,
+				Produces:  itemMedia,
+				Responses: map[int]any{201: webhook.Subscription{}, 400: problem{}}}, rt.webhooksCollection},
+			route{openapi.Route{Method: http.MethodGet, Path: "/webhooks/{id}", ID: "getWebhook", Summary: "Подписка по ID", Tag: "webhooks",
+				Produces:  itemMedia,
+				Responses: map[int]any{200: webhook.Subscription{}, 404: problem{}}}, rt.webhookItem},
+			route{openapi.Route{Method: http.MethodPut, Path: "/webhooks/{id}", ID: "updateWebhook", Summary: "Изменение подписки", Tag: "webhooks",
+				Body:      dto.WebhookInput{},
+				Consumes:  bodyMedia,
+				Produces:  itemMedia,
+				Responses: map[int]any{200: webhook.Subscription{}, 400: problem{}, 404: problem{}}}, rt.webhookItem},
+			route{openapi.Route{Method: http.MethodDelete, Path: "/webhooks/{id}", ID: "deleteWebhook", Summary: "Удаление подписки", Tag: "webhooks",
+				Produces:  itemMedia,
+				Responses: map[int]any{204: nil, 404: problem{}}}, rt.webhookItem},
+			route{openapi.Route{Method: http.MethodGet, Path: "/webhooks/{id}/deliveries", ID: "listDeliveries", Summary: "История доставок", Tag: "webhooks",
+				Produces:  listMedia,
+				Responses: map[int]any{200: []webhook.Delivery{}, 404: problem{}}}, rt.webhookItem},
+		)
+	}
+	if rt.events != nil {
+		rs = append(rs,
+			route{openapi.Route{Method: http.MethodGet, Path: "/events", ID: "streamEvents", Summary: "Поток изменений задач (SSE)", Tag: "events",
+				Query:       append(listQuery[:len(listQuery):len(listQuery)], &openapi.Parameter{Name: "last_event_id", Schema: &openapi.Schema{Type: "integer"}}),
+				ContentType: "text/event-stream",
+				Responses:   map[int]any{200: "", 400: problem{}}}, rt.Events},
+			route{openapi.Route{Method: http.MethodGet, Path: "/ws", ID: "websocket", Summary: "WebSocket: события и команды", Tag: "events",
+				Responses: map[int]any{101: nil, 400: nil}}, rt.WebSocket},
+		)
+	}
+	if rt.metrics != nil {
+		rs = append(rs, route{openapi.Route{Method: http.MethodGet, Path: "/metrics", ID: "metrics", Summary: "Метрики в формате Prometheus", Tag: "meta",
+			ContentType: "text/plain",
+			Responses:   map[int]any{200: ""}}, rt.metrics.reg.Handler().ServeHTTP})
+	}
+	rs = append(rs,
+		route{openapi.Route{Method: http.MethodGet, Path: "/livez", ID: "livez", Summary: "Liveness: процесс не завис", Tag: "meta",
+			Query:       healthQuery,
+			ContentType: "text/plain",
+			Responses:   map[int]any{200: "", 503: ""}}, health.Handler(rt.health.Live).ServeHTTP},
+		route{openapi.Route{Method: http.MethodGet, Path: "/readyz", ID: "readyz", Summary: "Readiness: сервис готов принимать запросы", Tag: "meta",
+			Query:       healthQuery,
+			ContentType: "text/plain",
+			Responses:   map[int]any{200: "", 503: ""}}, health.Handler(rt.health.Ready).ServeHTTP},
+		route{openapi.Route{Method: http.MethodGet, Path: "/health", ID: "health", Summary: "Проверка работоспособности, то же что /readyz", Tag: "meta",
+			Query:       healthQuery,
+			ContentType: "text/plain",
+			Responses:   map[int]any{200: "", 503: ""}}, health.Handler(rt.health.Ready).ServeHTTP},
+		route{openapi.Route{Method: http.MethodGet, Path: "/openapi.json", ID: "openapi", Summary: "Эта спецификация", Tag: "meta",
+			Responses: map[int]any{200: map[string]any{}}}, rt.OpenAPI},
+		route{openapi.Route{Method: http.MethodGet, Path: "/docs", ID: "docs", Summary: "Интерактивная документация", Tag: "meta",
+			ContentType: "text/html",
+			Responses:   map[int]any{200: ""}}, openapi.DocsHandler("TaskAPI", "/openapi.json").ServeHTTP},
+	)
+	return rs
+}
+
+// buildSpec строит документ по тем же маршрутам, что регистрирует Handler.
+func (rt *Router) buildSpec() *openapi.Document {
+	g := openapi.NewGenerator()
+	g.Enum(reflect.TypeFor[domain.Status](), domain.StatusTodo, domain.StatusInProgress, domain.StatusDone)
+
+	routes := rt.routes()
+	specs := make([]openapi.Route, len(routes))
+	for i, r := range routes {
+		specs[i] = r.Route
+	}
+	return openapi.Build(openapi.Info{
+		Title:       "TaskAPI",
+		Version:     APIVersion,
+		Description: "REST API для управления задачами.",
+	}, specs, g)
+}
+
+// Spec возвращает OpenAPI-документ роутера.
+func (rt *Router) Spec() *openapi.Document {
+	return rt.spec
+}
+
+func (rt *Router) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, rt.spec)
+}
+
+// validate проверяет запрос по операции маршрута до вызова обработчика.
+func (rt *Router) validate(rd route, next http.Handler) http.Handler {
+	op := (*rt.spec.Paths[rd.Path])[strings.ToLower(rd.Method)]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := rt.spec.ValidateRequest(op, r); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
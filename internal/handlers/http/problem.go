@@ -0,0 +1,195 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"taskapi/internal/codec"
+	"taskapi/internal/openapi"
+	"taskapi/internal/requestid"
+	"taskapi/internal/usecase"
+	"taskapi/internal/webhook"
+)
+
+// problemContentType — тип ответов с ошибкой (RFC 7807). Клиент, выбравший
+// XML, получает application/problem+xml.
+const problemContentType = "application/problem+json"
+
+var problemXML = codec.WithMediaType(codec.XML, "application/problem+xml")
+
+// problemTypePrefix + код — URI типа проблемы.
+const problemTypePrefix = "urn:taskapi:problem:"
+
+// Коды ошибок HTTP-слоя; доменные коды — в usecase.
+const (
+	codeInvalidBody      usecase.Code = "invalid_body"
+	codeInvalidHeader    usecase.Code = "invalid_header"
+	codeRouteNotFound    usecase.Code = "route_not_found"
+	codeMethodNotAllowed usecase.Code = "method_not_allowed"
+	codeWebhookNotFound  usecase.Code = "webhook_not_found"
+	codeWebhookInvalid   usecase.Code = "webhook_invalid"
+	codeHandshakeFailed  usecase.Code = "websocket_handshake_failed"
+	codeRequestTimeout   usecase.Code = "request_timeout"
+	codeDeadlineExceeded usecase.Code = "deadline_exceeded"
+	codeNotAcceptable    usecase.Code = "not_acceptable"
+	codeUnsupportedMedia usecase.Code = "unsupported_media_type"
+	codeCORSForbidden    usecase.Code = "cors_forbidden"
+	codeUnknownCommand   usecase.Code = "unknown_command"
+)
+
+type problemInfo struct {
+	status int
+	title  string
+}
+
+// problems — каталог всех кодов ответа: статус и заголовок. Заголовок
+// доменного кода берется из usecase.
+var problems = map[usecase.Code]problemInfo{
+	usecase.CodeTaskNotFound:     {status: http.StatusNotFound},
+	usecase.CodeValidationFailed: {status: http.StatusBadRequest},
+	usecase.CodeTaskHasSubtasks:  {status: http.StatusConflict},
+	usecase.CodeInternal:         {status: http.StatusInternalServerError},
+
+	codeInvalidBody:      {http.StatusBadRequest, "Invalid request body"},
+	codeInvalidHeader:    {http.StatusBadRequest, "Invalid request header"},
+	codeRouteNotFound:    {http.StatusNotFound, "Route not found"},
+	codeMethodNotAllowed: {http.StatusMethodNotAllowed, "Method not allowed"},
+	codeWebhookNotFound:  {http.StatusNotFound, "Webhook not found"},
+	codeWebhookInvalid:   {http.StatusBadRequest, "Invalid webhook"},
+	codeHandshakeFailed:  {http.StatusBadRequest, "WebSocket handshake failed"},
+	codeRequestTimeout:   {http.StatusGatewayTimeout, "Request timed out"},
+	codeDeadlineExceeded: {http.StatusServiceUnavailable, "Deadline already exceeded"},
+	codeNotAcceptable:    {http.StatusNotAcceptable, "Not acceptable"},
+	codeUnsupportedMedia: {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	codeCORSForbidden:    {http.StatusForbidden, "Cross-origin request forbidden"},
+	codeUnknownCommand:   {http.StatusBadRequest, "Unknown command"},
+}
+
+// problem — тело ответа с ошибкой, application/problem+json.
+type problem struct {
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Status    int                  `json:"status"`
+	Detail    string               `json:"detail,omitempty"`
+	Instance  string               `json:"instance,omitempty"`
+	Code      usecase.Code         `json:"code"`
+	RequestID string               `json:"request_id,omitempty"`
+	Errors    []usecase.FieldError `json:"errors,omitempty"`
+	// Timeout — срок, который истек, для request_timeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// MediaType — для OpenAPI-документа.
+func (problem) MediaType() string { return problemContentType }
+
+var _ openapi.MediaTyper = problem{}
+
+func problemf(code usecase.Code, format string, args ...any) *usecase.Error {
+	return &usecase.Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// writeError — единственное место, где ошибка превращается в ответ.
+// Текст ошибок без кода клиенту не показывается: он есть в журнале.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, problemOf(err))
+}
+
+func problemOf(err error) *usecase.Error {
+	var e *usecase.Error
+	var verr *openapi.ValidationError
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.As(err, &verr):
+		e = &usecase.Error{Code: usecase.CodeValidationFailed, Message: "request does not match the API schema"}
+		for _, msg := range verr.Errors {
+			field, text, _ := strings.Cut(msg, ": ")
+			code := usecase.FieldInvalid
+			if text == "is required" {
+				code = usecase.FieldRequired
+			}
+			e.Fields = append(e.Fields, usecase.FieldError{Field: field, Code: code, Message: text})
+		}
+		return e
+	case errors.Is(err, webhook.ErrInvalid):
+		return &usecase.Error{Code: codeWebhookInvalid, Message: strings.TrimPrefix(err.Error(), webhook.ErrInvalid.Error()+": ")}
+	case errors.Is(err, usecase.ErrNotFound), errors.Is(err, usecase.ErrBadRequest):
+		return &usecase.Error{Code: usecase.CodeOf(err), Message: err.Error()}
+	default:
+		return &usecase.Error{Code: usecase.CodeInternal, Message: "internal server error"}
+	}
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, e *usecase.Error, ext ...func(*problem)) {
+	info, ok := problems[e.Code]
+	if !ok {
+		info = problems[usecase.CodeInternal]
+	}
+	if info.title == "" {
+		info.title = e.Code.Title()
+	}
+	p := problem{
+		Type:      problemTypePrefix + string(e.Code),
+		Title:     info.title,
+		Status:    info.status,
+		Detail:    e.Message,
+		Instance:  r.URL.Path,
+		Code:      e.Code,
+		RequestID: requestid.FromContext(r.Context()),
+		Errors:    e.Fields,
+	}
+	for _, f := range ext {
+		f(&p)
+	}
+	w.Header().Del("Content-Length")
+	if encoderFrom(r) == codec.XML {
+		w.Header().Set("Content-Type", problemXML.MediaType())
+		w.WriteHeader(p.Status)
+		_ = problemXML.Encode(w, p)
+		return
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// routeErrors отвечает problem+json вместо текстовых 404 и 405 ServeMux.
+// Перенаправления на канонический путь проходят как есть.
+func routeErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{header: make(http.Header)}
+		h.ServeHTTP(rec, r)
+		if rec.status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", rec.header.Get("Allow"))
+			writeError(w, r, errMethodNotAllowed(r))
+			return
+		}
+		writeError(w, r, problemf(codeRouteNotFound, "no route for %s %s", r.Method, r.URL.Path))
+	})
+}
+
+// statusRecorder запоминает статус и заголовки, отбрасывая тело.
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (s *statusRecorder) Header() http.Header { return s.header }
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.WriteHeader(http.StatusOK)
+	return len(b), nil
+}
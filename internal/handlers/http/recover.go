@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"taskapi/internal/logger"
+	"taskapi/internal/requestid"
+	"taskapi/internal/tracing"
+	"taskapi/internal/usecase"
+	"time"
+)
+
+// dumpBodyLimit — сколько байт тела запроса сохраняется для дампа.
+const dumpBodyLimit = 64 << 10
+
+// WithRequestDump добавляет в запись panic_recovered заголовки и тело
+// запроса. Для разработки: тело держится в памяти до конца обработки.
+// Чувствительные поля скрывает логгер.
+func WithRequestDump(on bool) Option {
+	return func(rt *Router) { rt.dumpRequests = on }
+}
+
+// recoverMiddleware превращает панику обработчика в ответ 500 с ID запроса и
+// запись panic_recovered со стеком. Если ответ уже начат, исправить его
+// нельзя: соединение обрывается через http.ErrAbortHandler.
+func (rt *Router) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body *bytes.Buffer
+		if rt.dumpRequests && r.Body != nil && r.Body != http.NoBody {
+			body = &bytes.Buffer{}
+			r.Body = teeBody{Reader: io.TeeReader(r.Body, &limitedWriter{buf: body, n: dumpBodyLimit}), Closer: r.Body}
+		}
+		ww := &recoverWriter{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// Обработчик сам прервал ответ — это не ошибка сервиса.
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p)
+			}
+			rt.logPanic(r, p, debug.Stack(), body)
+			if ww.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			writeError(ww, r, problemf(usecase.CodeInternal, "internal server error"))
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+func (rt *Router) logPanic(r *http.Request, p any, stack []byte, body *bytes.Buffer) {
+	route := routeOf(r)
+	if rt.metrics != nil {
+		rt.metrics.panic(route)
+	}
+	data := map[string]any{
+		"panic":  fmt.Sprint(p),
+		"stack":  string(stack),
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}
+	if route != "" {
+		data["route"] = route
+	}
+	if rt.dumpRequests {
+		data["request"] = dumpRequest(r, body)
+	}
+	rt.log.Log(tracing.Annotate(r.Context(), logger.Entry{
+		Time:      time.Now().UTC(),
+		Level:     logger.LevelError,
+		Event:     "panic_recovered",
+		RequestID: requestid.FromContext(r.Context()),
+		Error:     fmt.Sprint(p),
+		Data:      data,
+	}))
+}
+
+// dumpRequest собирает запрос в виде полей записи, а не текста, чтобы
+// редактор логгера нашел заголовки Authorization, Cookie и поля JSON-тела.
+func dumpRequest(r *http.Request, body *bytes.Buffer) map[string]any {
+	headers := make(map[string]any, len(r.Header))
+	for k, v := range r.Header {
+		if len(v) == 1 {
+			headers[k] = v[0]
+		} else {
+			headers[k] = append([]string(nil), v...)
+		}
+	}
+	out := map[string]any{
+		"url":     r.URL.String(),
+		"proto":   r.Proto,
+		"host":    r.Host,
+		"headers": headers,
+	}
+	if body != nil && body.Len() > 0 {
+		var obj map[string]any
+		if json.Unmarshal(body.Bytes(), &obj) == nil {
+			out["body"] = obj
+		} else {
+			out["body"] = body.String()
+		}
+	}
+	return out
+}
+
+// recoverWriter запоминает, начат ли ответ.
+type recoverWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoverWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoverWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recoverWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedWriter пишет в buf не больше n байт и молча отбрасывает остальное.
+type limitedWriter struct {
+	buf *bytes.Buffer
+	n   int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if rest := w.n - w.buf.Len(); rest > 0 {
+		w.buf.Write(p[:min(len(p), rest)])
+	}
+	return len(p), nil
+}
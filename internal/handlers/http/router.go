@@ -2,27 +2,103 @@ package http
 
 import (
 	"net/http"
+	"taskapi/internal/dto"
+	"taskapi/internal/graphql"
+	"taskapi/internal/health"
 	"taskapi/internal/logger"
+	"taskapi/internal/metrics"
+	"taskapi/internal/openapi"
+	"taskapi/internal/tracing"
 	"taskapi/internal/usecase"
+	"taskapi/internal/webhook"
+	"time"
 )
 
+type WebhookStore interface {
+	Create(in dto.WebhookInput) (webhook.Subscription, error)
+	Get(id string) (webhook.Subscription, bool)
+	List() []webhook.Subscription
+	Update(id string, in dto.WebhookInput) (webhook.Subscription, bool, error)
+	Delete(id string) bool
+	Deliveries(id string) ([]webhook.Delivery, bool)
+}
+
 type Router struct {
-	svc usecase.TaskService
-	log logger.Logger
+	svc       usecase.TaskService
+	log       logger.Logger
+	hooks     WebhookStore
+	events    EventStream
+	heartbeat time.Duration
+	gql       *graphql.Schema
+	gqlLimits *graphql.Limits
+	spec      *openapi.Document
+	metrics   *httpMetrics
+	tracer    *tracing.Tracer
+	health    *health.Registry
+	timeouts  Timeouts
+	// compressMinSize — см. WithCompression.
+	compressMinSize int
+	// dumpRequests — см. WithRequestDump.
+	dumpRequests bool
+	// cors — см. WithCORS; nil — CORS выключен.
+	cors *corsPolicy
+}
+
+type Option func(*Router)
+
+func WithWebhooks(hooks WebhookStore) Option {
+	return func(rt *Router) { rt.hooks = hooks }
+}
+
+func WithEvents(stream EventStream, heartbeat time.Duration) Option {
+	return func(rt *Router) {
+		rt.events = stream
+		rt.heartbeat = heartbeat
+	}
+}
+
+func WithGraphQL(limits graphql.Limits) Option {
+	return func(rt *Router) { rt.gqlLimits = &limits }
+}
+
+// WithMetrics включает GET /metrics и сбор метрик HTTP-запросов.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(rt *Router) { rt.metrics = newHTTPMetrics(reg) }
+}
+
+// WithTracing включает спаны HTTP-запросов. Без него traceparent вызывающего
+// сервиса все равно попадает в логи.
+func WithTracing(t *tracing.Tracer) Option {
+	return func(rt *Router) { rt.tracer = t }
+}
+
+// WithHealth задает реестр проверок для /livez, /readyz и /health. Без него
+// проверок нет и сервис всегда здоров.
+func WithHealth(reg *health.Registry) Option {
+	return func(rt *Router) { rt.health = reg }
 }
 
-func NewRouter(svc usecase.TaskService, log logger.Logger) *Router {
-	return &Router{svc: svc, log: log}
+func NewRouter(svc usecase.TaskService, log logger.Logger, opts ...Option) *Router {
+	rt := &Router{svc: svc, log: log, heartbeat: 15 * time.Second, health: health.NewRegistry(), timeouts: DefaultTimeouts(), compressMinSize: DefaultCompressMinSize}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	if rt.gqlLimits != nil {
+		// Схема собирается из статических описаний и не может не собраться.
+		schema, err := rt.NewTaskSchema(*rt.gqlLimits)
+		if err != nil {
+			panic(err)
+		}
+		rt.gql = schema
+	}
+	rt.spec = rt.buildSpec()
+	return rt
 }
 
 func (rt *Router) Handler() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/tasks", rt.tasksCollection)
-	mux.HandleFunc("/tasks/", rt.Get)
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-	//return requestIDMiddleware(mux)
-	return requestIDMiddleware(rt.loggingMiddleware(mux))
+	for _, r := range rt.routes() {
+		mux.Handle(r.Method+" "+r.Path, rt.compress(r, rt.timeout(r, rt.negotiate(r, rt.validate(r, r.handler)))))
+	}
+	return requestIDMiddleware(rt.tracingMiddleware(rt.loggingMiddleware(rt.recoverMiddleware(rt.corsMiddleware(mux, routeErrors(mux))))))
 }
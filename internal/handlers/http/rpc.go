@@ -0,0 +1,276 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/usecase"
+	"taskapi/internal/usecase/validation"
+)
+
+// Коды ошибок JSON-RPC 2.0; -32001 и -32002 — прикладные, из диапазона
+// server error.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcNotFound       = -32001
+	rpcBadRequest     = -32002
+)
+
+const rpcMaxBody = 1 << 20
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcIDParams struct {
+	ID string `json:"id"`
+}
+
+// rpcListParams — те же фильтры, что у GET /tasks.
+type rpcListParams struct {
+	Status   domain.Status `json:"status"`
+	Project  string        `json:"project"`
+	Assignee string        `json:"assignee"`
+	ParentID string        `json:"parent_id"`
+}
+
+type rpcUpdateParams struct {
+	ID string `json:"id"`
+	dto.UpdateInput
+}
+
+type rpcCommentParams struct {
+	TaskID string `json:"task_id"`
+	dto.CommentInput
+}
+
+type rpcListCommentsParams struct {
+	TaskIDs []string `json:"task_ids"`
+}
+
+// RPC — JSON-RPC 2.0 поверх TaskService: одиночные и пакетные вызовы,
+// уведомления (без id) не получают ответа.
+func (rt *Router) RPC(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	body, err := io.ReadAll(io.LimitReader(r.Body, rpcMaxBody+1))
+	if err != nil || len(body) > rpcMaxBody {
+		writeJSON(w, http.StatusOK, rpcFail(nil, rpcInvalidRequest, "request too large or unreadable"))
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			writeJSON(w, http.StatusOK, rpcFail(nil, rpcParseError, "parse error"))
+			return
+		}
+		if len(batch) == 0 {
+			writeJSON(w, http.StatusOK, rpcFail(nil, rpcInvalidRequest, "empty batch"))
+			return
+		}
+		out := make([]rpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if resp, ok := rt.rpcCall(r.Context(), raw); ok {
+				out = append(out, resp)
+			}
+		}
+		if len(out) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	resp, ok := rt.rpcCall(r.Context(), body)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// rpcCall выполняет один вызов; false — это уведомление и ответ не нужен.
+func (rt *Router) rpcCall(ctx context.Context, raw json.RawMessage) (rpcResponse, bool) {
+	if !json.Valid(raw) {
+		return rpcFail(nil, rpcParseError, "parse error"), true
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" || !validRPCID(req.ID) {
+		return rpcFail(nil, rpcInvalidRequest, "invalid request"), true
+	}
+	notification := req.ID == nil
+
+	result, rerr := rt.rpcDispatch(ctx, req)
+	if notification {
+		return rpcResponse{}, false
+	}
+	if rerr != nil {
+		return rpcResponse{JSONRPC: "2.0", Error: rerr, ID: req.ID}, true
+	}
+	return rpcResponse{JSONRPC: "2.0", Result: result, ID: req.ID}, true
+}
+
+func (rt *Router) rpcDispatch(ctx context.Context, req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "tasks.create":
+		var in dto.CreateInput
+		if err := rpcParams(req.Params, &in); err != nil {
+			return nil, err
+		}
+		t, err := rt.svc.Create(ctx, in)
+		return rpcResult(t, err)
+	case "tasks.get":
+		var p rpcIDParams
+		if err := rpcParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.ID == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "missing id"}
+		}
+		t, err := rt.svc.Get(ctx, p.ID)
+		return rpcResult(t, err)
+	case "tasks.list":
+		var p rpcListParams
+		if len(req.Params) > 0 {
+			if err := rpcParams(req.Params, &p); err != nil {
+				return nil, err
+			}
+		}
+		f := dto.ListFilter{Project: p.Project, Assignee: p.Assignee}
+		if p.ParentID != "" {
+			f.ParentIDs = []string{p.ParentID}
+		}
+		if p.Status != "" {
+			if !validation.IsValidStatus(p.Status) {
+				return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid status"}
+			}
+			f.Status = &p.Status
+		}
+		list, err := rt.svc.List(ctx, f)
+		return rpcResult(list, err)
+	case "tasks.update":
+		var p rpcUpdateParams
+		if err := rpcParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.ID == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "missing id"}
+		}
+		t, err := rt.svc.Update(ctx, p.ID, p.UpdateInput)
+		return rpcResult(t, err)
+	case "tasks.delete":
+		var p rpcIDParams
+		if err := rpcParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.ID == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "missing id"}
+		}
+		err := rt.svc.Delete(ctx, p.ID)
+		return rpcResult(map[string]string{"id": p.ID}, err)
+	case "tasks.addComment":
+		var p rpcCommentParams
+		if err := rpcParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.TaskID == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "missing task_id"}
+		}
+		c, err := rt.svc.AddComment(ctx, p.TaskID, p.CommentInput)
+		return rpcResult(c, err)
+	case "tasks.listComments":
+		var p rpcListCommentsParams
+		if err := rpcParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.TaskIDs) == 0 {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "missing task_ids"}
+		}
+		list, err := rt.svc.ListComments(ctx, p.TaskIDs)
+		if list == nil {
+			list = []domain.Comment{}
+		}
+		return rpcResult(list, err)
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "method not found"}
+	}
+}
+
+// rpcParams принимает только именованные параметры (объект).
+func rpcParams(raw json.RawMessage, v any) *rpcError {
+	if len(raw) == 0 || raw[0] != '{' {
+		return &rpcError{Code: rpcInvalidParams, Message: "params must be an object"}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: "invalid params"}
+	}
+	return nil
+}
+
+func rpcResult(v any, err error) (any, *rpcError) {
+	if err == nil {
+		return v, nil
+	}
+	// В data — тот же код и нарушения по полям, что и в problem+json.
+	p := problemOf(err)
+	data := map[string]any{"code": p.Code}
+	if len(p.Fields) > 0 {
+		data["errors"] = p.Fields
+	}
+	code := rpcInternalError
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		code = rpcNotFound
+	case errors.Is(err, usecase.ErrBadRequest):
+		code = rpcBadRequest
+	}
+	return nil, &rpcError{Code: code, Message: p.Message, Data: data}
+}
+
+func rpcFail(id json.RawMessage, code int, msg string) rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: code, Message: msg}, ID: id}
+}
+
+// validRPCID — id может быть строкой, числом или null (либо отсутствовать).
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/events"
+	"time"
+)
+
+type EventStream interface {
+	Subscribe(lastID uint64) (*events.Subscriber, []events.Event, bool)
+}
+
+// Events отдает изменения задач как Server-Sent Events.
+// Фильтры те же, что у GET /tasks; для продолжения после обрыва
+// клиент присылает Last-Event-ID (или ?last_event_id=).
+func (rt *Router) Events(w http.ResponseWriter, r *http.Request) {
+	f, err := listFilterFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	lastRaw := r.Header.Get("Last-Event-ID")
+	if lastRaw == "" {
+		lastRaw = r.URL.Query().Get("last_event_id")
+	}
+	lastID, _ := strconv.ParseUint(strings.TrimSpace(lastRaw), 10, 64)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub, replay, complete := rt.events.Subscribe(lastID)
+	defer sub.Close()
+
+	_, _ = io.WriteString(w, "retry: 3000\n\n")
+	if !complete {
+		_, _ = io.WriteString(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, ev := range replay {
+		if matchFilter(f, ev.Task) {
+			writeSSE(w, ev)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(rt.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !matchFilter(f, ev.Task) {
+				continue
+			}
+			writeSSE(w, ev)
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": heartbeat\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w io.Writer, ev events.Event) {
+	b, err := json.Marshal(ev.TaskEvent)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, b)
+}
+
+func matchFilter(f dto.ListFilter, t domain.Task) bool {
+	if f.Status != nil && t.Status != *f.Status {
+		return false
+	}
+	if f.Project != "" && t.Project != f.Project {
+		return false
+	}
+	return true
+}
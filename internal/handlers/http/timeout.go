@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HeaderRequestTimeout — бюджет времени, который клиент дает запросу, в
+// формате time.ParseDuration ("500ms", "2s"). Он заменяет таймаут маршрута,
+// но не больше Timeouts.Max.
+const HeaderRequestTimeout = "X-Request-Timeout"
+
+// Timeouts — ограничения времени обработки запроса.
+type Timeouts struct {
+	// Default — для маршрутов без своего значения.
+	Default time.Duration
+	// Max — предел для HeaderRequestTimeout.
+	Max time.Duration
+	// Routes — по шаблону маршрута ("GET /tasks/{id}"); 0 — без ограничения.
+	Routes map[string]time.Duration
+}
+
+// DefaultTimeouts — ограничения без WithTimeouts. Потоки событий живут,
+// пока клиент подключен.
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Default: 10 * time.Second,
+		Max:     time.Minute,
+		Routes: map[string]time.Duration{
+			"GET /events": 0,
+			"GET /ws":     0,
+		},
+	}
+}
+
+func (t Timeouts) forRoute(key string) time.Duration {
+	if d, ok := t.Routes[key]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// WithTimeouts задает таймауты маршрутов. Маршруты потоков, не упомянутые в
+// t.Routes, остаются без ограничения.
+func WithTimeouts(t Timeouts) Option {
+	return func(rt *Router) {
+		routes := DefaultTimeouts().Routes
+		for k, v := range t.Routes {
+			routes[k] = v
+		}
+		t.Routes = routes
+		rt.timeouts = t
+	}
+}
+
+// CheckTimeouts сообщает о таймаутах для маршрутов, которых нет в API:
+// опечатка в шаблоне иначе тихо оставила бы маршрут со сроком по умолчанию.
+func (rt *Router) CheckTimeouts() error {
+	known := make(map[string]bool)
+	for _, r := range rt.routes() {
+		known[r.Method+" "+r.Path] = true
+	}
+	var unknown []string
+	for k := range rt.timeouts.Routes {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown routes: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// timeout ограничивает обработку запроса сроком маршрута или клиента. Если
+// срок истек, а обработчик не успел ответить или ответил ошибкой сервера,
+// клиент получает 504 с описанием.
+func (rt *Router) timeout(r route, next http.Handler) http.Handler {
+	limit := rt.timeouts.forRoute(r.Method + " " + r.Path)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		d := limit
+		if v := req.Header.Get(HeaderRequestTimeout); v != "" {
+			cd, err := time.ParseDuration(v)
+			if err != nil || cd < 0 {
+				writeError(w, req, problemf(codeInvalidHeader, "invalid %s header %q, expected a duration like 500ms", HeaderRequestTimeout, v))
+				return
+			}
+			if cd == 0 {
+				// Бюджет клиента исчерпан еще до начала обработки.
+				writeError(w, req, problemf(codeDeadlineExceeded, "the client deadline expired before processing started"))
+				return
+			}
+			d = min(cd, rt.timeouts.Max)
+		}
+		if d <= 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), d)
+		defer cancel()
+		tw := &timeoutWriter{ResponseWriter: w, req: req, ctx: ctx, limit: d}
+		next.ServeHTTP(tw, req.WithContext(ctx))
+		if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			tw.timedOut()
+		}
+	})
+}
+
+// timeoutWriter подменяет ответ 5xx, вызванный истекшим сроком, на 504.
+type timeoutWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	ctx         context.Context
+	limit       time.Duration
+	wroteHeader bool
+	discard     bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	if code >= 500 && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut()
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) timedOut() {
+	w.wroteHeader, w.discard = true, true
+	writeProblem(w.ResponseWriter, w.req, problemf(codeRequestTimeout, "the request was not completed within %s", w.limit),
+		func(p *problem) { p.Timeout = w.limit.String() })
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.discard {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
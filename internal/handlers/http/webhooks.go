@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"taskapi/internal/dto"
+)
+
+func (rt *Router) webhooksCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respond(w, r, http.StatusOK, rt.hooks.List())
+	case http.MethodPost:
+		rt.CreateWebhook(w, r)
+	}
+}
+
+func (rt *Router) webhookItem(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/webhooks/"), "/")
+	id := parts[0]
+	if id == "" {
+		writeError(w, r, errMissingID)
+		return
+	}
+	if len(parts) > 1 {
+		if parts[1] != "deliveries" || len(parts) > 2 {
+			writeError(w, r, problemf(codeRouteNotFound, "no route for %s %s", r.Method, r.URL.Path))
+			return
+		}
+		list, ok := rt.hooks.Deliveries(id)
+		if !ok {
+			writeError(w, r, errWebhookNotFound(id))
+			return
+		}
+		respond(w, r, http.StatusOK, list)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		sub, ok := rt.hooks.Get(id)
+		if !ok {
+			writeError(w, r, errWebhookNotFound(id))
+			return
+		}
+		respond(w, r, http.StatusOK, sub)
+	case http.MethodPut:
+		rt.UpdateWebhook(w, r, id)
+	case http.MethodDelete:
+		if !rt.hooks.Delete(id) {
+			writeError(w, r, errWebhookNotFound(id))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (rt *Router) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	var in dto.WebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, r, errInvalidJSON(err))
+		return
+	}
+	sub, err := rt.hooks.Create(in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	respond(w, r, http.StatusCreated, sub)
+}
+
+func (rt *Router) UpdateWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	var in dto.WebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, r, errInvalidJSON(err))
+		return
+	}
+	sub, ok, err := rt.hooks.Update(id, in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !ok {
+		writeError(w, r, errWebhookNotFound(id))
+		return
+	}
+	respond(w, r, http.StatusOK, sub)
+}
+
+func errWebhookNotFound(id string) error {
+	return problemf(codeWebhookNotFound, "webhook %q not found", id)
+}
@@ -0,0 +1,295 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/events"
+	"taskapi/internal/usecase"
+	"taskapi/internal/usecase/validation"
+	"taskapi/internal/websocket"
+	"time"
+)
+
+const (
+	wsMaxMessageSize = 64 * 1024
+	wsPongWait       = 60 * time.Second
+	wsPingInterval   = wsPongWait * 9 / 10
+)
+
+// wsCommand — сообщение клиента. ID возвращается в ответе, чтобы клиент
+// мог сопоставить результат с запросом.
+type wsCommand struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	TaskID      string          `json:"task_id,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Project     string          `json:"project,omitempty"`
+	LastEventID uint64          `json:"last_event_id,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// wsReply — ответ сервера. У ошибки те же code и errors, что в
+// problem+json, а в error — текст detail.
+type wsReply struct {
+	ID     string               `json:"id,omitempty"`
+	Type   string               `json:"type"`
+	Seq    uint64               `json:"seq,omitempty"`
+	Data   any                  `json:"data,omitempty"`
+	Code   usecase.Code         `json:"code,omitempty"`
+	Error  string               `json:"error,omitempty"`
+	Errors []usecase.FieldError `json:"errors,omitempty"`
+}
+
+// wsSubscription передается из читающей горутины в пишущую:
+// подпиской владеет только writeLoop.
+type wsSubscription struct {
+	cmdID    string
+	sub      *events.Subscriber
+	filter   dto.ListFilter
+	replay   []events.Event
+	complete bool
+}
+
+type wsSession struct {
+	rt   *Router
+	conn *websocket.Conn
+	ctx  context.Context
+	out  chan wsReply
+	subs chan wsSubscription
+}
+
+// wsHandshakeError отвечает на неудачное рукопожатие problem+json со
+// статусом, который выбрал websocket.Upgrade (400, 403, 405 или 426).
+func wsHandshakeError(w http.ResponseWriter, r *http.Request, status int, reason string) {
+	writeProblem(w, r, problemf(codeHandshakeFailed, "%s", reason), func(p *problem) { p.Status = status })
+}
+
+// wsCheckOrigin пускает страницы того же хоста и источники, разрешенные
+// для CORS.
+func (rt *Router) wsCheckOrigin(r *http.Request) bool {
+	if websocket.SameOrigin(r) {
+		return true
+	}
+	return rt.cors != nil && rt.cors.allowOrigin(r.Header.Get("Origin"))
+}
+
+// WebSocket — двунаправленный канал: подписка на изменения задач
+// (subscribe/unsubscribe) и команды create/update/get/list/delete.
+func (rt *Router) WebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r, websocket.Options{MaxMessageSize: wsMaxMessageSize, CheckOrigin: rt.wsCheckOrigin, Error: wsHandshakeError})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	s := &wsSession{
+		rt:   rt,
+		conn: conn,
+		ctx:  ctx,
+		out:  make(chan wsReply, 64),
+		subs: make(chan wsSubscription),
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.PongHandler = func([]byte) {
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	}
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.writeLoop()
+		cancel()
+		// Даем клиенту время ответить на close, затем читатель отпустит соединение.
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	}()
+
+	for {
+		op, msg, err := conn.ReadMessage()
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		if op != websocket.OpText {
+			_ = conn.WriteClose(websocket.CloseUnsupportedData, "text messages only")
+			break
+		}
+		var cmd wsCommand
+		if err := json.Unmarshal(msg, &cmd); err != nil {
+			s.send(wsReply{Type: "error", Error: "invalid JSON message"})
+			continue
+		}
+		s.handle(cmd)
+	}
+	cancel()
+	<-writerDone
+}
+
+func (s *wsSession) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	var cur wsSubscription
+	defer func() {
+		if cur.sub != nil {
+			cur.sub.Close()
+		}
+	}()
+
+	for {
+		var evCh <-chan events.Event
+		if cur.sub != nil {
+			evCh = cur.sub.C
+		}
+		select {
+		case <-s.ctx.Done():
+			_ = s.conn.WriteClose(websocket.CloseGoingAway, "")
+			return
+		case reply := <-s.out:
+			if !s.write(reply) {
+				return
+			}
+		case next := <-s.subs:
+			if cur.sub != nil {
+				cur.sub.Close()
+			}
+			cur = next
+			if !s.writeSubscribed(cur) {
+				return
+			}
+		case ev, ok := <-evCh:
+			if !ok {
+				// Хаб остановлен или клиент не успевает читать.
+				_ = s.conn.WriteClose(websocket.CloseGoingAway, "event stream closed")
+				return
+			}
+			if matchFilter(cur.filter, ev.Task) && !s.write(wsReply{Type: "event", Seq: ev.ID, Data: ev.TaskEvent}) {
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WritePing(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) writeSubscribed(sub wsSubscription) bool {
+	if sub.sub == nil {
+		return s.write(wsReply{ID: sub.cmdID, Type: "unsubscribed"})
+	}
+	if !s.write(wsReply{ID: sub.cmdID, Type: "subscribed", Data: map[string]bool{"complete": sub.complete}}) {
+		return false
+	}
+	for _, ev := range sub.replay {
+		if matchFilter(sub.filter, ev.Task) && !s.write(wsReply{Type: "event", Seq: ev.ID, Data: ev.TaskEvent}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *wsSession) write(reply wsReply) bool {
+	b, err := json.Marshal(reply)
+	if err != nil {
+		return true
+	}
+	return s.conn.WriteMessage(websocket.OpText, b) == nil
+}
+
+func (s *wsSession) send(reply wsReply) {
+	select {
+	case s.out <- reply:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *wsSession) handle(cmd wsCommand) {
+	ctx := s.ctx
+	switch cmd.Type {
+	case "subscribe":
+		f, err := wsFilter(cmd)
+		if err != nil {
+			s.fail(cmd.ID, err)
+			return
+		}
+		sub, replay, complete := s.rt.events.Subscribe(cmd.LastEventID)
+		select {
+		case s.subs <- wsSubscription{cmdID: cmd.ID, sub: sub, filter: f, replay: replay, complete: complete}:
+		case <-ctx.Done():
+			sub.Close()
+		}
+	case "unsubscribe":
+		select {
+		case s.subs <- wsSubscription{cmdID: cmd.ID}:
+		case <-ctx.Done():
+		}
+	case "create":
+		var in dto.CreateInput
+		if err := json.Unmarshal(cmd.Data, &in); err != nil {
+			s.fail(cmd.ID, errInvalidJSON(err))
+			return
+		}
+		t, err := s.rt.svc.Create(ctx, in)
+		s.reply(cmd.ID, t, err)
+	case "update":
+		var in dto.UpdateInput
+		if err := json.Unmarshal(cmd.Data, &in); err != nil {
+			s.fail(cmd.ID, errInvalidJSON(err))
+			return
+		}
+		t, err := s.rt.svc.Update(ctx, cmd.TaskID, in)
+		s.reply(cmd.ID, t, err)
+	case "get":
+		t, err := s.rt.svc.Get(ctx, cmd.TaskID)
+		s.reply(cmd.ID, t, err)
+	case "list":
+		f, err := wsFilter(cmd)
+		if err != nil {
+			s.fail(cmd.ID, err)
+			return
+		}
+		list, err := s.rt.svc.List(ctx, f)
+		s.reply(cmd.ID, list, err)
+	case "delete":
+		err := s.rt.svc.Delete(ctx, cmd.TaskID)
+		s.reply(cmd.ID, map[string]string{"id": cmd.TaskID}, err)
+	default:
+		s.fail(cmd.ID, problemf(codeUnknownCommand, "unknown command %q", cmd.Type))
+	}
+}
+
+func (s *wsSession) reply(id string, data any, err error) {
+	if err != nil {
+		s.fail(id, err)
+		return
+	}
+	s.send(wsReply{ID: id, Type: "result", Data: data})
+}
+
+// fail отправляет ошибку через тот же каталог, что и REST: текст ошибок
+// без кода клиенту не показывается.
+func (s *wsSession) fail(id string, err error) {
+	p := problemOf(err)
+	s.send(wsReply{ID: id, Type: "error", Code: p.Code, Error: p.Message, Errors: p.Fields})
+}
+
+func wsFilter(cmd wsCommand) (dto.ListFilter, error) {
+	f := dto.ListFilter{Project: cmd.Project}
+	if cmd.Status != "" {
+		st := domain.Status(cmd.Status)
+		if !validation.IsValidStatus(st) {
+			return f, &usecase.Error{Code: usecase.CodeValidationFailed, Message: "invalid filter", Fields: []usecase.FieldError{
+				{Field: "status", Code: usecase.FieldInvalid, Message: fmt.Sprintf("unknown status %q", cmd.Status)},
+			}}
+		}
+		f.Status = &st
+	}
+	return f, nil
+}
@@ -0,0 +1,15 @@
+package health
+
+import (
+	"context"
+	"taskapi/internal/repository"
+)
+
+// Repository проверяет, что хранилище отвечает на чтение: зависшая запись
+// держит блокировку, и проверка упрется в таймаут.
+func Repository(repo repository.TaskRepository) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, _, err := repo.GetByID(ctx, "healthcheck")
+		return err
+	}
+}
@@ -0,0 +1,168 @@
+// Package health — реестр проверок состояния для /livez и /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout ограничивает проверку, для которой таймаут не задан.
+const DefaultTimeout = 2 * time.Second
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check — проверка одного компонента. Проверки с Live участвуют и в
+// liveness: их провал означает, что процесс стоит перезапустить. Все
+// проверки участвуют в readiness.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Live    bool
+	Fn      func(ctx context.Context) error
+}
+
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []Check
+	shutdown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register добавляет проверку; имя должно быть уникальным.
+func (r *Registry) Register(c Check) {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks {
+		if existing.Name == c.Name {
+			panic("health: duplicate check " + c.Name)
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// SetShuttingDown переводит readiness в отказ: балансировщик перестает
+// присылать новые запросы, пока сервер дорабатывает текущие.
+func (r *Registry) SetShuttingDown() {
+	r.shutdown.Store(true)
+}
+
+// Live выполняет проверки liveness.
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+// Ready выполняет все проверки и учитывает остановку сервера.
+func (r *Registry) Ready(ctx context.Context) Report {
+	rep := r.run(ctx, false)
+	if r.shutdown.Load() {
+		rep.Status = StatusFail
+		rep.Checks = append([]Result{{Name: "shutdown", Status: StatusFail, Latency: "0s", Error: ErrShuttingDown.Error()}}, rep.Checks...)
+	}
+	return rep
+}
+
+// run выполняет проверки параллельно, каждую со своим таймаутом. Проверка,
+// не уважающая ctx, все равно считается проваленной по таймауту.
+func (r *Registry) run(ctx context.Context, liveOnly bool) Report {
+	r.mu.RLock()
+	var checks []Check
+	for _, c := range r.checks {
+		if !liveOnly || c.Live {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	rep := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK {
+			rep.Status = StatusFail
+		}
+	}
+	return rep
+}
+
+func runCheck(ctx context.Context, c Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.Fn(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	res := Result{Name: c.Name, Status: StatusOK, Latency: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Handler отдает "ok"/"fail" текстом, а с ?verbose — JSON со всеми
+// проверками. При отказе код ответа 503.
+func Handler(run func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := run(r.Context())
+		code := http.StatusOK
+		if !rep.OK() {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		if _, verbose := r.URL.Query()["verbose"]; verbose {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(rep)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(rep.Status))
+	})
+}
@@ -0,0 +1,94 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"taskapi/internal/health"
+)
+
+func newRegistry() *health.Registry {
+	reg := health.NewRegistry()
+	reg.Register(health.Check{Name: "logger", Live: true, Fn: func(context.Context) error { return nil }})
+	reg.Register(health.Check{Name: "repository", Timeout: 20 * time.Millisecond, Fn: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	reg.Register(health.Check{Name: "webhooks", Timeout: 20 * time.Millisecond, Fn: func(context.Context) error {
+		time.Sleep(time.Second) // не смотрит на ctx
+		return nil
+	}})
+	reg.Register(health.Check{Name: "storage", Fn: func(context.Context) error { return errors.New("disk gone") }})
+	return reg
+}
+
+func TestRegistry(t *testing.T) {
+	reg := newRegistry()
+
+	live := reg.Live(context.Background())
+	if !live.OK() || len(live.Checks) != 1 || live.Checks[0].Name != "logger" {
+		t.Errorf("liveness must run only live checks: %+v", live)
+	}
+
+	start := time.Now()
+	ready := reg.Ready(context.Background())
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("checks must run in parallel with timeouts, took %s", took)
+	}
+	want := map[string]string{
+		"logger":     "",
+		"repository": context.DeadlineExceeded.Error(),
+		"storage":    "disk gone",
+		"webhooks":   context.DeadlineExceeded.Error(),
+	}
+	if ready.OK() || len(ready.Checks) != len(want) {
+		t.Fatalf("unexpected readiness: %+v", ready)
+	}
+	for _, c := range ready.Checks {
+		if c.Error != want[c.Name] {
+			t.Errorf("%s: expected error %q, got %q", c.Name, want[c.Name], c.Error)
+		}
+		if c.Latency == "" {
+			t.Errorf("%s: latency is missing", c.Name)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := health.NewRegistry()
+	reg.Register(health.Check{Name: "repository", Fn: func(context.Context) error { return nil }})
+	ready := health.Handler(reg.Ready)
+
+	tests := []struct {
+		name     string
+		target   string
+		shutdown bool
+		wantCode int
+		wantBody string
+	}{
+		{"ok", "/readyz", false, http.StatusOK, "ok"},
+		{"verbose", "/readyz?verbose", false, http.StatusOK, `{"status":"ok","checks":[{"name":"repository","status":"ok"`},
+		{"shutting down", "/readyz", true, http.StatusServiceUnavailable, "fail"},
+		{"shutting down verbose", "/readyz?verbose=1", true, http.StatusServiceUnavailable, `"status":"fail","checks":[{"name":"shutdown","status":"fail","latency":"0s","error":"shutting down"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.shutdown {
+				reg.SetShuttingDown()
+			}
+			rr := httptest.NewRecorder()
+			ready.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected code %d, got %d", tt.wantCode, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -1,67 +1,349 @@
 package logger
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Entry struct {
 	Time      time.Time      `json:"time"`
+	Level     Level          `json:"level"`
 	Event     string         `json:"event"`
 	RequestID string         `json:"request_id,omitempty"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	SpanID    string         `json:"span_id,omitempty"`
 	Data      map[string]any `json:"data,omitempty"`
 	Error     string         `json:"error,omitempty"`
 }
 
 type Logger interface {
 	Log(Entry)
+	// Enabled сообщает, будет ли записана запись такого уровня, чтобы не
+	// собирать дорогие поля зря.
+	Enabled(Level) bool
 	Stop()
 }
 
+// Options настраивает Async.
+type Options struct {
+	Buffer int
+	// Level — минимальный уровень; записи ниже отбрасываются до очереди.
+	Level Level
+	Sinks []Sink
+	// Sampling — правила прореживания по имени события.
+	Sampling map[string]Sampling
+
+	// Policy — поведение при заполненной очереди, по умолчанию drop_newest.
+	Policy Policy
+	// BlockTimeout — сколько PolicyBlock ждет места в очереди.
+	BlockTimeout time.Duration
+	// SpillPath и SpillMaxBytes — файл переполнения для PolicySpill.
+	SpillPath     string
+	SpillMaxBytes int64
+	// SummaryInterval — как часто писать сводку log_dropped о потерях.
+	SummaryInterval time.Duration
+	// Redactor убирает чувствительные данные до очереди, так что они не
+	// попадают ни в получатели, ни в файл переполнения.
+	Redactor *Redactor
+}
+
+func (o Options) withDefaults() Options {
+	if o.Policy == "" {
+		o.Policy = PolicyDropNewest
+	}
+	if o.BlockTimeout <= 0 {
+		o.BlockTimeout = 100 * time.Millisecond
+	}
+	if o.SpillMaxBytes <= 0 {
+		o.SpillMaxBytes = 64 << 20
+	}
+	if o.SummaryInterval <= 0 {
+		o.SummaryInterval = 10 * time.Second
+	}
+	return o
+}
+
 type Async struct {
-	ch      chan Entry
-	wg      sync.WaitGroup
-	closeMu sync.Mutex
+	ch chan Entry
+	wg sync.WaitGroup
+	// closeMu держат на чтение все отправители, поэтому Stop не закроет
+	// канал под ждущей отправкой PolicyBlock.
+	closeMu sync.RWMutex
 	closed  bool
+	dropped atomic.Uint64
+	spilled atomic.Uint64
+	drops   *dropCounter
+	// recentDrops — потери по секундам для Check.
+	recentDrops dropWindow
+	// busySince — когда началась запись текущей строки (UnixNano), 0 — простой.
+	busySince atomic.Int64
 
-	out *log.Logger
+	opts  Options
+	sinks []Sink
+	spill *spillFile
+	// level, sampler и redactor меняются на ходу при перечитывании
+	// конфигурации.
+	level    atomic.Int64
+	sampler  atomic.Pointer[sampler]
+	redactor atomic.Pointer[Redactor]
 }
 
+// NewAsync пишет все записи от info и выше в out в формате JSON.
 func NewAsync(buffer int, out io.Writer) *Async {
+	l, _ := New(Options{Buffer: buffer, Sinks: []Sink{{Name: "out", Out: out, Level: LevelDebug, Format: FormatJSON}}})
+	return l
+}
+
+// New создает логгер; ошибка возможна только при открытии файла
+// переполнения.
+func New(opts Options) (*Async, error) {
+	opts = opts.withDefaults()
 	l := &Async{
-		ch:  make(chan Entry, buffer),
-		out: log.New(out, "", 0),
+		ch:    make(chan Entry, opts.Buffer),
+		drops: newDropCounter(),
+		opts:  opts,
+		sinks: opts.Sinks,
+	}
+	l.SetLevel(opts.Level)
+	l.SetSampling(opts.Sampling)
+	l.SetRedactor(opts.Redactor)
+	if opts.Policy == PolicySpill {
+		if opts.SpillPath == "" {
+			return nil, errors.New("log overflow policy spill requires a spill path")
+		}
+		spill, err := openSpill(opts.SpillPath, opts.SpillMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		l.spill = spill
 	}
 	l.wg.Add(1)
-	go func() {
-		defer l.wg.Done()
-		for e := range l.ch {
-			b, err := json.Marshal(e)
-			if err != nil {
-				l.out.Printf(`{"time":"%s","event":"logger_error","error":"%s"}`, time.Now().UTC().Format(time.RFC3339Nano), err.Error())
-				continue
+	go l.run()
+	return l, nil
+}
+
+func (a *Async) run() {
+	defer a.wg.Done()
+	summary := time.NewTicker(a.opts.SummaryInterval)
+	defer summary.Stop()
+	// Файл переполнения вычитывается и без новых записей в очереди.
+	drain := time.NewTicker(time.Second)
+	defer drain.Stop()
+	// Записи, оставшиеся с прошлого запуска, пишутся первыми.
+	a.drainSpill()
+	for {
+		select {
+		case e, ok := <-a.ch:
+			if !ok {
+				a.drainSpill()
+				a.summarize()
+				if a.spill != nil {
+					_ = a.spill.close()
+				}
+				return
+			}
+			a.busySince.Store(time.Now().UnixNano())
+			a.write(e)
+			a.busySince.Store(0)
+			if len(a.ch) == 0 {
+				a.drainSpill()
+			}
+		case <-drain.C:
+			if len(a.ch) == 0 {
+				a.drainSpill()
 			}
-			l.out.Println(string(b))
+		case <-summary.C:
+			a.summarize()
 		}
-	}()
-	return l
+	}
+}
+
+// drainSpill дописывает записи из файла переполнения порциями, пока очередь
+// пуста. Порция удаляется из файла только после записи, поэтому сбой
+// посреди вычитывания ничего не теряет.
+func (a *Async) drainSpill() {
+	if a.spill == nil {
+		return
+	}
+	for a.spill.pending() && len(a.ch) == 0 {
+		entries, n, err := a.spill.next()
+		if err != nil {
+			a.internalError("spill", err)
+			return
+		}
+		for _, e := range entries {
+			a.write(e)
+		}
+		if err := a.spill.advance(n); err != nil {
+			a.internalError("spill", err)
+			return
+		}
+	}
+}
+
+// summarize пишет сводку потерь с прошлого раза: сколько записей и каких
+// событий отброшено.
+func (a *Async) summarize() {
+	n, events := a.drops.flush()
+	if n == 0 {
+		return
+	}
+	a.write(Entry{
+		Time:  time.Now().UTC(),
+		Level: LevelWarn,
+		Event: "log_dropped",
+		Data:  map[string]any{"count": n, "events": events, "policy": string(a.opts.Policy)},
+	})
+}
+
+// write отдает запись всем получателям, чей уровень она проходит. Ошибка
+// одного получателя не мешает остальным.
+func (a *Async) write(e Entry) {
+	for _, s := range a.sinks {
+		if e.Level < s.Level {
+			continue
+		}
+		// О приостановленном получателе уже сообщено при первом сбое.
+		if err := s.write(e); err != nil && !errors.Is(err, ErrSinkSuspended) {
+			a.internalError(s.Name, err)
+		}
+	}
+}
+
+// Reopener — получатель, который умеет заново открыть свой файл.
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen переоткрывает файлы получателей, например после внешнего
+// logrotate по SIGHUP.
+func (a *Async) Reopen() error {
+	var errs []error
+	for _, s := range a.sinks {
+		if r, ok := s.Out.(Reopener); ok {
+			if err := r.Reopen(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// internalError сообщает о сбое самого логгера в stderr, мимо получателей.
+func (a *Async) internalError(sink string, err error) {
+	fmt.Fprintf(os.Stderr, `{"time":"%s","level":"error","event":"logger_error","data":{"sink":%q},"error":%q}`+"\n",
+		time.Now().UTC().Format(time.RFC3339Nano), sink, err.Error())
 }
 
+func (a *Async) Enabled(l Level) bool {
+	return l >= Level(a.level.Load())
+}
+
+func (a *Async) SetLevel(l Level) { a.level.Store(int64(l)) }
+
+// SetSampling заменяет правила прореживания; счетчики начинаются заново.
+func (a *Async) SetSampling(rules map[string]Sampling) { a.sampler.Store(newSampler(rules)) }
+
+func (a *Async) SetRedactor(r *Redactor) { a.redactor.Store(r) }
+
 func (a *Async) Log(e Entry) {
-	a.closeMu.Lock()
-	closed := a.closed
-	a.closeMu.Unlock()
-	if closed {
+	if !a.Enabled(e.Level) || !a.sampler.Load().keep(e) {
+		return
+	}
+	e = a.redactor.Load().Redact(e)
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+	if a.closed {
 		return
 	}
 	select {
 	case a.ch <- e:
+		return
 	default:
-		a.out.Printf(`{"time":"%s","event":"log_dropped"}`, time.Now().UTC().Format(time.RFC3339Nano))
 	}
+	switch a.opts.Policy {
+	case PolicyDropOldest:
+		// Между освобождением места и отправкой очередь могут снова занять
+		// другие горутины, поэтому несколько попыток.
+		for i := 0; i < 3; i++ {
+			select {
+			case old := <-a.ch:
+				a.drop(old)
+			default:
+			}
+			select {
+			case a.ch <- e:
+				return
+			default:
+			}
+		}
+	case PolicyBlock:
+		t := time.NewTimer(a.opts.BlockTimeout)
+		defer t.Stop()
+		select {
+		case a.ch <- e:
+			return
+		case <-t.C:
+		}
+	case PolicySpill:
+		if err := a.spill.put(e); err == nil {
+			a.spilled.Add(1)
+			return
+		}
+	}
+	a.drop(e)
+}
+
+func (a *Async) drop(e Entry) {
+	a.dropped.Add(1)
+	a.drops.add(e.Event)
+	a.recentDrops.add(time.Now())
+}
+
+// QueueLen — записей, ожидающих вывода.
+func (a *Async) QueueLen() int { return len(a.ch) }
+
+func (a *Async) QueueCap() int { return cap(a.ch) }
+
+// Dropped — записей, отброшенных из-за переполненной очереди.
+func (a *Async) Dropped() uint64 { return a.dropped.Load() }
+
+// DroppedByEvent — отброшенные записи по имени события с момента запуска.
+func (a *Async) DroppedByEvent() map[string]uint64 { return a.drops.snapshot() }
+
+// Spilled — записей, ушедших в файл переполнения.
+func (a *Async) Spilled() uint64 { return a.spilled.Load() }
+
+// StallTimeout — сколько может писаться одна запись, прежде чем логгер
+// считается зависшим.
+const StallTimeout = 5 * time.Second
+
+// Check сообщает, что логгер не успевает писать: вывод завис или записи
+// отбрасываются постоянно. Разовый всплеск, даже заполнивший очередь,
+// готовность не снимает.
+// Check ничего не меняет, поэтому разные пробы видят одно и то же.
+func (a *Async) Check(context.Context) error {
+	a.closeMu.RLock()
+	closed := a.closed
+	a.closeMu.RUnlock()
+	if closed {
+		return errors.New("logger stopped")
+	}
+	if since := a.busySince.Load(); since != 0 {
+		if d := time.Since(time.Unix(0, since)); d > StallTimeout {
+			return fmt.Errorf("output blocked for %s", d.Round(time.Second))
+		}
+	}
+	if secs, n := a.recentDrops.stats(time.Now()); secs >= dropSustained {
+		return fmt.Errorf("%d entries dropped in %d of the last %d seconds", n, secs, dropWindowSize)
+	}
+	return nil
 }
 
 func (a *Async) Stop() {
@@ -0,0 +1,532 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newAsync(t *testing.T, opts Options) *Async {
+	t.Helper()
+	l, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestAsync_SinksAndLevels(t *testing.T) {
+	var all, errs bytes.Buffer
+	l := newAsync(t, Options{
+		Buffer: 16,
+		Level:  LevelInfo,
+		Sinks: []Sink{
+			{Name: "all", Out: &all, Level: LevelDebug, Format: FormatJSON},
+			{Name: "errors", Out: &errs, Level: LevelError, Format: FormatText},
+		},
+	})
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.Log(Entry{Time: at, Level: LevelDebug, Event: "debug_event"})
+	l.Log(Entry{Time: at, Event: "task_created", RequestID: "r1"})
+	l.Log(Entry{Time: at, Level: LevelError, Event: "task_read", Data: map[string]any{"id": "t 1"}, Error: "boom"})
+	l.Stop()
+
+	want := `{"time":"2026-01-02T03:04:05Z","level":"info","event":"task_created","request_id":"r1"}
+{"time":"2026-01-02T03:04:05Z","level":"error","event":"task_read","data":{"id":"t 1"},"error":"boom"}
+`
+	if all.String() != want {
+		t.Errorf("unexpected JSON sink output:\n%s", all.String())
+	}
+	if got := errs.String(); got != "2026-01-02T03:04:05Z ERROR task_read id=\"t 1\" error=boom\n" {
+		t.Errorf("unexpected text sink output: %q", got)
+	}
+	if l.Enabled(LevelDebug) || !l.Enabled(LevelWarn) {
+		t.Error("Enabled must follow the minimum level")
+	}
+}
+
+func TestSampler(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newSampler(map[string]Sampling{"task_read": {First: 2, Thereafter: 3, Tick: time.Second}})
+	s.now = func() time.Time { return now }
+
+	var kept []int
+	for i := 1; i <= 8; i++ {
+		if s.keep(Entry{Event: "task_read"}) {
+			kept = append(kept, i)
+		}
+	}
+	if want := "[1 2 5 8]"; fmt.Sprint(kept) != want {
+		t.Errorf("expected %s, got %v", want, kept)
+	}
+	if !s.keep(Entry{Event: "task_read", Level: LevelWarn}) || !s.keep(Entry{Event: "task_list"}) {
+		t.Error("warnings and events without rules must not be sampled")
+	}
+	now = now.Add(time.Second)
+	if !s.keep(Entry{Event: "task_read"}) {
+		t.Error("a new tick must start counting again")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		sinks   string
+		wantErr string
+	}{
+		{"stdout", "stdout", ""},
+		{"several", "stdout,level=warn;file,path=/tmp/x.log,format=text;syslog,path=/dev/log", ""},
+		{"unknown kind", "kafka", "unknown log sink"},
+		{"file without path", "file", "path is required"},
+		{"bad level", "stdout,level=loud", "unknown log level"},
+		{"bad format", "stdout,format=xml", "unknown format"},
+		{"empty", " ; ", "no log sinks"},
+		{"file rotation", "file,path=/tmp/x.log,max_size=10MB,rotate=24h,max_backups=7,max_age=720h,compress=true", ""},
+		{"bad size", "file,path=/tmp/x.log,max_size=ten", "invalid size"},
+		{"rotation for stdout", "stdout,max_size=1KB", "only supported for files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSinks(tt.sinks)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	rules, err := ParseSampling("task_read=10:100, task_list=5:0")
+	if err != nil || rules["task_read"].First != 10 || rules["task_list"].Thereafter != 0 {
+		t.Errorf("unexpected sampling rules %+v, %v", rules, err)
+	}
+	if _, err := ParseSampling("task_read=10"); err == nil {
+		t.Error("expected error for a rule without thereafter")
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram is not available: %v", err)
+	}
+	defer conn.Close()
+
+	sink, closer, err := OpenSink(SinkConfig{Kind: "syslog", Path: addr, Level: LevelWarn, Format: FormatText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	l := newAsync(t, Options{Buffer: 4, Sinks: []Sink{sink}})
+	l.Log(Entry{Event: "ignored"})
+	l.Log(Entry{Level: LevelError, Event: "webhook_failed"})
+	l.Stop()
+
+	buf := make([]byte, 512)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<11>") || !strings.Contains(msg, " taskapi[") || !strings.Contains(msg, "ERROR webhook_failed") {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+}
+
+// blockingWriter держит запись, пока тест не откроет gate.
+type blockingWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsync_OverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy      Policy
+		wantDropped uint64
+		wantEvents  []string
+		wantSpilled uint64
+	}{
+		// Первая запись уже у воркера, в очереди помещается две.
+		{PolicyDropNewest, 2, []string{"e0", "e1", "e2"}, 0},
+		{PolicyDropOldest, 2, []string{"e0", "e3", "e4"}, 0},
+		{PolicyBlock, 2, []string{"e0", "e1", "e2"}, 0},
+		{PolicySpill, 0, []string{"e0", "e1", "e2", "e3", "e4"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			w := &blockingWriter{gate: make(chan struct{})}
+			l := newAsync(t, Options{
+				Buffer:       2,
+				Sinks:        []Sink{{Name: "w", Out: w, Level: LevelDebug, Format: FormatText}},
+				Policy:       tt.policy,
+				BlockTimeout: 10 * time.Millisecond,
+				SpillPath:    filepath.Join(t.TempDir(), "overflow.jsonl"),
+			})
+			l.Log(Entry{Event: "e0"})
+			for l.QueueLen() != 0 {
+				time.Sleep(time.Millisecond)
+			}
+			for i := 1; i <= 4; i++ {
+				l.Log(Entry{Event: fmt.Sprintf("e%d", i)})
+			}
+			if l.Dropped() != tt.wantDropped || l.Spilled() != tt.wantSpilled {
+				t.Errorf("expected dropped=%d spilled=%d, got %d, %d", tt.wantDropped, tt.wantSpilled, l.Dropped(), l.Spilled())
+			}
+			if err := l.Check(context.Background()); err != nil {
+				t.Errorf("a single burst must not fail the health check: %v", err)
+			}
+			close(w.gate)
+			l.Stop()
+
+			out := w.String()
+			var events []string
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+				events = append(events, strings.Fields(line)[2])
+			}
+			got := strings.Join(events, " ")
+			want := strings.Join(tt.wantEvents, " ")
+			if tt.wantDropped > 0 {
+				want += " log_dropped"
+			}
+			if got != want {
+				t.Errorf("expected events %q, got %q", want, got)
+			}
+			var dropped uint64
+			for _, n := range l.DroppedByEvent() {
+				dropped += n
+			}
+			if dropped != tt.wantDropped {
+				t.Errorf("per-event counters do not add up: %v", l.DroppedByEvent())
+			}
+			if tt.wantDropped > 0 && !strings.Contains(out, "count=2") {
+				t.Errorf("summary must carry the count: %s", out)
+			}
+		})
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	// Файлы соседних получателей с тем же началом имени — не резервные копии.
+	others := []string{"app-access.log", "app-foo.log", "app-20260102T030405.000.x.log"}
+	for _, name := range others {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("other\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := OpenRotatingFile(path, RotateOptions{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := fmt.Fprintf(r, "line %d\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(path); string(b) != "line 3\n" {
+		t.Errorf("unexpected current file %q", b)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 compressed backups, got %v", backups)
+	}
+	f, err := os.Open(backups[len(backups)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != "line 2\n" {
+		t.Errorf("unexpected newest backup %q", b)
+	}
+	for _, name := range others {
+		if b, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(b) != "other\n" {
+			t.Errorf("unrelated file %s must be left alone: %q, %v", name, b, err)
+		}
+	}
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 1, 2, 23, 59, 0, 0, time.UTC)
+	r, err := OpenRotatingFile(path, RotateOptions{Interval: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.now = func() time.Time { return now }
+	r.next = now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	_, _ = r.Write([]byte("before midnight\n"))
+	now = now.Add(2 * time.Minute)
+	_, _ = r.Write([]byte("after midnight\n"))
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-20260103T000100.000.log"))
+	if len(backups) != 1 {
+		t.Fatalf("expected a backup at the day boundary, got %v", backups)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "after midnight\n" {
+		t.Errorf("unexpected current file %q", b)
+	}
+}
+
+func TestRotatingFile_WriteErrorAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := OpenRotatingFile(path, RotateOptions{RetryAfter: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// Закрытый дескриптор ведет себя как заполненный диск: запись падает.
+	r.mu.Lock()
+	_ = r.f.Close()
+	r.mu.Unlock()
+	if _, err := r.Write([]byte("lost\n")); err == nil || errors.Is(err, ErrSinkSuspended) {
+		t.Fatalf("expected the write error to be reported once, got %v", err)
+	}
+	if _, err := r.Write([]byte("lost\n")); !errors.Is(err, ErrSinkSuspended) {
+		t.Fatalf("expected writes to be suspended, got %v", err)
+	}
+	if r.Failed() != 2 {
+		t.Errorf("expected 2 failed writes, got %d", r.Failed())
+	}
+
+	// Внешний logrotate переименовал файл и прислал SIGHUP.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("after reopen\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "after reopen\n" {
+		t.Errorf("unexpected reopened file %q", b)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	rules, err := ParseRedaction(`default;hash:path=user.name;drop:path=items.*.card;mask:regex=\d{4}-\d{4}-\d{4}-\d{4}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRedactor(rules, "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]any{
+		"path":  "/tasks",
+		"query": "status=done&access_token=abc&Api-Key=k",
+		"Query": "next=/home&token=abc&cb=http://x",
+		"url":   "https://hooks.example.com/x?token=t&a=1#frag",
+		"user":  map[string]any{"name": "Alice", "Email": "alice@example.com"},
+		"items": []any{map[string]any{"card": "1", "id": "i1"}},
+		"headers": map[string]string{
+			"Authorization": "Bearer xyz",
+			"X-Note":        "paid with 1111-2222-3333-4444",
+		},
+	}
+	got := r.Redact(Entry{Data: data, Error: "upstream said: Bearer abc.def"})
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"plain path", got.Data["path"], "/tasks"},
+		{"query", got.Data["query"], "status=done&access_token=***&Api-Key=***"},
+		{"query with slashes", got.Data["Query"], "next=/home&token=***&cb=http://x"},
+		{"url", got.Data["url"], "https://hooks.example.com/x?token=***&a=1#frag"},
+		{"hash by path", got.Data["user"].(map[string]any)["name"], r.hash("Alice")},
+		{"hash by key", got.Data["user"].(map[string]any)["Email"], r.hash("alice@example.com")},
+		{"drop by path", fmt.Sprint(got.Data["items"]), "[map[id:i1]]"},
+		{"mask by key", got.Data["headers"].(map[string]any)["Authorization"], "***"},
+		{"regex", got.Data["headers"].(map[string]any)["X-Note"], "paid with ***"},
+		{"error", got.Error, "upstream said: ***"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, tt.got)
+			}
+		})
+	}
+	if data["user"].(map[string]any)["name"] != "Alice" {
+		t.Error("the caller's data must not be modified")
+	}
+	if !strings.HasPrefix(r.hash("Alice"), "sha256:") || r.hash("Alice") == r.hash("Bob") {
+		t.Errorf("unexpected hash %q", r.hash("Alice"))
+	}
+
+	// Без salt хеш не должен быть простым SHA-256 значения, иначе его
+	// восстанавливает перебор; ключ процесса стабилен между Redactor.
+	hashRules, _ := ParseRedaction("hash:key=email")
+	unsalted, _ := NewRedactor(hashRules, "")
+	again, _ := NewRedactor(hashRules, "")
+	plain, _ := NewRedactor(hashRules, "x")
+	plain.salt = nil
+	if h := unsalted.hash("Alice"); h == plain.hash("Alice") || h != again.hash("Alice") {
+		t.Errorf("expected a stable per-process salt, got %q", h)
+	}
+
+	for _, spec := range []string{"mask", "blur:key=a", "mask:color=a", "mask:regex=("} {
+		rules, err := ParseRedaction(spec)
+		if err == nil {
+			_, err = NewRedactor(rules, "")
+		}
+		if err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestAsync_RedactsBeforeSpill(t *testing.T) {
+	rules, _ := ParseRedaction("")
+	r, _ := NewRedactor(rules, "")
+	w := &blockingWriter{gate: make(chan struct{})}
+	spill := filepath.Join(t.TempDir(), "overflow.jsonl")
+	l := newAsync(t, Options{
+		Buffer:    1,
+		Sinks:     []Sink{{Name: "w", Out: w, Level: LevelDebug, Format: FormatJSON}},
+		Policy:    PolicySpill,
+		SpillPath: spill,
+		Redactor:  r,
+	})
+	l.Log(Entry{Event: "e0"})
+	for l.QueueLen() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 1; i <= 3; i++ {
+		l.Log(Entry{Event: "login", Data: map[string]any{"password": "hunter2"}})
+	}
+	b, err := os.ReadFile(spill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(w.gate)
+	l.Stop()
+	if strings.Contains(string(b), "hunter2") || strings.Contains(w.String(), "hunter2") {
+		t.Errorf("secret leaked: %s %s", b, w.String())
+	}
+	if !strings.Contains(w.String(), `"password":"***"`) {
+		t.Errorf("expected masked password: %s", w.String())
+	}
+}
+
+func TestDropWindow(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name        string
+		dropsAt     []int // секунды от start
+		checkAt     int
+		wantSeconds int
+		wantDropped uint64
+	}{
+		{"burst", []int{0, 0, 0, 0, 1}, 1, 2, 5},
+		{"sustained", []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20}, 20, 11, 11},
+		{"outside window", []int{0, 1, 2}, dropWindowSize + 2, 0, 0},
+		{"bucket reused", []int{0, dropWindowSize}, dropWindowSize, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w dropWindow
+			for _, s := range tt.dropsAt {
+				w.add(start.Add(time.Duration(s) * time.Second))
+			}
+			now := start.Add(time.Duration(tt.checkAt) * time.Second)
+			for i := 0; i < 2; i++ { // чтение не меняет окно
+				secs, n := w.stats(now)
+				if secs != tt.wantSeconds || n != tt.wantDropped {
+					t.Fatalf("expected %d seconds and %d drops, got %d and %d", tt.wantSeconds, tt.wantDropped, secs, n)
+				}
+			}
+		})
+	}
+}
+
+func TestSpillFile_DrainsInChunks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overflow.jsonl")
+	s, err := openSpill(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+	// Больше одной порции и одна строка длиннее порции.
+	for i := 0; i < 3*spillChunk/1024; i++ {
+		_ = s.put(Entry{Event: fmt.Sprintf("e%d", i), Error: strings.Repeat("x", 1000)})
+	}
+	_ = s.put(Entry{Event: "huge", Error: strings.Repeat("y", 2*spillChunk)})
+	// След сбоя посреди записи.
+	_, _ = s.f.WriteString(`{"event":"torn"`)
+	s.size += int64(len(`{"event":"torn"`))
+
+	first, n, err := s.next()
+	if err != nil || len(first) == 0 || n > spillChunk {
+		t.Fatalf("expected a bounded chunk, got %d entries in %d bytes, %v", len(first), n, err)
+	}
+	// Без advance порция не считается записанной и читается снова.
+	if again, _, _ := s.next(); len(again) != len(first) || again[0].Event != first[0].Event {
+		t.Fatalf("expected the same chunk before advance, got %d entries", len(again))
+	}
+
+	var events []string
+	for s.pending() {
+		entries, n, err := s.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			events = append(events, e.Event)
+		}
+		if err := s.advance(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(events) != 3*spillChunk/1024+1 || events[len(events)-1] != "huge" {
+		t.Errorf("unexpected drained entries: %d, last %q", len(events), events[len(events)-1])
+	}
+	if st, _ := os.Stat(path); st.Size() != 0 {
+		t.Errorf("expected the drained file to be truncated, size %d", st.Size())
+	}
+}
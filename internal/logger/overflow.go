@@ -0,0 +1,234 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Policy — что делать с записью, когда очередь логгера заполнена.
+type Policy string
+
+const (
+	// PolicyDropNewest отбрасывает новую запись; вызывающий не ждет.
+	PolicyDropNewest Policy = "drop_newest"
+	// PolicyDropOldest вытесняет самую старую запись из очереди.
+	PolicyDropOldest Policy = "drop_oldest"
+	// PolicyBlock ждет места в очереди не дольше BlockTimeout.
+	PolicyBlock Policy = "block"
+	// PolicySpill дописывает запись в файл переполнения; он вычитывается,
+	// когда очередь освобождается. Порядок записей при этом не сохраняется.
+	PolicySpill Policy = "spill"
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicyDropNewest, PolicyDropOldest, PolicyBlock, PolicySpill:
+		return p, nil
+	case "":
+		return PolicyDropNewest, nil
+	default:
+		return "", fmt.Errorf("unknown log overflow policy %q", s)
+	}
+}
+
+// dropCounter считает потерянные записи по событиям: всего и с момента
+// последней сводки.
+type dropCounter struct {
+	mu      sync.Mutex
+	total   map[string]uint64
+	pending map[string]uint64
+}
+
+func newDropCounter() *dropCounter {
+	return &dropCounter{total: make(map[string]uint64), pending: make(map[string]uint64)}
+}
+
+func (d *dropCounter) add(event string) {
+	d.mu.Lock()
+	d.total[event]++
+	d.pending[event]++
+	d.mu.Unlock()
+}
+
+func (d *dropCounter) snapshot() map[string]uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make(map[string]uint64, len(d.total))
+	for k, v := range d.total {
+		out[k] = v
+	}
+	return out
+}
+
+// flush возвращает потери с прошлой сводки и обнуляет их.
+func (d *dropCounter) flush() (uint64, map[string]any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.pending) == 0 {
+		return 0, nil
+	}
+	var n uint64
+	events := make(map[string]any, len(d.pending))
+	for k, v := range d.pending {
+		n += v
+		events[k] = v
+	}
+	d.pending = make(map[string]uint64)
+	return n, events
+}
+
+// Check считает логгер нездоровым, если записи терялись хотя бы в
+// dropSustained секундах из последних dropWindowSize.
+const (
+	dropWindowSize = 30
+	dropSustained  = 10
+)
+
+// dropWindow — потери по секундам за последние dropWindowSize секунд.
+type dropWindow struct {
+	mu      sync.Mutex
+	buckets [dropWindowSize]struct {
+		sec int64
+		n   uint64
+	}
+}
+
+func (w *dropWindow) add(now time.Time) {
+	sec := now.Unix()
+	w.mu.Lock()
+	b := &w.buckets[sec%dropWindowSize]
+	if b.sec != sec {
+		b.sec, b.n = sec, 0
+	}
+	b.n++
+	w.mu.Unlock()
+}
+
+// stats — сколько секунд окна были с потерями и сколько записей потеряно.
+func (w *dropWindow) stats(now time.Time) (seconds int, dropped uint64) {
+	sec := now.Unix()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, b := range w.buckets {
+		if b.n > 0 && b.sec > sec-dropWindowSize && b.sec <= sec {
+			seconds++
+			dropped += b.n
+		}
+	}
+	return seconds, dropped
+}
+
+// spillChunk — сколько байт файла переполнения читается за раз.
+const spillChunk = 256 << 10
+
+// spillFile — файл переполнения: JSON-строки, которые не поместились в
+// очередь. Размер ограничен, чтобы переполнение не заняло весь диск.
+// Записи вычитываются с позиции off порциями; файл обнуляется, только когда
+// все прочитанное уже записано.
+type spillFile struct {
+	mu       sync.Mutex
+	path     string
+	f        *os.File
+	size     int64
+	off      int64
+	maxBytes int64
+}
+
+func openSpill(path string, maxBytes int64) (*spillFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &spillFile{path: path, f: f, size: st.Size(), maxBytes: maxBytes}, nil
+}
+
+func (s *spillFile) put(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxBytes > 0 && s.size+int64(len(b)) > s.maxBytes {
+		return fmt.Errorf("overflow file %s is full", s.path)
+	}
+	n, err := s.f.Write(b)
+	s.size += int64(n)
+	return err
+}
+
+func (s *spillFile) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size > s.off
+}
+
+// next читает очередную порцию целых строк, не сдвигая позицию: после
+// записи вызывающий подтверждает ее через advance(n). Строка длиннее
+// spillChunk читается целиком.
+func (s *spillFile) next() (entries []Entry, n int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rest := s.size - s.off
+	if rest == 0 {
+		return nil, 0, nil
+	}
+	var data []byte
+	for limit := int64(spillChunk); ; limit *= 2 {
+		data, err = io.ReadAll(io.NewSectionReader(s.f, s.off, min(limit, rest)))
+		if err != nil {
+			return nil, 0, err
+		}
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[:i+1]
+			break
+		}
+		// Хвост без перевода строки — след сбоя посреди записи.
+		if int64(len(data)) == rest {
+			break
+		}
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		var e Entry
+		if len(line) > 0 && json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, int64(len(data)), nil
+}
+
+// advance отмечает n байт как записанные; дочитанный файл обнуляется.
+func (s *spillFile) advance(n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.off += n
+	if s.off < s.size {
+		return nil
+	}
+	if err := s.f.Truncate(0); err != nil {
+		return err
+	}
+	s.size, s.off = 0, 0
+	return nil
+}
+
+func (s *spillFile) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
@@ -0,0 +1,332 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Strategy — чем заменить чувствительное значение.
+type Strategy string
+
+const (
+	// StrategyMask заменяет значение на "***".
+	StrategyMask Strategy = "mask"
+	// StrategyHash заменяет значение на короткий HMAC-SHA256: по нему можно
+	// сопоставить записи об одном пользователе, не раскрывая значение.
+	StrategyHash Strategy = "hash"
+	// StrategyDrop удаляет поле целиком; для регулярных выражений и
+	// параметров запроса работает как mask.
+	StrategyDrop Strategy = "drop"
+)
+
+const redactedMask = "***"
+
+// RedactRule — одно правило: ровно одно из Keys, Path и Pattern.
+type RedactRule struct {
+	// Keys — имена полей Data на любой глубине, без учета регистра и с
+	// "-" равным "_". Те же имена скрываются в параметрах запроса URL.
+	Keys []string
+	// Path — путь от корня Data через точку, "*" — любой ключ или элемент
+	// массива: "user.email", "items.*.token".
+	Path string
+	// Pattern ищется во всех строковых значениях и в Entry.Error.
+	Pattern  *regexp.Regexp
+	Strategy Strategy
+}
+
+// DefaultRedaction — правила, которые действуют, если ничего не задано.
+const DefaultRedaction = `mask:key=password|passwd|secret|token|access_token|refresh_token|api_key|apikey|authorization|cookie|set_cookie;` +
+	`hash:key=email;` +
+	`mask:regex=(?i)bearer\s+[a-z0-9._~+/=-]+`
+
+// URLFields — поля Data, в которых лежат URL, и QueryFields — поля со
+// строкой запроса без URL; в них скрываются значения параметров с
+// чувствительными именами.
+var (
+	URLFields   = []string{"url", "uri", "path", "referer", "target"}
+	QueryFields = []string{"query"}
+)
+
+// Redactor убирает чувствительные данные из записи до сериализации. Нулевой
+// и nil Redactor ничего не меняют.
+type Redactor struct {
+	keys     map[string]Strategy
+	paths    []pathRule
+	patterns []patternRule
+	salt     []byte
+}
+
+type pathRule struct {
+	parts    []string
+	strategy Strategy
+}
+
+type patternRule struct {
+	re       *regexp.Regexp
+	strategy Strategy
+}
+
+// processSalt — ключ HMAC на случай, когда salt не задан: без ключа
+// короткий хеш email или телефона восстанавливается перебором. Ключ один на
+// процесс, чтобы хеши не менялись при перечитывании конфигурации.
+var processSalt = sync.OnceValue(func() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+})
+
+// NewRedactor собирает правила; salt — ключ HMAC для StrategyHash. Пустой
+// salt заменяется случайным ключом процесса: хеши тогда сопоставимы только
+// в пределах одного запуска.
+func NewRedactor(rules []RedactRule, salt string) (*Redactor, error) {
+	r := &Redactor{keys: make(map[string]Strategy), salt: []byte(salt)}
+	for _, rule := range rules {
+		if rule.Strategy == StrategyHash && salt == "" {
+			r.salt = processSalt()
+		}
+		switch rule.Strategy {
+		case StrategyMask, StrategyHash, StrategyDrop:
+		default:
+			return nil, fmt.Errorf("unknown redaction strategy %q", rule.Strategy)
+		}
+		switch {
+		case len(rule.Keys) > 0 && rule.Path == "" && rule.Pattern == nil:
+			for _, k := range rule.Keys {
+				r.keys[normalizeKey(k)] = rule.Strategy
+			}
+		case rule.Path != "" && len(rule.Keys) == 0 && rule.Pattern == nil:
+			r.paths = append(r.paths, pathRule{parts: strings.Split(rule.Path, "."), strategy: rule.Strategy})
+		case rule.Pattern != nil && len(rule.Keys) == 0 && rule.Path == "":
+			r.patterns = append(r.patterns, patternRule{re: rule.Pattern, strategy: rule.Strategy})
+		default:
+			return nil, fmt.Errorf("redaction rule must set exactly one of keys, path or pattern")
+		}
+	}
+	return r, nil
+}
+
+// ParseRedaction разбирает правила, разделенные ";", в виде
+// стратегия:вид=значение:
+//
+//	mask:key=password|token;hash:path=user.email;mask:regex=\d{16}
+//
+// Пустая строка и "default" — DefaultRedaction, "none" — без правил.
+// "default" можно дополнить своими правилами: "default;hash:key=phone".
+func ParseRedaction(spec string) ([]RedactRule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "":
+		spec = DefaultRedaction
+	case "none":
+		return nil, nil
+	}
+	var out []RedactRule
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part == "default" {
+			rules, err := ParseRedaction(DefaultRedaction)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, rules...)
+			continue
+		}
+		strategy, rest, ok := strings.Cut(part, ":")
+		kind, value, ok2 := strings.Cut(rest, "=")
+		if !ok || !ok2 || value == "" {
+			return nil, fmt.Errorf("redaction rule %q: expected strategy:kind=value", part)
+		}
+		rule := RedactRule{Strategy: Strategy(strategy)}
+		switch kind {
+		case "key":
+			rule.Keys = strings.Split(value, "|")
+		case "path":
+			rule.Path = value
+		case "regex":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %q: %w", part, err)
+			}
+			rule.Pattern = re
+		default:
+			return nil, fmt.Errorf("redaction rule %q: unknown kind %q", part, kind)
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+func normalizeKey(k string) string {
+	return strings.ReplaceAll(strings.ToLower(k), "-", "_")
+}
+
+// Redact возвращает копию записи без чувствительных данных; Data исходной
+// записи не меняется, потому что вызывающий может держать ссылку на нее.
+func (r *Redactor) Redact(e Entry) Entry {
+	if r == nil || len(r.keys) == 0 && len(r.paths) == 0 && len(r.patterns) == 0 {
+		return e
+	}
+	if e.Data != nil {
+		data, _ := r.value(e.Data, nil, "").(map[string]any)
+		e.Data = data
+	}
+	if e.Error != "" {
+		e.Error = r.patternsIn(e.Error)
+	}
+	return e
+}
+
+// value обходит значение по пути path; key — имя поля, в котором оно лежит.
+func (r *Redactor) value(v any, path []string, key string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, child := range v {
+			childPath := append(path[:len(path):len(path)], k)
+			if s, ok := r.ruleFor(childPath, k); ok {
+				if s != StrategyDrop {
+					out[k] = r.apply(child, s)
+				}
+				continue
+			}
+			out[k] = r.value(child, childPath, k)
+		}
+		return out
+	case map[string]string:
+		m := make(map[string]any, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return r.value(m, path, key)
+	case []any:
+		out := make([]any, len(v))
+		for i, child := range v {
+			out[i] = r.value(child, append(path[:len(path):len(path)], fmt.Sprint(i)), key)
+		}
+		return out
+	case []string:
+		out := make([]any, len(v))
+		for i, s := range v {
+			out[i] = s
+		}
+		return r.value(out, path, key)
+	case string:
+		return r.str(v, key)
+	default:
+		return v
+	}
+}
+
+// ruleFor ищет правило для поля: сначала по пути, затем по имени.
+func (r *Redactor) ruleFor(path []string, key string) (Strategy, bool) {
+	for _, p := range r.paths {
+		if matchPath(p.parts, path) {
+			return p.strategy, true
+		}
+	}
+	s, ok := r.keys[normalizeKey(key)]
+	return s, ok
+}
+
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Redactor) apply(v any, s Strategy) any {
+	if s == StrategyHash {
+		return r.hash(fmt.Sprint(v))
+	}
+	return redactedMask
+}
+
+func (r *Redactor) hash(s string) string {
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(s))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func (r *Redactor) str(s, key string) string {
+	switch key = normalizeKey(key); {
+	case slices.Contains(URLFields, key):
+		s = r.ScrubURL(s)
+	case slices.Contains(QueryFields, key):
+		s = r.ScrubQuery(s)
+	}
+	return r.patternsIn(s)
+}
+
+func (r *Redactor) patternsIn(s string) string {
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(m string) string {
+			if p.strategy == StrategyHash {
+				return r.hash(m)
+			}
+			return redactedMask
+		})
+	}
+	return s
+}
+
+// ScrubURL скрывает значения параметров запроса с чувствительными именами
+// в URL ("/tasks?token=x"). Строка без "?" возвращается как есть.
+func (r *Redactor) ScrubURL(s string) string {
+	i := strings.IndexByte(s, '?')
+	if r == nil || len(r.keys) == 0 || i < 0 {
+		return s
+	}
+	query, fragment := s[i+1:], ""
+	if j := strings.IndexByte(query, '#'); j >= 0 {
+		query, fragment = query[:j], query[j:]
+	}
+	return s[:i+1] + r.ScrubQuery(query) + fragment
+}
+
+// ScrubQuery скрывает значения чувствительных параметров в строке запроса
+// ("token=x&next=/home"). Остальные параметры и их порядок не меняются.
+func (r *Redactor) ScrubQuery(query string) string {
+	if r == nil || len(r.keys) == 0 {
+		return query
+	}
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok {
+			continue
+		}
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		strategy, ok := r.keys[normalizeKey(name)]
+		if !ok {
+			continue
+		}
+		if strategy == StrategyHash {
+			if v, err := url.QueryUnescape(value); err == nil {
+				value = v
+			}
+			params[i] = p[:strings.IndexByte(p, '=')+1] + r.hash(value)
+		} else {
+			params[i] = p[:strings.IndexByte(p, '=')+1] + redactedMask
+		}
+	}
+	return strings.Join(params, "&")
+}
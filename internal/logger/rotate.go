@@ -0,0 +1,355 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSinkSuspended — получатель временно не пишет после ошибки диска; о
+// самой ошибке уже сообщено при первом сбое.
+var ErrSinkSuspended = errors.New("sink suspended after write error")
+
+// RotateOptions — правила ротации файла журнала.
+type RotateOptions struct {
+	// MaxSize — размер, после которого файл ротируется (0 — без ограничения).
+	MaxSize int64
+	// Interval — ротация по времени, границы выровнены по UTC (24h — в
+	// полночь). 0 — без ротации по времени.
+	Interval time.Duration
+	// MaxBackups и MaxAge ограничивают число и возраст старых файлов
+	// (0 — без ограничения).
+	MaxBackups int
+	MaxAge     time.Duration
+	// Compress сжимает старые файлы в gzip.
+	Compress bool
+	// RetryAfter — пауза после ошибки записи (например, диск заполнен), в
+	// течение которой записи отбрасываются, не трогая диск.
+	RetryAfter time.Duration
+}
+
+// RotatingFile — файл журнала с ротацией по размеру и времени. Старые
+// файлы называются base-20060102T150405.000.ext и при Compress сжимаются
+// в фоне.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	f         *os.File
+	size      int64
+	next      time.Time
+	partial   bool
+	failedAt  time.Time
+	failed    uint64
+	suspended bool
+
+	mill     chan struct{}
+	millDone chan struct{}
+}
+
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = 5 * time.Second
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &RotatingFile{
+		path:     path,
+		opts:     opts,
+		now:      time.Now,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.runMill(r.mill)
+	r.mill <- struct{}{}
+	return r, nil
+}
+
+// open вызывается под r.mu.
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, st.Size()
+	if r.opts.Interval > 0 {
+		r.next = r.now().UTC().Truncate(r.opts.Interval).Add(r.opts.Interval)
+	}
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.suspended {
+		if r.now().Sub(r.failedAt) < r.opts.RetryAfter {
+			r.failed++
+			return 0, ErrSinkSuspended
+		}
+		r.suspended = false
+	}
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, r.fail(err)
+		}
+	}
+	if r.needRotate(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, r.fail(err)
+		}
+	}
+	if r.partial {
+		// Прошлая запись оборвалась на середине строки.
+		if _, err := r.f.Write([]byte{'\n'}); err != nil {
+			return 0, r.fail(err)
+		}
+		r.size++
+		r.partial = false
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	if err != nil {
+		r.partial = n > 0
+		return n, r.fail(err)
+	}
+	return n, nil
+}
+
+// fail переводит файл в паузу: пока она идет, записи отбрасываются сразу,
+// а не упираются в заполненный диск. Старые файлы удаляются по правилам
+// хранения, чтобы освободить место.
+func (r *RotatingFile) fail(err error) error {
+	r.failed++
+	r.suspended = true
+	r.failedAt = r.now()
+	r.kickMill()
+	return fmt.Errorf("log file %s: %w", r.path, err)
+}
+
+// Failed — записей, не попавших в файл из-за ошибок.
+func (r *RotatingFile) Failed() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed
+}
+
+func (r *RotatingFile) needRotate(n int) bool {
+	if r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(n) > r.opts.MaxSize {
+		return true
+	}
+	return r.opts.Interval > 0 && !r.now().Before(r.next)
+}
+
+// rotate вызывается под r.mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		r.f = nil
+		return err
+	}
+	r.f = nil
+	if err := os.Rename(r.path, r.backupName()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.kickMill()
+	return nil
+}
+
+func (r *RotatingFile) backupName() string {
+	dir, base := filepath.Split(r.path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext)
+	stamp := r.now().UTC().Format(backupStamp)
+	name := filepath.Join(dir, prefix+"-"+stamp+ext)
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s-%s.%d%s", prefix, stamp, i, ext))
+	}
+	return name
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Reopen закрывает и заново открывает файл по тому же пути — для внешнего
+// logrotate, который переименовал файл и прислал SIGHUP.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f != nil {
+		_ = r.f.Close()
+		r.f = nil
+	}
+	r.suspended = false
+	if err := r.open(); err != nil {
+		return r.fail(err)
+	}
+	return nil
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.f != nil {
+		err = r.f.Close()
+		r.f = nil
+	}
+	mill := r.mill
+	r.mill = nil
+	r.mu.Unlock()
+	if mill != nil {
+		close(mill)
+		<-r.millDone
+	}
+	return err
+}
+
+// kickMill вызывается под r.mu.
+func (r *RotatingFile) kickMill() {
+	if r.mill == nil {
+		return
+	}
+	select {
+	case r.mill <- struct{}{}:
+	default:
+	}
+}
+
+// runMill сжимает и удаляет старые файлы вне пути записи.
+func (r *RotatingFile) runMill(kick <-chan struct{}) {
+	defer close(r.millDone)
+	for range kick {
+		backups := r.backups()
+		if r.opts.Compress {
+			for i, b := range backups {
+				if !strings.HasSuffix(b.path, ".gz") {
+					if err := compressFile(b.path); err == nil {
+						backups[i].path += ".gz"
+					}
+				}
+			}
+		}
+		for i, b := range backups {
+			tooMany := r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups
+			tooOld := r.opts.MaxAge > 0 && r.now().Sub(b.modTime) > r.opts.MaxAge
+			if tooMany || tooOld {
+				_ = os.Remove(b.path)
+			}
+		}
+	}
+}
+
+type backup struct {
+	path    string
+	modTime time.Time
+}
+
+// backups — старые файлы, новые первыми.
+func (r *RotatingFile) backups() []backup {
+	dir, base := filepath.Split(r.path)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var out []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !isBackupName(name, prefix, ext) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, backup{path: filepath.Join(dir, name), modTime: info.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].path > out[j].path })
+	return out
+}
+
+// backupStamp — метка времени в имени старого файла, см. backupName.
+const backupStamp = "20060102T150405.000"
+
+// isBackupName проверяет, что name — prefix-метка[.N]ext[.gz]. Другие файлы
+// с тем же началом имени (например, журнал соседнего получателя
+// app-access.log рядом с app.log) не трогаются.
+func isBackupName(name, prefix, ext string) bool {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return false
+	}
+	rest = strings.TrimSuffix(rest, ".gz")
+	if rest, ok = strings.CutSuffix(rest, ext); !ok || len(rest) < len(backupStamp) {
+		return false
+	}
+	if _, err := time.Parse(backupStamp, rest[:len(backupStamp)]); err != nil {
+		return false
+	}
+	tail := rest[len(backupStamp):]
+	if tail == "" {
+		return true
+	}
+	n, ok := strings.CutPrefix(tail, ".")
+	return ok && n != "" && strings.Trim(n, "0123456789") == ""
+}
+
+// compressFile сжимает файл рядом во временный .gz и только потом удаляет
+// исходный: при заполненном диске исходный файл остается целым.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	st, _ := src.Stat()
+	if st != nil {
+		_ = os.Chtimes(path+".gz", st.ModTime(), st.ModTime())
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sampling ограничивает шумное событие: в каждом окне Tick пишутся первые
+// First записей, дальше — каждая Thereafter-я (0 — ни одной).
+type Sampling struct {
+	First      int
+	Thereafter int
+	Tick       time.Duration
+}
+
+type sampleState struct {
+	start time.Time
+	count int
+}
+
+// sampler решает по имени события; warn и error не отбрасываются никогда.
+type sampler struct {
+	rules map[string]Sampling
+	now   func() time.Time
+
+	mu    sync.Mutex
+	state map[string]*sampleState
+}
+
+func newSampler(rules map[string]Sampling) *sampler {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &sampler{rules: rules, now: time.Now, state: make(map[string]*sampleState)}
+}
+
+func (s *sampler) keep(e Entry) bool {
+	if s == nil || e.Level >= LevelWarn {
+		return true
+	}
+	rule, ok := s.rules[e.Event]
+	if !ok {
+		return true
+	}
+	tick := rule.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state[e.Event]
+	if st == nil || now.Sub(st.start) >= tick {
+		st = &sampleState{start: now}
+		s.state[e.Event] = st
+	}
+	st.count++
+	if st.count <= rule.First {
+		return true
+	}
+	return rule.Thereafter > 0 && (st.count-rule.First)%rule.Thereafter == 0
+}
+
+// ParseSampling разбирает правила вида "task_read=10:100,task_list=5:0":
+// первые 10 записей в секунду, затем каждая сотая.
+func ParseSampling(spec string) (map[string]Sampling, error) {
+	rules := make(map[string]Sampling)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		event, value, ok := strings.Cut(part, "=")
+		first, thereafter, ok2 := strings.Cut(value, ":")
+		if !ok || !ok2 || event == "" {
+			return nil, fmt.Errorf("log sampling %q: expected event=first:thereafter", part)
+		}
+		f, err1 := strconv.Atoi(first)
+		t, err2 := strconv.Atoi(thereafter)
+		if err1 != nil || err2 != nil || f < 0 || t < 0 {
+			return nil, fmt.Errorf("log sampling %q: counts must be non-negative integers", part)
+		}
+		rules[event] = Sampling{First: f, Thereafter: t, Tick: time.Second}
+	}
+	return rules, nil
+}
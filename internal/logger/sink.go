@@ -0,0 +1,329 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	// FormatText — строка для человека: время, уровень, событие и пары
+	// ключ=значение.
+	FormatText Format = "text"
+)
+
+// Sink — один получатель записей со своим минимальным уровнем и форматом.
+type Sink struct {
+	Name   string
+	Out    io.Writer
+	Level  Level
+	Format Format
+}
+
+// LevelWriter — получатель, которому нужен уровень записи, например syslog
+// для поля priority.
+type LevelWriter interface {
+	WriteLevel(l Level, line []byte) error
+}
+
+func (s Sink) write(e Entry) error {
+	var (
+		line []byte
+		err  error
+	)
+	if s.Format == FormatText {
+		line = encodeText(e)
+	} else {
+		line, err = json.Marshal(e)
+		if err != nil {
+			return err
+		}
+	}
+	if lw, ok := s.Out.(LevelWriter); ok {
+		return lw.WriteLevel(e.Level, line)
+	}
+	_, err = s.Out.Write(append(line, '\n'))
+	return err
+}
+
+func encodeText(e Entry) []byte {
+	var b bytes.Buffer
+	b.WriteString(e.Time.UTC().Format(time.RFC3339Nano))
+	b.WriteByte(' ')
+	b.WriteString(strings.ToUpper(e.Level.String()))
+	b.WriteByte(' ')
+	b.WriteString(e.Event)
+	pair := func(k string, v any) {
+		b.WriteByte(' ')
+		b.WriteString(k)
+		b.WriteByte('=')
+		s := fmt.Sprint(v)
+		if s == "" || strings.ContainsAny(s, " \"=\n\t") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(s)
+	}
+	if e.RequestID != "" {
+		pair("request_id", e.RequestID)
+	}
+	if e.TraceID != "" {
+		pair("trace_id", e.TraceID)
+		pair("span_id", e.SpanID)
+	}
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		pair(k, e.Data[k])
+	}
+	if e.Error != "" {
+		pair("error", e.Error)
+	}
+	return b.Bytes()
+}
+
+// SinkConfig — описание получателя из конфигурации.
+type SinkConfig struct {
+	Kind   string // stdout, stderr, file, syslog
+	Path   string // файл или unix-сокет
+	Level  Level
+	Format Format
+	// Rotate — ротация для вида file.
+	Rotate RotateOptions
+}
+
+// ParseSinks разбирает список получателей, разделенных ";". Каждый — вид и
+// параметры через запятую:
+//
+//	stdout;file,path=/var/log/taskapi.log,format=text,level=debug;syslog,path=/dev/log,level=warn
+//
+// Для file доступна ротация: max_size=100MB, rotate=24h, max_backups=7,
+// max_age=720h, compress=true.
+func ParseSinks(spec string) ([]SinkConfig, error) {
+	var out []SinkConfig
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ",")
+		sc := SinkConfig{Kind: strings.TrimSpace(fields[0]), Format: FormatJSON, Level: LevelDebug}
+		for _, f := range fields[1:] {
+			k, v, ok := strings.Cut(strings.TrimSpace(f), "=")
+			if !ok {
+				return nil, fmt.Errorf("log sink %q: expected key=value, got %q", sc.Kind, f)
+			}
+			switch k {
+			case "path":
+				sc.Path = v
+			case "format":
+				sc.Format = Format(v)
+			case "level":
+				l, err := ParseLevel(v)
+				if err != nil {
+					return nil, fmt.Errorf("log sink %q: %w", sc.Kind, err)
+				}
+				sc.Level = l
+			case "max_size":
+				n, err := parseSize(v)
+				if err != nil {
+					return nil, fmt.Errorf("log sink %q: max_size: %w", sc.Kind, err)
+				}
+				sc.Rotate.MaxSize = n
+			case "rotate", "max_age":
+				d, err := time.ParseDuration(v)
+				if err != nil || d < 0 {
+					return nil, fmt.Errorf("log sink %q: %s: invalid duration %q", sc.Kind, k, v)
+				}
+				if k == "rotate" {
+					sc.Rotate.Interval = d
+				} else {
+					sc.Rotate.MaxAge = d
+				}
+			case "max_backups":
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("log sink %q: max_backups: invalid number %q", sc.Kind, v)
+				}
+				sc.Rotate.MaxBackups = n
+			case "compress":
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, fmt.Errorf("log sink %q: compress: invalid bool %q", sc.Kind, v)
+				}
+				sc.Rotate.Compress = b
+			default:
+				return nil, fmt.Errorf("log sink %q: unknown option %q", sc.Kind, k)
+			}
+		}
+		if err := sc.validate(); err != nil {
+			return nil, err
+		}
+		out = append(out, sc)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no log sinks configured")
+	}
+	return out, nil
+}
+
+func (sc SinkConfig) validate() error {
+	switch sc.Kind {
+	case "stdout", "stderr":
+	case "file", "syslog":
+		if sc.Path == "" {
+			return fmt.Errorf("log sink %q: path is required", sc.Kind)
+		}
+	default:
+		return fmt.Errorf("unknown log sink %q", sc.Kind)
+	}
+	if sc.Format != FormatJSON && sc.Format != FormatText {
+		return fmt.Errorf("log sink %q: unknown format %q", sc.Kind, sc.Format)
+	}
+	if sc.Kind != "file" && sc.Rotate != (RotateOptions{}) {
+		return fmt.Errorf("log sink %q: rotation is only supported for files", sc.Kind)
+	}
+	return nil
+}
+
+// parseSize разбирает размер в байтах с необязательным суффиксом KB, MB, GB.
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	mult := int64(1)
+	num := strings.ToUpper(strings.TrimSpace(s))
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+// OpenSink открывает получателя. Closer нужно закрыть после Stop логгера;
+// для stdout и stderr он nil.
+func OpenSink(sc SinkConfig) (Sink, io.Closer, error) {
+	if err := sc.validate(); err != nil {
+		return Sink{}, nil, err
+	}
+	s := Sink{Name: sc.Kind, Level: sc.Level, Format: sc.Format}
+	switch sc.Kind {
+	case "stdout":
+		s.Out = os.Stdout
+		return s, nil, nil
+	case "stderr":
+		s.Out = os.Stderr
+		return s, nil, nil
+	case "file":
+		f, err := OpenRotatingFile(sc.Path, sc.Rotate)
+		if err != nil {
+			return Sink{}, nil, err
+		}
+		s.Name, s.Out = "file:"+sc.Path, f
+		return s, f, nil
+	default:
+		w := NewSyslogWriter(sc.Path, "taskapi")
+		s.Name, s.Out = "syslog:"+sc.Path, w
+		return s, w, nil
+	}
+}
+
+// SyslogWriter пишет записи в unix-сокет syslog (обычно /dev/log) в формате
+// RFC 3164: <priority>время тег[pid]: сообщение. Соединение открывается при
+// первой записи и переоткрывается после ошибки.
+type SyslogWriter struct {
+	addr string
+	tag  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func NewSyslogWriter(addr, tag string) *SyslogWriter {
+	return &SyslogWriter{addr: addr, tag: tag}
+}
+
+// facility user (1).
+const syslogFacility = 1 << 3
+
+func syslogSeverity(l Level) int {
+	switch {
+	case l >= LevelError:
+		return 3
+	case l >= LevelWarn:
+		return 4
+	case l >= LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+func (w *SyslogWriter) WriteLevel(l Level, line []byte) error {
+	msg := fmt.Sprintf("<%d>%s %s[%d]: %s\n", syslogFacility|syslogSeverity(l),
+		time.Now().Format(time.Stamp), w.tag, os.Getpid(), line)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			conn, err := dialSyslog(w.addr)
+			if err != nil {
+				return err
+			}
+			w.conn = conn
+		}
+		if _, err := io.WriteString(w.conn, msg); err == nil {
+			return nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	return fmt.Errorf("syslog %s: write failed", w.addr)
+}
+
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	if err := w.WriteLevel(LevelInfo, bytes.TrimRight(p, "\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// dialSyslog пробует датаграммный сокет, как у /dev/log, затем потоковый.
+func dialSyslog(addr string) (net.Conn, error) {
+	conn, err := net.Dial("unixgram", addr)
+	if err == nil {
+		return conn, nil
+	}
+	return net.Dial("unix", addr)
+}
@@ -0,0 +1,323 @@
+// Package metrics — минимальный реестр метрик с выводом в текстовом
+// формате Prometheus (exposition format 0.0.4).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets — границы гистограммы задержек в секундах.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// WriteText пишет все метрики в порядке регистрации.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
+
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+}
+
+// vec хранит дочерние метрики по значениям меток.
+type vec[T any] struct {
+	desc
+	mu       sync.RWMutex
+	children map[string]*T
+	values   map[string][]string
+	newChild func() *T
+}
+
+func (v *vec[T]) with(lvs []string) *T {
+	if len(lvs) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(lvs)))
+	}
+	key := strings.Join(lvs, "\xff")
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok {
+		return c
+	}
+	c = v.newChild()
+	v.children[key] = c
+	v.values[key] = append([]string(nil), lvs...)
+	return c
+}
+
+// each обходит дочерние метрики в порядке значений меток.
+func (v *vec[T]) each(fn func(lvs []string, c *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for k := range v.children {
+		keys = append(keys, k)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+	for _, k := range keys {
+		v.mu.RLock()
+		c, lvs := v.children[k], v.values[k]
+		v.mu.RUnlock()
+		fn(lvs, c)
+	}
+}
+
+// Counter — монотонно растущее значение.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add увеличивает счетчик; отрицательные значения игнорируются.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	for {
+		old := c.bits.Load()
+		if c.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (c *Counter) Value() float64 { return math.Float64frombits(c.bits.Load()) }
+
+type CounterVec struct {
+	v *vec[Counter]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{v: &vec[Counter]{
+		desc:     desc{name: name, help: help, typ: "counter", labels: labels},
+		children: make(map[string]*Counter),
+		values:   make(map[string][]string),
+		newChild: func() *Counter { return &Counter{} },
+	}}
+	r.register(name, cv)
+	return cv
+}
+
+func (cv *CounterVec) With(lvs ...string) *Counter { return cv.v.with(lvs) }
+
+func (cv *CounterVec) write(w *bufio.Writer) {
+	cv.v.header(w)
+	cv.v.each(func(lvs []string, c *Counter) {
+		sample(w, cv.v.name, cv.v.labels, lvs, "", "", c.Value())
+	})
+}
+
+// Histogram считает наблюдения по корзинам.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+type HistogramVec struct {
+	v *vec[Histogram]
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	hv := &HistogramVec{v: &vec[Histogram]{
+		desc:     desc{name: name, help: help, typ: "histogram", labels: labels},
+		children: make(map[string]*Histogram),
+		values:   make(map[string][]string),
+		newChild: func() *Histogram {
+			return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+		},
+	}}
+	r.register(name, hv)
+	return hv
+}
+
+func (hv *HistogramVec) With(lvs ...string) *Histogram { return hv.v.with(lvs) }
+
+func (hv *HistogramVec) write(w *bufio.Writer) {
+	hv.v.header(w)
+	hv.v.each(func(lvs []string, h *Histogram) {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+		var cum uint64
+		for i, b := range h.buckets {
+			cum += counts[i]
+			sample(w, hv.v.name+"_bucket", hv.v.labels, lvs, "le", formatFloat(b), float64(cum))
+		}
+		sample(w, hv.v.name+"_bucket", hv.v.labels, lvs, "le", "+Inf", float64(count))
+		sample(w, hv.v.name+"_sum", hv.v.labels, lvs, "", "", sum)
+		sample(w, hv.v.name+"_count", hv.v.labels, lvs, "", "", float64(count))
+	})
+}
+
+// funcFamily вычисляет значения в момент сбора: для гауг вроде глубины
+// очереди и для счетчиков, которые ведет сам компонент.
+type funcFamily struct {
+	desc
+	fn func(emit func(v float64, lvs ...string))
+}
+
+func (f *funcFamily) write(w *bufio.Writer) {
+	f.header(w)
+	f.fn(func(v float64, lvs ...string) {
+		sample(w, f.name, f.labels, lvs, "", "", v)
+	})
+}
+
+// GaugeFunc регистрирует гаугу без меток.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &funcFamily{
+		desc: desc{name: name, help: help, typ: "gauge"},
+		fn:   func(emit func(float64, ...string)) { emit(fn()) },
+	})
+}
+
+// CounterFunc регистрирует счетчик, значение которого хранит компонент.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(name, &funcFamily{
+		desc: desc{name: name, help: help, typ: "counter"},
+		fn:   func(emit func(float64, ...string)) { emit(fn()) },
+	})
+}
+
+// CounterVecFunc — CounterFunc с метками.
+func (r *Registry) CounterVecFunc(name, help string, labels []string, fn func(emit func(v float64, lvs ...string))) {
+	r.register(name, &funcFamily{
+		desc: desc{name: name, help: help, typ: "counter", labels: labels},
+		fn:   fn,
+	})
+}
+
+// GaugeVecFunc регистрирует гаугу с метками: fn вызывает emit для каждого
+// набора значений меток.
+func (r *Registry) GaugeVecFunc(name, help string, labels []string, fn func(emit func(v float64, lvs ...string))) {
+	r.register(name, &funcFamily{
+		desc: desc{name: name, help: help, typ: "gauge", labels: labels},
+		fn:   fn,
+	})
+}
+
+func sample(w *bufio.Writer, name string, labels, lvs []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			value := ""
+			if i < len(lvs) {
+				value = lvs[i]
+			}
+			fmt.Fprintf(w, `%s="%s"`, l, escapeLabel(value))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,85 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/logger"
+	"taskapi/internal/metrics"
+	"taskapi/internal/repository/memory"
+	"taskapi/internal/usecase"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounterVec("requests_total", "Requests\nserved.", "path")
+	c.With(`/a"b`).Inc()
+	c.With("/z").Add(2.5)
+	c.With("/z").Add(-1)
+	h := r.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "op")
+	h.With("get").Observe(0.05)
+	h.With("get").Observe(0.5)
+	h.With("get").Observe(3)
+	r.GaugeFunc("up", "Up.", func() float64 { return 1 })
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP requests_total Requests\nserved.
+# TYPE requests_total counter
+requests_total{path="/a\"b"} 1
+requests_total{path="/z"} 2.5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.1"} 1
+latency_seconds_bucket{op="get",le="1"} 2
+latency_seconds_bucket{op="get",le="+Inf"} 3
+latency_seconds_sum{op="get"} 3.55
+latency_seconds_count{op="get"} 3
+# HELP up Up.
+# TYPE up gauge
+up 1
+`
+	if b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(logger.Entry)          {}
+func (nopLogger) Enabled(logger.Level) bool { return true }
+func (nopLogger) Stop()                     {}
+
+func TestInstrumentService(t *testing.T) {
+	r := metrics.NewRegistry()
+	repo := memory.New()
+	svc := metrics.InstrumentService(usecase.NewService(repo, nopLogger{}), r)
+	metrics.RegisterTasks(r, repo)
+	ctx := context.Background()
+
+	if _, err := svc.Create(ctx, dto.CreateInput{Title: "T", Status: domain.StatusDone}); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = svc.Get(ctx, "missing")
+	_, _ = svc.Create(ctx, dto.CreateInput{})
+
+	var b strings.Builder
+	_ = r.WriteText(&b)
+	for _, want := range []string{
+		`taskapi_usecase_operations_total{operation="create",result="ok"} 1`,
+		`taskapi_usecase_operations_total{operation="get",result="not_found"} 1`,
+		`taskapi_usecase_operations_total{operation="create",result="bad_request"} 1`,
+		`taskapi_usecase_operation_duration_seconds_count{operation="create"} 2`,
+		`taskapi_tasks{status="done"} 1`,
+		`taskapi_tasks{status="todo"} 0`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output is missing %s\n%s", want, b.String())
+		}
+	}
+}
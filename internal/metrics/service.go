@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/repository"
+	"taskapi/internal/usecase"
+	"time"
+)
+
+// service считает вызовы и ошибки операций usecase.TaskService.
+type service struct {
+	next     usecase.TaskService
+	ops      *CounterVec
+	duration *HistogramVec
+}
+
+// InstrumentService оборачивает сервис метриками
+// taskapi_usecase_operations_total{operation,result} и
+// taskapi_usecase_operation_duration_seconds{operation}.
+func InstrumentService(next usecase.TaskService, r *Registry) usecase.TaskService {
+	return &service{
+		next: next,
+		ops: r.NewCounterVec("taskapi_usecase_operations_total",
+			"Вызовы операций сервиса задач по результату.", "operation", "result"),
+		duration: r.NewHistogramVec("taskapi_usecase_operation_duration_seconds",
+			"Длительность операций сервиса задач.", nil, "operation"),
+	}
+}
+
+func (s *service) observe(op string, start time.Time, err error) {
+	s.duration.With(op).Observe(time.Since(start).Seconds())
+	s.ops.With(op, result(err)).Inc()
+}
+
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, usecase.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, usecase.ErrBadRequest):
+		return "bad_request"
+	default:
+		return "error"
+	}
+}
+
+func (s *service) Create(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+	start := time.Now()
+	t, err := s.next.Create(ctx, in)
+	s.observe("create", start, err)
+	return t, err
+}
+
+func (s *service) Get(ctx context.Context, id string) (domain.Task, error) {
+	start := time.Now()
+	t, err := s.next.Get(ctx, id)
+	s.observe("get", start, err)
+	return t, err
+}
+
+func (s *service) List(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+	start := time.Now()
+	list, err := s.next.List(ctx, f)
+	s.observe("list", start, err)
+	return list, err
+}
+
+func (s *service) Update(ctx context.Context, id string, in dto.UpdateInput) (domain.Task, error) {
+	start := time.Now()
+	t, err := s.next.Update(ctx, id, in)
+	s.observe("update", start, err)
+	return t, err
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := s.next.Delete(ctx, id)
+	s.observe("delete", start, err)
+	return err
+}
+
+func (s *service) Revision(ctx context.Context) (domain.Revision, error) {
+	start := time.Now()
+	rev, err := s.next.Revision(ctx)
+	s.observe("revision", start, err)
+	return rev, err
+}
+
+func (s *service) AddComment(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error) {
+	start := time.Now()
+	c, err := s.next.AddComment(ctx, taskID, in)
+	s.observe("add_comment", start, err)
+	return c, err
+}
+
+func (s *service) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	start := time.Now()
+	list, err := s.next.ListComments(ctx, taskIDs)
+	s.observe("list_comments", start, err)
+	return list, err
+}
+
+// RegisterTasks добавляет taskapi_tasks{status}: число задач в хранилище,
+// считается при каждом сборе метрик.
+func RegisterTasks(r *Registry, repo repository.TaskRepository) {
+	statuses := []domain.Status{domain.StatusTodo, domain.StatusInProgress, domain.StatusDone}
+	r.GaugeVecFunc("taskapi_tasks", "Число задач по статусу.", []string{"status"}, func(emit func(float64, ...string)) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tasks, err := repo.List(ctx, repository.Filter{})
+		if err != nil {
+			return
+		}
+		counts := make(map[domain.Status]int, len(statuses))
+		for _, t := range tasks {
+			counts[t.Status]++
+		}
+		for _, st := range statuses {
+			emit(float64(counts[st]), string(st))
+		}
+	})
+}
+
+// QueueStats — то, что логгер сообщает о своей очереди.
+type QueueStats interface {
+	QueueLen() int
+	QueueCap() int
+	DroppedByEvent() map[string]uint64
+	Spilled() uint64
+}
+
+func RegisterLogger(r *Registry, l QueueStats) {
+	r.GaugeFunc("taskapi_logger_queue_depth", "Записей в очереди асинхронного логгера.",
+		func() float64 { return float64(l.QueueLen()) })
+	r.GaugeFunc("taskapi_logger_queue_capacity", "Емкость очереди асинхронного логгера.",
+		func() float64 { return float64(l.QueueCap()) })
+	r.CounterVecFunc("taskapi_logger_dropped_entries_total", "Записи, отброшенные из-за переполненной очереди, по событию.",
+		[]string{"event"}, func(emit func(float64, ...string)) {
+			drops := l.DroppedByEvent()
+			events := make([]string, 0, len(drops))
+			for event := range drops {
+				events = append(events, event)
+			}
+			sort.Strings(events)
+			for _, event := range events {
+				emit(float64(drops[event]), event)
+			}
+		})
+	r.CounterFunc("taskapi_logger_spilled_entries_total", "Записи, ушедшие в файл переполнения.",
+		func() float64 { return float64(l.Spilled()) })
+}
+
+// WebhookStats — то, что диспетчер вебхуков сообщает о своей очереди.
+type WebhookStats interface {
+	QueueLen() int
+	QueueCap() int
+	Dropped() uint64
+}
+
+func RegisterWebhooks(r *Registry, d WebhookStats) {
+	r.GaugeFunc("taskapi_webhook_queue_depth", "Доставок в очереди вебхуков.",
+		func() float64 { return float64(d.QueueLen()) })
+	r.GaugeFunc("taskapi_webhook_queue_capacity", "Емкость очереди вебхуков.",
+		func() float64 { return float64(d.QueueCap()) })
+	r.CounterFunc("taskapi_webhook_dropped_total", "Доставки, отброшенные из-за переполненной очереди.",
+		func() float64 { return float64(d.Dropped()) })
+}
+
+func RegisterRuntime(r *Registry) {
+	r.GaugeFunc("go_goroutines", "Число горутин.", func() float64 { return float64(runtime.NumGoroutine()) })
+}
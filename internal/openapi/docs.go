@@ -0,0 +1,21 @@
+package openapi
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+)
+
+//go:embed docs.html
+var docsHTML string
+
+var docsTmpl = template.Must(template.New("docs").Parse(docsHTML))
+
+// DocsHandler отдает самодостаточную страницу документации: она загружает
+// спецификацию по specURL и позволяет отправлять запросы прямо из браузера.
+func DocsHandler(title, specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = docsTmpl.Execute(w, map[string]string{"Title": title, "SpecURL": specURL})
+	})
+}
@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FormValue переводит поля HTML-формы (application/x-www-form-urlencoded) в
+// JSON-объект по схеме тела операции: числа и логические значения
+// разбираются, массивы собираются из повторяющихся полей (events=a&events=b
+// или events[]=a). Значения, которые не разбираются, остаются строками —
+// о них сообщит ValidateRequest. Поля вне схемы передаются как есть.
+func (d *Document) FormValue(op *Operation, form url.Values) map[string]any {
+	v := &validator{doc: d}
+	var props map[string]*Schema
+	if op.RequestBody != nil {
+		if s := v.resolve(op.RequestBody.Content["application/json"].Schema); s != nil {
+			props = s.Properties
+		}
+	}
+	out := make(map[string]any, len(form))
+	// Ключи по порядку: events и events[] сливаются детерминированно.
+	for _, key := range slices.Sorted(maps.Keys(form)) {
+		vals := form[key]
+		name := strings.TrimSuffix(key, "[]")
+		s := v.formSchema(props[name])
+		if s != nil && hasType(s, "array") || key != name {
+			items := v.formSchema(s.items())
+			arr, _ := out[name].([]any)
+			for _, raw := range vals {
+				arr = append(arr, formScalar(items, raw))
+			}
+			out[name] = arr
+			continue
+		}
+		out[name] = formScalar(s, vals[0])
+	}
+	return out
+}
+
+// formSchema снимает $ref и nullable-обертку anyOf.
+func (v *validator) formSchema(s *Schema) *Schema {
+	s = v.resolve(s)
+	if s == nil {
+		return nil
+	}
+	for _, alt := range s.AnyOf {
+		if alt := v.resolve(alt); alt != nil && !hasType(alt, "null") {
+			return alt
+		}
+	}
+	return s
+}
+
+func (s *Schema) items() *Schema {
+	if s == nil {
+		return nil
+	}
+	return s.Items
+}
+
+func formScalar(s *Schema, raw string) any {
+	if s == nil {
+		return raw
+	}
+	switch {
+	case hasType(s, "integer"), hasType(s, "number"):
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+	case hasType(s, "boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
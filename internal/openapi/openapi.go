@@ -0,0 +1,220 @@
+// Package openapi описывает HTTP API документом OpenAPI 3.1: схемы тел
+// строятся по Go-типам, а тот же документ используется для проверки
+// входящих запросов.
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem — операции одного пути по методам в нижнем регистре.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Route — описание одного зарегистрированного маршрута. Path задается
+// в синтаксисе OpenAPI ({id}), он же совпадает с шаблонами http.ServeMux.
+type Route struct {
+	Method  string
+	Path    string
+	ID      string
+	Summary string
+	Tag     string
+	Query   []*Parameter
+	// Body — нулевое значение типа тела запроса; nil — тела нет.
+	Body any
+	// ContentType тела и ответов; по умолчанию application/json.
+	ContentType string
+	// Responses: код ответа → нулевое значение типа тела (nil — без тела).
+	Responses map[int]any
+	// Consumes и Produces — дополнительные к ContentType представления тела
+	// запроса и успешных ответов с той же схемой.
+	Consumes []string
+	Produces []string
+}
+
+// MediaTyper задает тип содержимого ответа независимо от Route.ContentType,
+// например application/problem+json для ошибок.
+type MediaTyper interface {
+	MediaType() string
+}
+
+// Build собирает документ по маршрутам; схемы типов складываются в
+// components генератора g.
+func Build(info Info, routes []Route, g *Generator) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	for _, rt := range routes {
+		item := doc.Paths[rt.Path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[rt.Path] = item
+		}
+		(*item)[strings.ToLower(rt.Method)] = g.operation(rt)
+	}
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+func (g *Generator) operation(rt Route) *Operation {
+	ct := rt.ContentType
+	if ct == "" {
+		ct = "application/json"
+	}
+	op := &Operation{
+		OperationID: rt.ID,
+		Summary:     rt.Summary,
+		Responses:   make(map[string]*Response),
+	}
+	if rt.Tag != "" {
+		op.Tags = []string{rt.Tag}
+	}
+	for _, name := range pathParams(rt.Path) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+	for _, p := range rt.Query {
+		q := *p
+		q.In = "query"
+		op.Parameters = append(op.Parameters, &q)
+	}
+	if rt.Body != nil {
+		body := MediaType{Schema: g.SchemaOf(rt.Body)}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": body},
+		}
+		for _, mt := range rt.Consumes {
+			op.RequestBody.Content[mt] = body
+		}
+	}
+
+	codes := make([]int, 0, len(rt.Responses))
+	for code := range rt.Responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		resp := &Response{Description: http.StatusText(code)}
+		if v := rt.Responses[code]; v != nil {
+			mt := ct
+			if m, ok := v.(MediaTyper); ok {
+				mt = m.MediaType()
+			}
+			resp.Content = map[string]MediaType{mt: {Schema: g.SchemaOf(v)}}
+			if code < 300 && mt == ct {
+				for _, extra := range rt.Produces {
+					resp.Content[extra] = resp.Content[mt]
+				}
+			}
+		}
+		op.Responses[strconv.Itoa(code)] = resp
+	}
+	return op
+}
+
+func pathParams(path string) []string {
+	var out []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			out = append(out, seg[1:len(seg)-1])
+		}
+	}
+	return out
+}
+
+// Find ищет операцию по методу и фактическому пути запроса.
+// HEAD обслуживается так же, как GET.
+func (d *Document) Find(method, path string) (*Operation, bool) {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	method = strings.ToLower(method)
+	if item, ok := d.Paths[path]; ok {
+		op, ok := (*item)[method]
+		return op, ok
+	}
+	segs := strings.Split(path, "/")
+	for tmpl, item := range d.Paths {
+		if !strings.Contains(tmpl, "{") || !matchPath(strings.Split(tmpl, "/"), segs) {
+			continue
+		}
+		if op, ok := (*item)[method]; ok {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+func matchPath(tmpl, segs []string) bool {
+	if len(tmpl) != len(segs) {
+		return false
+	}
+	for i, t := range tmpl {
+		if strings.HasPrefix(t, "{") {
+			if segs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if t != segs[i] {
+			return false
+		}
+	}
+	return true
+}
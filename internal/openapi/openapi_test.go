@@ -0,0 +1,142 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"taskapi/internal/openapi"
+)
+
+type color string
+
+type item struct {
+	Name    string    `json:"name" openapi:"required,minLength=1"`
+	Color   color     `json:"color"`
+	Link    *string   `json:"link" openapi:"format=uri"`
+	Tags    []string  `json:"tags,omitempty"`
+	Count   int       `json:"count" openapi:"minimum=0"`
+	Parent  *item     `json:"parent,omitempty"`
+	At      time.Time `json:"at"`
+	Ignored string    `json:"-"`
+}
+
+func testDoc() *openapi.Document {
+	g := openapi.NewGenerator()
+	g.Enum(reflect.TypeFor[color](), color("red"), color("green"))
+	return openapi.Build(openapi.Info{Title: "test", Version: "1"}, []openapi.Route{
+		{Method: "POST", Path: "/items", Body: item{}, Responses: map[int]any{201: item{}}},
+		{Method: "GET", Path: "/items/{id}", Query: []*openapi.Parameter{
+			{Name: "limit", Schema: &openapi.Schema{Type: "integer", Minimum: new(float64)}},
+			{Name: "color", Required: true, Schema: &openapi.Schema{Ref: "#/components/schemas/color"}},
+		}, Responses: map[int]any{200: item{}, 404: nil}},
+	}, g)
+}
+
+func TestBuild(t *testing.T) {
+	doc := testDoc()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"openapi":"3.1.0"`,
+		`"color":{"type":"string","enum":["red","green"]}`,
+		`"link":{"type":["string","null"],"format":"uri"}`,
+		`"parent":{"anyOf":[{"$ref":"#/components/schemas/item"},{"type":"null"}]}`,
+		`"at":{"type":"string","format":"date-time"}`,
+		`"required":["name"]`,
+		`{"name":"id","in":"path","required":true,"schema":{"type":"string"}}`,
+		`"404":{"description":"Not Found"}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected document to contain %s", want)
+		}
+	}
+	if strings.Contains(string(data), "Ignored") {
+		t.Error("json:\"-\" field must be skipped")
+	}
+
+	if _, ok := doc.Find("GET", "/items/42"); !ok {
+		t.Error("expected /items/{id} to match")
+	}
+	if _, ok := doc.Find("HEAD", "/items/42"); !ok {
+		t.Error("expected HEAD to match GET operation")
+	}
+	if _, ok := doc.Find("DELETE", "/items/42"); ok {
+		t.Error("unexpected match for DELETE")
+	}
+	if _, ok := doc.Find("GET", "/items/42/extra"); ok {
+		t.Error("unexpected match for longer path")
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc := testDoc()
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		wantErr []string
+	}{
+		{"valid body", "POST", "/items", `{"name":"a","color":"red","link":null,"count":1,"parent":{"name":"p"}}`, nil},
+		{"missing required", "POST", "/items", `{"color":"red"}`, []string{"body.name: is required"}},
+		{"empty string", "POST", "/items", `{"name":""}`, []string{"body.name: must be at least 1 characters"}},
+		{"enum", "POST", "/items", `{"name":"a","color":"blue"}`, []string{"body.color: must be one of red, green"}},
+		{"wrong type", "POST", "/items", `{"name":"a","count":"1","tags":[1]}`, []string{"body.tags[0]: must be string", "body.count: must be integer"}},
+		{"format and minimum", "POST", "/items", `{"name":"a","link":"nope","count":-1}`, []string{"body.link: must be an absolute URI", "body.count: must be >= 0"}},
+		{"nested", "POST", "/items", `{"name":"a","parent":{"color":"x"}}`, []string{"body.parent: does not match any allowed schema"}},
+		{"invalid JSON", "POST", "/items", `{"name":`, []string{"body: invalid JSON"}},
+		{"empty body", "POST", "/items", ``, []string{"body: is required"}},
+		{"valid query", "GET", "/items/1?color=red&limit=5", ``, nil},
+		{"query errors", "GET", "/items/1?limit=x", ``, []string{"query.color: is required", "query.limit: must be an integer"}},
+		{"query minimum", "GET", "/items/1?color=green&limit=-2", ``, []string{"query.limit: must be >= 0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			op, ok := doc.Find(r.Method, r.URL.Path)
+			if !ok {
+				t.Fatal("operation not found")
+			}
+			err := doc.ValidateRequest(op, r)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			verr, ok := err.(*openapi.ValidationError)
+			if !ok {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			for _, want := range tt.wantErr {
+				found := false
+				for _, got := range verr.Errors {
+					found = found || got == want
+				}
+				if !found {
+					t.Errorf("expected error %q, got %v", want, verr.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRequest_KeepsBody(t *testing.T) {
+	doc := testDoc()
+	r := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"a"}`))
+	op, _ := doc.Find(r.Method, r.URL.Path)
+	if err := doc.ValidateRequest(op, r); err != nil {
+		t.Fatal(err)
+	}
+	var got item
+	if err := json.NewDecoder(r.Body).Decode(&got); err != nil || got.Name != "a" {
+		t.Fatalf("body not restored: %v %+v", err, got)
+	}
+}
@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema — подмножество JSON Schema 2020-12, которое нужно API.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+
+	// order — порядок свойств как в структуре, для сообщений об ошибках.
+	order []string
+}
+
+// any — схема без ограничений, ей соответствует любое значение.
+func (s *Schema) any() bool {
+	return s == nil || s.Ref == "" && s.Type == nil && s.Enum == nil && s.Properties == nil &&
+		s.Items == nil && s.AnyOf == nil && s.Format == ""
+}
+
+var (
+	timeType = reflect.TypeFor[time.Time]()
+	rawType  = reflect.TypeFor[json.RawMessage]()
+)
+
+// Generator строит схемы по Go-типам. Именованные структуры и
+// перечисления попадают в components и подставляются через $ref.
+//
+// Поля структур берут имена из тега json; тег openapi уточняет схему:
+//
+//	Title string `json:"title" openapi:"required,minLength=1"`
+//	URL   string `json:"url" openapi:"required,format=uri"`
+type Generator struct {
+	schemas map[string]*Schema
+	enums   map[reflect.Type][]any
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		enums:   make(map[reflect.Type][]any),
+	}
+}
+
+// Enum объявляет допустимые значения именованного типа.
+func (g *Generator) Enum(t reflect.Type, values ...any) {
+	g.enums[t] = values
+}
+
+// SchemaOf возвращает схему для типа значения v.
+func (g *Generator) SchemaOf(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == rawType {
+		return &Schema{}
+	}
+	if values, ok := g.enums[t]; ok {
+		return g.component(t, func() *Schema {
+			return &Schema{Type: jsonType(t.Kind()), Enum: values}
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.component(t, func() *Schema { return g.object(t) })
+	case reflect.Interface:
+		return &Schema{}
+	default:
+		return &Schema{Type: jsonType(t.Kind())}
+	}
+}
+
+// component регистрирует схему под именем типа. Заглушка ставится до
+// построения, чтобы рекурсивные типы не зацикливались.
+func (g *Generator) component(t reflect.Type, build func() *Schema) *Schema {
+	name := t.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, ok := g.schemas[name]; ok {
+		return ref
+	}
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *build()
+	return ref
+}
+
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(s, t)
+	return s
+}
+
+func (g *Generator) fields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		for _, opt := range strings.Split(f.Tag.Get("openapi"), ",") {
+			key, val, _ := strings.Cut(opt, "=")
+			switch key {
+			case "required":
+				s.Required = append(s.Required, name)
+			case "format":
+				fs.Format = val
+			case "minLength":
+				n, _ := strconv.Atoi(val)
+				fs.MinLength = &n
+			case "minimum":
+				n, _ := strconv.ParseFloat(val, 64)
+				fs.Minimum = &n
+			}
+		}
+		s.Properties[name] = fs
+		s.order = append(s.order, name)
+	}
+}
+
+// nullable разрешает null: для ссылок через anyOf, иначе вторым типом.
+func nullable(s *Schema) *Schema {
+	switch {
+	case s.Ref != "":
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	case s.Type == nil:
+		return s
+	default:
+		out := *s
+		out.Type = []string{s.Type.(string), "null"}
+		return &out
+	}
+}
+
+func jsonType(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,286 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBodySize ограничивает тело, которое валидатор читает в память.
+const MaxBodySize = 1 << 20
+
+// ValidationError перечисляет все найденные нарушения запроса.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid request: " + strings.Join(e.Errors, "; ")
+}
+
+// ValidateRequest проверяет query-параметры и JSON-тело запроса по
+// операции. Тело прочитывается и подменяется копией, так что обработчик
+// получает его нетронутым.
+func (d *Document) ValidateRequest(op *Operation, r *http.Request) error {
+	v := &validator{doc: d}
+	q := r.URL.Query()
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		raw, ok := q[p.Name]
+		if !ok || raw[0] == "" {
+			if p.Required {
+				v.fail("query."+p.Name, "is required")
+			}
+			continue
+		}
+		v.param("query."+p.Name, p.Schema, raw[0])
+	}
+
+	// Пустая схема ({}) — тело разбирает сам обработчик со своим форматом
+	// ошибок (например, JSON-RPC отвечает -32700 на невалидный JSON).
+	if op.RequestBody != nil && !op.RequestBody.Content["application/json"].Schema.any() {
+		body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		switch {
+		case err != nil:
+			v.fail("body", "cannot be read")
+		case len(body) > MaxBodySize:
+			v.fail("body", fmt.Sprintf("exceeds %d bytes", MaxBodySize))
+		case len(bytes.TrimSpace(body)) == 0:
+			if op.RequestBody.Required {
+				v.fail("body", "is required")
+			}
+		default:
+			var val any
+			if err := json.Unmarshal(body, &val); err != nil {
+				v.fail("body", "invalid JSON")
+				break
+			}
+			v.value("body", op.RequestBody.Content["application/json"].Schema, val)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+	return nil
+}
+
+// ValidateValue проверяет уже разобранное JSON-значение по схеме.
+func (d *Document) ValidateValue(s *Schema, val any) error {
+	v := &validator{doc: d}
+	v.value("", s, val)
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+	return nil
+}
+
+type validator struct {
+	doc  *Document
+	errs []string
+}
+
+func (v *validator) fail(path, msg string) {
+	if path == "" {
+		path = "value"
+	}
+	v.errs = append(v.errs, path+": "+msg)
+}
+
+func (v *validator) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = v.doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// param проверяет строковое значение query-параметра, приводя его к типу схемы.
+func (v *validator) param(path string, s *Schema, raw string) {
+	s = v.resolve(s)
+	if s == nil {
+		return
+	}
+	var val any = raw
+	switch {
+	case hasType(s, "integer"):
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			v.fail(path, "must be an integer")
+			return
+		}
+		val = float64(n)
+	case hasType(s, "number"):
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			v.fail(path, "must be a number")
+			return
+		}
+		val = n
+	case hasType(s, "boolean"):
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			v.fail(path, "must be a boolean")
+			return
+		}
+		val = b
+	}
+	v.value(path, s, val)
+}
+
+func (v *validator) value(path string, s *Schema, val any) {
+	s = v.resolve(s)
+	if s == nil {
+		return
+	}
+	if len(s.AnyOf) > 0 {
+		for _, alt := range s.AnyOf {
+			sub := &validator{doc: v.doc}
+			sub.value(path, alt, val)
+			if len(sub.errs) == 0 {
+				return
+			}
+		}
+		v.fail(path, "does not match any allowed schema")
+		return
+	}
+	if s.Type != nil && !hasType(s, typeOf(val)) && !(typeOf(val) == "integer" && hasType(s, "number")) {
+		v.fail(path, "must be "+typeNames(s))
+		return
+	}
+	if len(s.Enum) > 0 && val != nil && !enumContains(s.Enum, val) {
+		v.fail(path, "must be one of "+enumNames(s.Enum))
+		return
+	}
+
+	switch x := val.(type) {
+	case string:
+		if s.MinLength != nil && utf8.RuneCountInString(x) < *s.MinLength {
+			v.fail(path, fmt.Sprintf("must be at least %d characters", *s.MinLength))
+		}
+		v.format(path, s.Format, x)
+	case float64:
+		if s.Minimum != nil && x < *s.Minimum {
+			v.fail(path, fmt.Sprintf("must be >= %g", *s.Minimum))
+		}
+	case []any:
+		for i, item := range x {
+			v.value(fmt.Sprintf("%s[%d]", path, i), s.Items, item)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := x[name]; !ok {
+				v.fail(join(path, name), "is required")
+			}
+		}
+		for _, name := range s.order {
+			if item, ok := x[name]; ok {
+				v.value(join(path, name), s.Properties[name], item)
+			}
+		}
+		for name, item := range x {
+			if _, known := s.Properties[name]; known {
+				if s.order == nil {
+					v.value(join(path, name), s.Properties[name], item)
+				}
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				v.value(join(path, name), s.AdditionalProperties, item)
+			}
+		}
+	}
+}
+
+func (v *validator) format(path, format, s string) {
+	switch format {
+	case "uri":
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			v.fail(path, "must be an absolute URI")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(path, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func typeOf(val any) string {
+	switch x := val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == math.Trunc(x) && !math.IsInf(x, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func hasType(s *Schema, name string) bool {
+	switch t := s.Type.(type) {
+	case string:
+		return t == name
+	case []string:
+		return slices.Contains(t, name)
+	}
+	return false
+}
+
+func typeNames(s *Schema) string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return strings.Join(t, " or ")
+	}
+	return ""
+}
+
+// enumContains сравнивает строковые представления: значения перечисления
+// задаются Go-типами (domain.Status), а из JSON приходят строки.
+func enumContains(enum []any, val any) bool {
+	s := fmt.Sprint(val)
+	for _, e := range enum {
+		if fmt.Sprint(e) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func enumNames(enum []any) string {
+	names := make([]string, len(enum))
+	for i, e := range enum {
+		names[i] = fmt.Sprint(e)
+	}
+	return strings.Join(names, ", ")
+}
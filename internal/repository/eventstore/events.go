@@ -0,0 +1,148 @@
+package eventstore
+
+import (
+	"time"
+
+	"taskapi/internal/domain"
+)
+
+type EventType string
+
+const (
+	EventCreated            EventType = "created"
+	EventStatusChanged      EventType = "status_changed"
+	EventRetitled           EventType = "retitled"
+	EventDescriptionChanged EventType = "description_changed"
+	EventProjectChanged     EventType = "project_changed"
+	EventAssigneeChanged    EventType = "assignee_changed"
+	EventParentChanged      EventType = "parent_changed"
+	EventCommented          EventType = "commented"
+	EventDeleted            EventType = "deleted"
+)
+
+type Event struct {
+	Seq         uint64          `json:"seq"`
+	Type        EventType       `json:"type"`
+	TaskID      string          `json:"task_id"`
+	At          time.Time       `json:"at"`
+	Task        *domain.Task    `json:"task,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Status      domain.Status   `json:"status,omitempty"`
+	Project     string          `json:"project,omitempty"`
+	Assignee    string          `json:"assignee,omitempty"`
+	ParentID    string          `json:"parent_id,omitempty"`
+	Comment     *domain.Comment `json:"comment,omitempty"`
+}
+
+// Projection — read-модель, которая строится проигрыванием событий.
+type Projection interface {
+	Reset()
+	Apply(ev Event)
+}
+
+type taskProjection struct {
+	tasks    map[string]domain.Task
+	comments map[string][]domain.Comment
+}
+
+func newTaskProjection() *taskProjection {
+	p := &taskProjection{}
+	p.Reset()
+	return p
+}
+
+func (p *taskProjection) Reset() {
+	p.tasks = make(map[string]domain.Task)
+	p.comments = make(map[string][]domain.Comment)
+}
+
+func (p *taskProjection) Apply(ev Event) {
+	if ev.Type == EventCreated {
+		if ev.Task != nil {
+			p.tasks[ev.TaskID] = *ev.Task
+		}
+		return
+	}
+	t, ok := p.tasks[ev.TaskID]
+	if !ok {
+		return
+	}
+	switch ev.Type {
+	case EventStatusChanged:
+		t.Status = ev.Status
+	case EventRetitled:
+		t.Title = ev.Title
+	case EventDescriptionChanged:
+		t.Description = ev.Description
+	case EventProjectChanged:
+		t.Project = ev.Project
+	case EventAssigneeChanged:
+		t.Assignee = ev.Assignee
+	case EventParentChanged:
+		t.ParentID = ev.ParentID
+	case EventCommented:
+		// Комментарий не меняет саму задачу и ее UpdatedAt.
+		if ev.Comment != nil {
+			p.comments[ev.TaskID] = append(p.comments[ev.TaskID], *ev.Comment)
+		}
+		return
+	case EventDeleted:
+		delete(p.tasks, ev.TaskID)
+		delete(p.comments, ev.TaskID)
+		return
+	}
+	t.UpdatedAt = ev.At
+	p.tasks[ev.TaskID] = t
+}
+
+func (p *taskProjection) load(tasks []domain.Task, comments []domain.Comment) {
+	p.Reset()
+	for _, t := range tasks {
+		p.tasks[t.ID] = t
+	}
+	for _, c := range comments {
+		p.comments[c.TaskID] = append(p.comments[c.TaskID], c)
+	}
+}
+
+func (p *taskProjection) list() []domain.Task {
+	out := make([]domain.Task, 0, len(p.tasks))
+	for _, t := range p.tasks {
+		out = append(out, t)
+	}
+	return out
+}
+
+// listComments — комментарии в порядке добавления внутри каждой задачи.
+func (p *taskProjection) listComments() []domain.Comment {
+	var out []domain.Comment
+	for _, list := range p.comments {
+		out = append(out, list...)
+	}
+	return out
+}
+
+// diff возвращает события, переводящие old в updated.
+func diff(old, updated domain.Task) []Event {
+	var out []Event
+	if old.Status != updated.Status {
+		out = append(out, Event{Type: EventStatusChanged, TaskID: old.ID, At: updated.UpdatedAt, Status: updated.Status})
+	}
+	if old.Title != updated.Title {
+		out = append(out, Event{Type: EventRetitled, TaskID: old.ID, At: updated.UpdatedAt, Title: updated.Title})
+	}
+	if old.Description != updated.Description {
+		out = append(out, Event{Type: EventDescriptionChanged, TaskID: old.ID, At: updated.UpdatedAt, Description: updated.Description})
+	}
+	if old.Project != updated.Project {
+		out = append(out, Event{Type: EventProjectChanged, TaskID: old.ID, At: updated.UpdatedAt, Project: updated.Project})
+	}
+	if old.Assignee != updated.Assignee {
+		out = append(out, Event{Type: EventAssigneeChanged, TaskID: old.ID, At: updated.UpdatedAt, Assignee: updated.Assignee})
+	}
+	if old.ParentID != updated.ParentID {
+		out = append(out, Event{Type: EventParentChanged, TaskID: old.ID, At: updated.UpdatedAt, ParentID: updated.ParentID})
+	}
+	return out
+}
@@ -0,0 +1,313 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"taskapi/internal/domain"
+	"taskapi/internal/logger"
+	"taskapi/internal/repository"
+)
+
+// EventSnapshotFailed пишется в журнал, когда снапшот не сохранился: событие
+// уже в потоке, поэтому операция считается успешной.
+const EventSnapshotFailed = "snapshot_failed"
+
+type Logger interface {
+	Log(logger.Entry)
+}
+
+// Repo — репозиторий, где источник правды это поток событий,
+// а текущее состояние восстанавливается проигрыванием.
+type Repo struct {
+	// Log получает ошибки фоновых операций; nil — не писать.
+	Log Logger
+
+	mu    sync.RWMutex
+	log   Log
+	snaps SnapshotStore
+	every int
+	// base — время первого события журнала: у журнала, созданного заново,
+	// номера событий начинаются с 1, а ревизии — нет.
+	base        uint64
+	seq         uint64
+	modified    time.Time
+	sinceSnap   int
+	tasks       *taskProjection
+	projections []Projection
+}
+
+// New восстанавливает состояние из последнего снапшота и событий после него.
+// every — через сколько событий делать новый снапшот (0 — не делать).
+func New(log Log, snaps SnapshotStore, every int) (*Repo, error) {
+	r := &Repo{
+		log:   log,
+		snaps: snaps,
+		every: every,
+		tasks: newTaskProjection(),
+	}
+	r.projections = []Projection{r.tasks}
+
+	if snaps != nil {
+		snap, ok, err := snaps.Latest()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			r.tasks.load(snap.Tasks, snap.Comments)
+			r.seq = snap.Seq
+			// Время удалений в снапшот не попадает: берется последнее
+			// изменение из оставшихся задач.
+			for _, t := range snap.Tasks {
+				if t.UpdatedAt.After(r.modified) {
+					r.modified = t.UpdatedAt
+				}
+			}
+		}
+	}
+	err := log.ReadFrom(r.seq, func(ev Event) error {
+		r.apply(ev)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.readBase(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var errStopRead = errors.New("stop")
+
+// readBase берет время первого события; после снапшота его нет среди
+// проигранных, поэтому читается одна первая строка журнала.
+func (r *Repo) readBase() error {
+	err := r.log.ReadFrom(0, func(ev Event) error {
+		r.base = baseOf(ev.At)
+		return errStopRead
+	})
+	if errors.Is(err, errStopRead) {
+		return nil
+	}
+	return err
+}
+
+// baseOf — время в наносекундах; время до 1970 года (в том числе нулевое)
+// сдвига не дает.
+func baseOf(at time.Time) uint64 {
+	if at.Before(time.Unix(0, 0)) {
+		return 0
+	}
+	return uint64(at.UnixNano())
+}
+
+// AddProjection регистрирует дополнительную read-модель и сразу
+// строит ее с нуля по всему потоку.
+func (r *Repo) AddProjection(p Projection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.Reset()
+	if err := r.log.ReadFrom(0, func(ev Event) error {
+		p.Apply(ev)
+		return nil
+	}); err != nil {
+		return err
+	}
+	r.projections = append(r.projections, p)
+	return nil
+}
+
+// Rebuild сбрасывает все проекции и проигрывает поток с самого начала,
+// игнорируя снапшоты.
+func (r *Repo) Rebuild(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.projections {
+		p.Reset()
+	}
+	r.seq, r.modified = 0, time.Time{}
+	err := r.log.ReadFrom(0, func(ev Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.apply(ev)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.snapshot()
+}
+
+func (r *Repo) Create(ctx context.Context, t domain.Task) (domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seq == 0 {
+		r.base = baseOf(t.CreatedAt)
+	}
+	task := t
+	err := r.emit(Event{Type: EventCreated, TaskID: t.ID, At: t.CreatedAt, Task: &task})
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return t, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, id string) (domain.Task, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tasks.tasks[id]
+	return t, ok, nil
+}
+
+func (r *Repo) List(ctx context.Context, f repository.Filter) ([]domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]domain.Task, 0, len(r.tasks.tasks))
+	n := 0
+	for _, t := range r.tasks.tasks {
+		if n++; n%repository.ScanCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if !f.Match(t) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (r *Repo) Update(ctx context.Context, t domain.Task) (domain.Task, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.tasks.tasks[t.ID]
+	if !ok {
+		return domain.Task{}, false, nil
+	}
+	if repository.Unchanged(old, t) {
+		return old, true, nil
+	}
+	if err := r.emit(diff(old, t)...); err != nil {
+		return domain.Task{}, true, err
+	}
+	return r.tasks.tasks[t.ID], true, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, id string, at time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks.tasks[id]; !ok {
+		return false, nil
+	}
+	if err := r.emit(Event{Type: EventDeleted, TaskID: id, At: at}); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (r *Repo) AddComment(ctx context.Context, c domain.Comment) (domain.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Comment{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment := c
+	if err := r.emit(Event{Type: EventCommented, TaskID: c.TaskID, At: c.CreatedAt, Comment: &comment}); err != nil {
+		return domain.Comment{}, err
+	}
+	return c, nil
+}
+
+func (r *Repo) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.Comment
+	for _, id := range taskIDs {
+		out = append(out, r.tasks.comments[id]...)
+	}
+	return out, nil
+}
+
+// Revision — номер последнего события, сдвинутый на время первого: он растет
+// с каждым изменением, сохраняется между перезапусками и не повторяется,
+// если каталог данных создан заново.
+func (r *Repo) Revision(ctx context.Context) (domain.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Revision{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return domain.Revision{Version: r.base + r.seq, Modified: r.modified}, nil
+}
+
+// emit вызывается под r.mu. Ошибка — только если события не записаны;
+// неудачный снапшот лишь откладывает следующий.
+func (r *Repo) emit(events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	for i := range events {
+		events[i].Seq = r.seq + uint64(i) + 1
+	}
+	if err := r.log.Append(events...); err != nil {
+		return err
+	}
+	for _, ev := range events {
+		r.apply(ev)
+	}
+	r.sinceSnap += len(events)
+	if r.every > 0 && r.sinceSnap >= r.every {
+		if err := r.snapshot(); err != nil && r.Log != nil {
+			r.Log.Log(logger.Entry{
+				Time:  time.Now().UTC(),
+				Level: logger.LevelError,
+				Event: EventSnapshotFailed,
+				Data:  map[string]any{"seq": r.seq},
+				Error: err.Error(),
+			})
+		}
+	}
+	return nil
+}
+
+func (r *Repo) apply(ev Event) {
+	for _, p := range r.projections {
+		p.Apply(ev)
+	}
+	r.seq = ev.Seq
+	if ev.At.After(r.modified) {
+		r.modified = ev.At
+	}
+}
+
+func (r *Repo) snapshot() error {
+	r.sinceSnap = 0
+	if r.snaps == nil {
+		return nil
+	}
+	return r.snaps.Save(Snapshot{Seq: r.seq, Tasks: r.tasks.list(), Comments: r.tasks.listComments()})
+}
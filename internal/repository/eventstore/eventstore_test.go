@@ -0,0 +1,295 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"taskapi/internal/domain"
+	"taskapi/internal/logger"
+	"taskapi/internal/repository"
+	"taskapi/internal/repository/eventstore"
+)
+
+type countingProjection struct {
+	counts map[eventstore.EventType]int
+}
+
+func (p *countingProjection) Reset() { p.counts = map[eventstore.EventType]int{} }
+func (p *countingProjection) Apply(ev eventstore.Event) {
+	p.counts[ev.Type]++
+}
+
+func TestRepo_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo, err := eventstore.New(eventstore.NewMemLog(), eventstore.NewMemSnapshots(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := domain.Task{ID: "1", Title: "Task", Status: domain.StatusTodo, CreatedAt: now, UpdatedAt: now}
+	if _, err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("unexpected error on Create: %v", err)
+	}
+
+	task.Title = "Renamed"
+	task.Status = domain.StatusDone
+	task.UpdatedAt = now.Add(time.Hour)
+	got, ok, err := repo.Update(ctx, task)
+	if err != nil || !ok {
+		t.Fatalf("unexpected Update result: ok=%v err=%v", ok, err)
+	}
+	if got.Title != "Renamed" || got.Status != domain.StatusDone || !got.UpdatedAt.Equal(task.UpdatedAt) {
+		t.Errorf("unexpected task after update: %+v", got)
+	}
+
+	if _, ok, _ := repo.Update(ctx, domain.Task{ID: "missing"}); ok {
+		t.Errorf("expected update of missing task to report not found")
+	}
+
+	done := domain.StatusDone
+	list, _ := repo.List(ctx, repository.Filter{Status: &done})
+	if len(list) != 1 {
+		t.Errorf("expected 1 done task, got %d", len(list))
+	}
+
+	deletedAt := now.Add(2 * time.Hour)
+	ok, err = repo.Delete(ctx, "1", deletedAt)
+	if err != nil || !ok {
+		t.Fatalf("unexpected Delete result: ok=%v err=%v", ok, err)
+	}
+	if rev, _ := repo.Revision(ctx); !rev.Modified.Equal(deletedAt) {
+		t.Errorf("expected the deletion time %v, got %v", deletedAt, rev.Modified)
+	}
+	if _, ok, _ := repo.GetByID(ctx, "1"); ok {
+		t.Errorf("expected task to be deleted")
+	}
+}
+
+func TestRepo_CommentsSurviveReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.jsonl")
+	snaps := eventstore.NewFileSnapshots(filepath.Join(dir, "snapshot.json"))
+
+	log, err := eventstore.OpenFileLog(logPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Снапшот после третьего события: один комментарий в нем, остальное — в журнале.
+	repo, err := eventstore.New(log, snaps, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"1", "2"} {
+		if _, err := repo.Create(ctx, domain.Task{ID: id, Title: "Task " + id}); err != nil {
+			t.Fatalf("unexpected error on Create: %v", err)
+		}
+	}
+	for _, c := range []domain.Comment{{ID: "a", TaskID: "1", Body: "first"}, {ID: "b", TaskID: "2"}, {ID: "c", TaskID: "1", Body: "second"}} {
+		if _, err := repo.AddComment(ctx, c); err != nil {
+			t.Fatalf("unexpected error on AddComment: %v", err)
+		}
+	}
+	if _, err := repo.Delete(ctx, "2", time.Now()); err != nil {
+		t.Fatalf("unexpected error on Delete: %v", err)
+	}
+	_ = log.Close()
+
+	log, err = eventstore.OpenFileLog(logPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer log.Close()
+	restored, err := eventstore.New(log, snaps, 3)
+	if err != nil {
+		t.Fatalf("unexpected error on replay: %v", err)
+	}
+	got, _ := restored.ListComments(ctx, []string{"1", "2"})
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Errorf("expected comments a, c of task 1 after replay, got %+v", got)
+	}
+	if task, _, _ := restored.GetByID(ctx, "1"); !task.UpdatedAt.IsZero() {
+		t.Errorf("a comment must not touch the task, got %+v", task)
+	}
+}
+
+func TestRepo_ReplayFromFileWithSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.jsonl")
+	snaps := eventstore.NewFileSnapshots(filepath.Join(dir, "snapshot.json"))
+
+	log, err := eventstore.OpenFileLog(logPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo, err := eventstore.New(log, snaps, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := repo.Create(ctx, domain.Task{ID: id, Title: "Task " + id, Status: domain.StatusTodo}); err != nil {
+			t.Fatalf("unexpected error on Create: %v", err)
+		}
+	}
+	if _, err := repo.Delete(ctx, "2", time.Now()); err != nil {
+		t.Fatalf("unexpected error on Delete: %v", err)
+	}
+	_ = log.Close()
+
+	snap, ok, err := snaps.Latest()
+	if err != nil || !ok {
+		t.Fatalf("expected snapshot, ok=%v err=%v", ok, err)
+	}
+	if snap.Seq != 4 {
+		t.Errorf("expected snapshot at seq 4, got %d", snap.Seq)
+	}
+
+	log, err = eventstore.OpenFileLog(logPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer log.Close()
+	restored, err := eventstore.New(log, snaps, 2)
+	if err != nil {
+		t.Fatalf("unexpected error on replay: %v", err)
+	}
+	list, _ := restored.List(ctx, repository.Filter{})
+	if len(list) != 2 {
+		t.Errorf("expected 2 tasks after replay, got %d", len(list))
+	}
+	if rev, _ := restored.Revision(ctx); rev.Version != 4 {
+		t.Errorf("expected revision 4 after replay, got %d", rev.Version)
+	}
+
+	proj := &countingProjection{}
+	if err := restored.AddProjection(proj); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if proj.counts[eventstore.EventCreated] != 3 || proj.counts[eventstore.EventDeleted] != 1 {
+		t.Errorf("unexpected projection counts: %v", proj.counts)
+	}
+
+	if err := restored.Rebuild(ctx); err != nil {
+		t.Fatalf("unexpected error on Rebuild: %v", err)
+	}
+	if proj.counts[eventstore.EventCreated] != 3 {
+		t.Errorf("expected projection to be rebuilt from scratch, got %v", proj.counts)
+	}
+	if _, ok, _ := restored.GetByID(ctx, "3"); !ok {
+		t.Errorf("expected task 3 after rebuild")
+	}
+}
+
+type failingSnapshots struct{ eventstore.MemSnapshots }
+
+func (*failingSnapshots) Save(eventstore.Snapshot) error { return errors.New("disk full") }
+
+type entryRecorder struct{ entries []logger.Entry }
+
+func (r *entryRecorder) Log(e logger.Entry) { r.entries = append(r.entries, e) }
+
+func TestRepo_SnapshotFailureKeepsWrite(t *testing.T) {
+	ctx := context.Background()
+	repo, err := eventstore.New(eventstore.NewMemLog(), &failingSnapshots{}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log := &entryRecorder{}
+	repo.Log = log
+
+	if _, err := repo.Create(ctx, domain.Task{ID: "1", Title: "Task"}); err != nil {
+		t.Fatalf("a failed snapshot must not fail the write: %v", err)
+	}
+	if _, ok, _ := repo.GetByID(ctx, "1"); !ok {
+		t.Error("expected the task to be stored")
+	}
+	if len(log.entries) != 1 || log.entries[0].Event != eventstore.EventSnapshotFailed || log.entries[0].Error != "disk full" {
+		t.Errorf("expected a %s entry, got %+v", eventstore.EventSnapshotFailed, log.entries)
+	}
+}
+
+func TestFileLog_TornTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	log, err := eventstore.OpenFileLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo, _ := eventstore.New(log, nil, 0)
+	if _, err := repo.Create(ctx, domain.Task{ID: "1", Title: "Task"}); err != nil {
+		t.Fatalf("unexpected error on Create: %v", err)
+	}
+	_ = log.Close()
+
+	// Сбой посреди записи второго события.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString(`{"seq":2,"type":"created","task_id":"2","ta`)
+	_ = f.Close()
+
+	log, err = eventstore.OpenFileLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer log.Close()
+	repo, err = eventstore.New(log, nil, 0)
+	if err != nil {
+		t.Fatalf("a torn last record must not break startup: %v", err)
+	}
+	if _, err := repo.Create(ctx, domain.Task{ID: "2", Title: "Task"}); err != nil {
+		t.Fatalf("unexpected error on Create: %v", err)
+	}
+
+	// После отрезания хвоста новое событие ложится с новой строки.
+	restored, err := eventstore.New(log, nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error on replay: %v", err)
+	}
+	if list, _ := restored.List(ctx, repository.Filter{}); len(list) != 2 {
+		t.Errorf("expected 2 tasks after replay, got %+v", list)
+	}
+}
+
+func TestRepo_RevisionAcrossRecreatedLogs(t *testing.T) {
+	ctx := context.Background()
+	open := func(dir string) (*eventstore.Repo, *eventstore.FileLog) {
+		t.Helper()
+		log, err := eventstore.OpenFileLog(filepath.Join(dir, "events.jsonl"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		repo, err := eventstore.New(log, eventstore.NewFileSnapshots(filepath.Join(dir, "snapshot.json")), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return repo, log
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	var revs []uint64
+	for i, dir := range []string{first, second} {
+		repo, log := open(dir)
+		at := time.Date(2025, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		if _, err := repo.Create(ctx, domain.Task{ID: "1", Title: "Task", CreatedAt: at, UpdatedAt: at}); err != nil {
+			t.Fatalf("unexpected error on Create: %v", err)
+		}
+		rev, _ := repo.Revision(ctx)
+		revs = append(revs, rev.Version)
+		_ = log.Close()
+	}
+	if revs[0] == revs[1] {
+		t.Errorf("a recreated log must not repeat revisions, both are %d", revs[0])
+	}
+
+	// После перезапуска со снапшота ревизия та же.
+	repo, log := open(first)
+	defer log.Close()
+	if rev, _ := repo.Revision(ctx); rev.Version != revs[0] {
+		t.Errorf("expected revision %d after restart, got %d", revs[0], rev.Version)
+	}
+}
@@ -0,0 +1,250 @@
+package eventstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"taskapi/internal/domain"
+)
+
+// Log — append-only поток событий.
+type Log interface {
+	Append(events ...Event) error
+	ReadFrom(seq uint64, fn func(Event) error) error
+}
+
+type MemLog struct {
+	mu     sync.RWMutex
+	events []Event
+}
+
+func NewMemLog() *MemLog {
+	return &MemLog{}
+}
+
+func (l *MemLog) Append(events ...Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, events...)
+	return nil
+}
+
+func (l *MemLog) ReadFrom(seq uint64, fn func(Event) error) error {
+	l.mu.RLock()
+	events := append([]Event(nil), l.events...)
+	l.mu.RUnlock()
+	for _, ev := range events {
+		if ev.Seq <= seq {
+			continue
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileLog хранит события в файле по одному JSON-объекту на строку.
+type FileLog struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+func OpenFileLog(path string) (*FileLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLog{path: path, f: f}, nil
+}
+
+func (l *FileLog) Append(events ...Event) error {
+	var buf []byte
+	for _, ev := range events {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, b...)
+		buf = append(buf, '\n')
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(buf); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// ReadFrom проигрывает события после seq. Последняя строка без перевода
+// строки — след записи, прерванной сбоем: Append не вернул успех, поэтому
+// строка отрезается, а не считается повреждением журнала.
+func (l *FileLog) ReadFrom(seq uint64, fn func(Event) error) error {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rd := bufio.NewReaderSize(f, 64*1024)
+	var offset int64
+	for line := 1; ; line++ {
+		b, err := rd.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(b) > 0 {
+				return l.truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(b))
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(b, &ev); err != nil {
+			return fmt.Errorf("event log %s line %d: %w", l.path, line, err)
+		}
+		if ev.Seq <= seq {
+			continue
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// truncate отрезает недописанный хвост, чтобы следующий Append начался
+// с новой строки.
+func (l *FileLog) truncate(size int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Truncate(size); err != nil {
+		return fmt.Errorf("event log %s: truncate torn record: %w", l.path, err)
+	}
+	return l.f.Sync()
+}
+
+// Check проверяет, что журнал открыт и файл на диске не пропал.
+func (l *FileLog) Check(context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Stat(); err != nil {
+		return err
+	}
+	_, err := os.Stat(l.path)
+	return err
+}
+
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+type Snapshot struct {
+	Seq      uint64           `json:"seq"`
+	Tasks    []domain.Task    `json:"tasks"`
+	Comments []domain.Comment `json:"comments,omitempty"`
+}
+
+type SnapshotStore interface {
+	Save(s Snapshot) error
+	Latest() (Snapshot, bool, error)
+}
+
+type MemSnapshots struct {
+	mu   sync.RWMutex
+	last *Snapshot
+}
+
+func NewMemSnapshots() *MemSnapshots {
+	return &MemSnapshots{}
+}
+
+func (m *MemSnapshots) Save(s Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last = &s
+	return nil
+}
+
+func (m *MemSnapshots) Latest() (Snapshot, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.last == nil {
+		return Snapshot{}, false, nil
+	}
+	return *m.last, true, nil
+}
+
+// FileSnapshots хранит только последний снапшот; запись атомарная через rename.
+type FileSnapshots struct {
+	path string
+}
+
+func NewFileSnapshots(path string) *FileSnapshots {
+	return &FileSnapshots{path: path}
+}
+
+func (s *FileSnapshots) Save(snap Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := writeSynced(tmp, b); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// writeSynced пишет файл и дожидается его записи на диск: без Sync после
+// сбоя под итоговым именем мог бы оказаться пустой или оборванный снапшот.
+func writeSynced(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *FileSnapshots) Latest() (Snapshot, bool, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return Snapshot{}, false, fmt.Errorf("snapshot %s: %w", s.path, err)
+	}
+	return snap, true, nil
+}
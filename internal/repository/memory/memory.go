@@ -3,30 +3,50 @@ package memory
 import (
 	"context"
 	"sync"
+	"time"
 
 	"taskapi/internal/domain"
 	"taskapi/internal/repository"
 )
 
 type Repo struct {
-	mu    sync.RWMutex
-	tasks map[string]domain.Task
+	mu       sync.RWMutex
+	tasks    map[string]domain.Task
+	comments map[string][]domain.Comment
+	rev      domain.Revision
 }
 
 func New() *Repo {
 	return &Repo{
-		tasks: make(map[string]domain.Task),
+		tasks:    make(map[string]domain.Task),
+		comments: make(map[string][]domain.Comment),
+		// Данные не переживают перезапуск, поэтому и версии не должны
+		// начинаться заново: иначе старый ETag совпадет с новыми данными.
+		rev: domain.Revision{Version: uint64(time.Now().UnixNano())},
 	}
 }
 
+// touch отмечает изменение коллекции; вызывается под r.mu.
+func (r *Repo) touch() {
+	r.rev.Version++
+	r.rev.Modified = time.Now().UTC()
+}
+
 func (r *Repo) Create(ctx context.Context, t domain.Task) (domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.tasks[t.ID] = t
+	r.touch()
 	return t, nil
 }
 
 func (r *Repo) GetByID(ctx context.Context, id string) (domain.Task, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, false, err
+	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	t, ok := r.tasks[id]
@@ -34,15 +54,90 @@ func (r *Repo) GetByID(ctx context.Context, id string) (domain.Task, bool, error
 }
 
 func (r *Repo) List(ctx context.Context, f repository.Filter) ([]domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	out := make([]domain.Task, 0, len(r.tasks))
+	n := 0
 	for _, t := range r.tasks {
-		if f.Status != nil && t.Status != *f.Status {
+		if n++; n%repository.ScanCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if !f.Match(t) {
 			continue
 		}
 		out = append(out, t)
 	}
 	return out, nil
 }
+
+func (r *Repo) Update(ctx context.Context, t domain.Task) (domain.Task, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.tasks[t.ID]
+	if !ok {
+		return domain.Task{}, false, nil
+	}
+	if repository.Unchanged(old, t) {
+		return old, true, nil
+	}
+	r.tasks[t.ID] = t
+	r.touch()
+	return t, true, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, id string, _ time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[id]; !ok {
+		return false, nil
+	}
+	delete(r.tasks, id)
+	delete(r.comments, id)
+	r.touch()
+	return true, nil
+}
+
+func (r *Repo) Revision(ctx context.Context) (domain.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Revision{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rev, nil
+}
+
+func (r *Repo) AddComment(ctx context.Context, c domain.Comment) (domain.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Comment{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comments[c.TaskID] = append(r.comments[c.TaskID], c)
+	r.touch()
+	return c, nil
+}
+
+func (r *Repo) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.Comment
+	for _, id := range taskIDs {
+		out = append(out, r.comments[id]...)
+	}
+	return out, nil
+}
@@ -2,10 +2,13 @@ package memory_test
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"taskapi/internal/domain"
 	"taskapi/internal/repository"
 	"taskapi/internal/repository/memory"
 	"testing"
+	"time"
 )
 
 func TestRepo_CreateAndGetByID(t *testing.T) {
@@ -113,3 +116,115 @@ func TestRepo_List(t *testing.T) {
 func ptrStatus(s domain.Status) *domain.Status {
 	return &s
 }
+
+func TestRepo_UpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+
+	if _, ok, _ := repo.Update(ctx, domain.Task{ID: "1"}); ok {
+		t.Errorf("expected update of missing task to report not found")
+	}
+
+	if _, err := repo.Create(ctx, domain.Task{ID: "1", Title: "Task", Status: domain.StatusTodo}); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	got, ok, err := repo.Update(ctx, domain.Task{ID: "1", Title: "Renamed", Status: domain.StatusDone})
+	if err != nil || !ok {
+		t.Fatalf("unexpected Update result: ok=%v err=%v", ok, err)
+	}
+	if got.Title != "Renamed" {
+		t.Errorf("expected title %q, got %q", "Renamed", got.Title)
+	}
+
+	ok, err = repo.Delete(ctx, "1", time.Now())
+	if err != nil || !ok {
+		t.Fatalf("unexpected Delete result: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := repo.Delete(ctx, "1", time.Now()); ok {
+		t.Errorf("expected second delete to report not found")
+	}
+}
+
+func TestRepo_Comments(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	for _, id := range []string{"1", "2"} {
+		if _, err := repo.Create(ctx, domain.Task{ID: id, Title: "Task"}); err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+	}
+	for _, c := range []domain.Comment{{ID: "a", TaskID: "1", Body: "first"}, {ID: "b", TaskID: "2"}, {ID: "c", TaskID: "1", Body: "second"}} {
+		if _, err := repo.AddComment(ctx, c); err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+	}
+
+	got, _ := repo.ListComments(ctx, []string{"1"})
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Errorf("expected comments a, c in order, got %+v", got)
+	}
+	_, _ = repo.Delete(ctx, "2", time.Now())
+	if got, _ := repo.ListComments(ctx, []string{"1", "2"}); len(got) != 2 {
+		t.Errorf("expected comments to be deleted with the task, got %+v", got)
+	}
+}
+
+func TestRepo_CanceledContext(t *testing.T) {
+	repo := memory.New()
+	for i := 0; i < 1000; i++ {
+		id := strconv.Itoa(i)
+		if _, err := repo.Create(context.Background(), domain.Task{ID: id, Title: id}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := repo.List(ctx, repository.Filter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("List: expected context.Canceled, got %v", err)
+	}
+	if _, _, err := repo.GetByID(ctx, "1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetByID: expected context.Canceled, got %v", err)
+	}
+	if _, err := repo.Delete(ctx, "1", time.Now()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Delete: expected context.Canceled, got %v", err)
+	}
+	if _, ok, _ := repo.GetByID(context.Background(), "1"); !ok {
+		t.Error("task deleted despite canceled context")
+	}
+}
+
+func TestRepo_Revision(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	prev, _ := repo.Revision(ctx)
+
+	steps := []struct {
+		name    string
+		op      func() error
+		changed bool
+	}{
+		{"create", func() error { _, err := repo.Create(ctx, domain.Task{ID: "1", Title: "Task"}); return err }, true},
+		{"read", func() error { _, _, err := repo.GetByID(ctx, "1"); return err }, false},
+		{"update", func() error { _, _, err := repo.Update(ctx, domain.Task{ID: "1", Title: "Renamed"}); return err }, true},
+		{"update missing", func() error { _, _, err := repo.Update(ctx, domain.Task{ID: "2"}); return err }, false},
+		{"delete", func() error { _, err := repo.Delete(ctx, "1", time.Now()); return err }, true},
+		{"delete missing", func() error { _, err := repo.Delete(ctx, "1", time.Now()); return err }, false},
+	}
+	for _, st := range steps {
+		if err := st.op(); err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		rev, err := repo.Revision(ctx)
+		if err != nil {
+			t.Fatalf("%s: revision: %v", st.name, err)
+		}
+		if changed := rev.Version != prev.Version; changed != st.changed {
+			t.Errorf("%s: expected changed=%v, revision %d -> %d", st.name, st.changed, prev.Version, rev.Version)
+		}
+		if st.changed && rev.Modified.IsZero() {
+			t.Errorf("%s: expected modification time", st.name)
+		}
+		prev = rev
+	}
+}
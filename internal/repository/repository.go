@@ -2,12 +2,57 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"taskapi/internal/domain"
 )
 
 type Filter struct {
-	Status *domain.Status
+	Status    *domain.Status
+	Project   string
+	Assignee  string
+	IDs       []string
+	ParentIDs []string
+}
+
+func (f Filter) Match(t domain.Task) bool {
+	if f.Status != nil && t.Status != *f.Status {
+		return false
+	}
+	if f.Project != "" && t.Project != f.Project {
+		return false
+	}
+	if f.Assignee != "" && t.Assignee != f.Assignee {
+		return false
+	}
+	if f.IDs != nil && !contains(f.IDs, t.ID) {
+		return false
+	}
+	if f.ParentIDs != nil && !contains(f.ParentIDs, t.ParentID) {
+		return false
+	}
+	return true
+}
+
+// Unchanged сообщает, что updated не меняет ни одного поля old, кроме
+// UpdatedAt. Такое обновление хранилища не выполняют: задача, ее UpdatedAt
+// и ревизия коллекции остаются прежними.
+func Unchanged(old, updated domain.Task) bool {
+	return old.Title == updated.Title && old.Description == updated.Description && old.Status == updated.Status &&
+		old.Project == updated.Project && old.Assignee == updated.Assignee && old.ParentID == updated.ParentID
+}
+
+// ScanCheckEvery — через сколько задач List проверяет контекст: длинный
+// обход прерывается, когда истек срок запроса.
+const ScanCheckEvery = 256
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // Так как таска маленькая и копирование дешевое, то передаю ее по значению
@@ -15,4 +60,15 @@ type TaskRepository interface {
 	Create(ctx context.Context, t domain.Task) (domain.Task, error)
 	GetByID(ctx context.Context, id string) (domain.Task, bool, error)
 	List(ctx context.Context, f Filter) ([]domain.Task, error)
+	// Update возвращает сохраненную задачу; при Unchanged — прежнюю.
+	Update(ctx context.Context, t domain.Task) (domain.Task, bool, error)
+	// Delete удаляет задачу; at — время удаления по часам сервиса.
+	Delete(ctx context.Context, id string, at time.Time) (bool, error)
+	// Revision — текущая версия всей коллекции, для ETag списков.
+	Revision(ctx context.Context) (domain.Revision, error)
+	// AddComment и ListComments работают с комментариями; Delete удаляет
+	// задачу вместе с ее комментариями.
+	AddComment(ctx context.Context, c domain.Comment) (domain.Comment, error)
+	// ListComments возвращает комментарии задач taskIDs в порядке добавления.
+	ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error)
 }
@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"taskapi/internal/domain"
+	"taskapi/internal/repository"
+	"taskapi/internal/repository/eventstore"
+	"taskapi/internal/repository/memory"
+)
+
+// Хранилища взаимозаменяемы: один и тот же вызов ведет себя одинаково.
+func TestRepositories_Update(t *testing.T) {
+	backends := []struct {
+		name string
+		new  func(t *testing.T) repository.TaskRepository
+	}{
+		{"memory", func(*testing.T) repository.TaskRepository { return memory.New() }},
+		{"eventstore", func(t *testing.T) repository.TaskRepository {
+			repo, err := eventstore.New(eventstore.NewMemLog(), eventstore.NewMemSnapshots(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return repo
+		}},
+	}
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := created.Add(time.Hour)
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := b.new(t)
+			task := domain.Task{ID: "1", Title: "Task", Status: domain.StatusTodo, CreatedAt: created, UpdatedAt: created}
+			if _, err := repo.Create(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+			before, _ := repo.Revision(ctx)
+
+			// Обновление без изменений ничего не трогает.
+			same := task
+			same.UpdatedAt = later
+			got, ok, err := repo.Update(ctx, same)
+			if err != nil || !ok || !got.UpdatedAt.Equal(created) {
+				t.Errorf("no-op update must keep the stored task: %+v, ok=%v, err=%v", got, ok, err)
+			}
+			if rev, _ := repo.Revision(ctx); rev != before {
+				t.Errorf("no-op update must keep the revision: %+v, was %+v", rev, before)
+			}
+
+			changed := same
+			changed.Title = "Renamed"
+			got, ok, err = repo.Update(ctx, changed)
+			if err != nil || !ok || got.Title != "Renamed" || !got.UpdatedAt.Equal(later) {
+				t.Errorf("unexpected update result: %+v, ok=%v, err=%v", got, ok, err)
+			}
+			if rev, _ := repo.Revision(ctx); rev.Version == before.Version {
+				t.Errorf("update must bump the revision: %+v, was %+v", rev, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package requestid — идентификатор запроса: генерация, проверка входящего
+// значения и передача через context.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Header — заголовок, в котором идентификатор приходит от шлюза и уходит в
+// исходящие вызовы.
+const Header = "X-Request-ID"
+
+// MaxLen — предел длины входящего идентификатора.
+const MaxLen = 128
+
+// Valid сообщает, можно ли принять входящий идентификатор: 1..MaxLen
+// символов из A-Z, a-z, 0-9 и "-", "_", ".", ":". Так в журнал и заголовки
+// ответа не попадут пробелы, управляющие символы и произвольно длинные
+// строки.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// crockford — алфавит Crockford base32: без I, L, O, U, порядок символов
+// совпадает с порядком значений, поэтому строки сортируются как числа.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	mu       sync.Mutex
+	lastMS   uint64
+	lastRand [10]byte
+)
+
+// New возвращает ULID: 26 символов, 48 бит времени в миллисекундах и 80
+// случайных бит. Идентификаторы сортируются по времени создания; внутри
+// одной миллисекунды случайная часть увеличивается на единицу, поэтому
+// порядок сохраняется и совпадения исключены.
+func New() string {
+	ms := uint64(time.Now().UnixMilli())
+	mu.Lock()
+	if ms <= lastMS {
+		ms = lastMS
+		increment(&lastRand)
+	} else {
+		lastMS = ms
+		if _, err := rand.Read(lastRand[:]); err != nil {
+			panic("requestid: crypto/rand: " + err.Error())
+		}
+	}
+	r := lastRand
+	mu.Unlock()
+	return encode(ms, r)
+}
+
+// increment прибавляет единицу к 80-битному числу; переполнение за одну
+// миллисекунду практически невозможно и просто начинает счет с нуля.
+func increment(b *[10]byte) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return
+		}
+	}
+}
+
+func encode(ms uint64, r [10]byte) string {
+	// 128 бит: 48 бит времени и 80 бит случайной части, по 5 бит на символ
+	// начиная со старших; первый символ несет только 3 бита.
+	var raw [16]byte
+	binary.BigEndian.PutUint64(raw[:8], ms<<16)
+	copy(raw[6:], r[:])
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+type ctxKey struct{}
+
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или "", если его нет.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
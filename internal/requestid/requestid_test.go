@@ -0,0 +1,71 @@
+package requestid_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"taskapi/internal/requestid"
+	"testing"
+)
+
+func TestNew_UniqueAndSortable(t *testing.T) {
+	const goroutines, perG = 8, 1000
+	var (
+		mu  sync.Mutex
+		all []string
+		wg  sync.WaitGroup
+	)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]string, perG)
+			for i := range ids {
+				ids[i] = requestid.New()
+			}
+			if !sort.StringsAreSorted(ids) {
+				t.Error("ids from one goroutine must be increasing")
+			}
+			mu.Lock()
+			all = append(all, ids...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	seen := make(map[string]bool, len(all))
+	for _, id := range all {
+		if len(id) != 26 || !requestid.Valid(id) {
+			t.Fatalf("unexpected id %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate id %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"01J9ZQ3K8C6V2X7M4N5P0R1S2T", true},
+		{"gw-1.a_b:c", true},
+		{"", false},
+		{strings.Repeat("a", requestid.MaxLen), true},
+		{strings.Repeat("a", requestid.MaxLen+1), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"юникод", false},
+	}
+	for _, tt := range tests {
+		if got := requestid.Valid(tt.id); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+	ctx := requestid.WithContext(context.Background(), "r1")
+	if requestid.FromContext(ctx) != "r1" || requestid.FromContext(context.Background()) != "" {
+		t.Error("unexpected context value")
+	}
+}
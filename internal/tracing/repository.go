@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"taskapi/internal/domain"
+	"taskapi/internal/logger"
+	"taskapi/internal/repository"
+	"time"
+)
+
+// Annotate дописывает в запись лога trace/span ID из ctx и отмечает ее
+// событием на текущем спане; ошибка записи помечает спан ошибочным.
+func Annotate(ctx context.Context, e logger.Entry) logger.Entry {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		e.TraceID = sc.TraceID.String()
+		e.SpanID = sc.SpanID.String()
+	}
+	if s := SpanFromContext(ctx); s != nil {
+		s.AddEvent(e.Event)
+		s.SetError(e.Error)
+	}
+	return e
+}
+
+type repo struct {
+	next    repository.TaskRepository
+	tracer  *Tracer
+	storage string
+}
+
+// Repository оборачивает хранилище: каждый вызов — отдельный спан repo.*.
+func Repository(next repository.TaskRepository, t *Tracer, storage string) repository.TaskRepository {
+	return &repo{next: next, tracer: t, storage: storage}
+}
+
+func (r *repo) start(ctx context.Context, op string) (context.Context, *Span) {
+	ctx, span := r.tracer.Start(ctx, "repo."+op, KindInternal)
+	span.SetAttr("db.system", r.storage)
+	span.SetAttr("db.operation", op)
+	return ctx, span
+}
+
+func end(span *Span, err error) {
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	span.End()
+}
+
+func (r *repo) Create(ctx context.Context, t domain.Task) (domain.Task, error) {
+	ctx, span := r.start(ctx, "Create")
+	span.SetAttr("task.id", t.ID)
+	out, err := r.next.Create(ctx, t)
+	end(span, err)
+	return out, err
+}
+
+func (r *repo) GetByID(ctx context.Context, id string) (domain.Task, bool, error) {
+	ctx, span := r.start(ctx, "GetByID")
+	span.SetAttr("task.id", id)
+	t, ok, err := r.next.GetByID(ctx, id)
+	span.SetAttr("found", ok)
+	end(span, err)
+	return t, ok, err
+}
+
+func (r *repo) List(ctx context.Context, f repository.Filter) ([]domain.Task, error) {
+	ctx, span := r.start(ctx, "List")
+	tasks, err := r.next.List(ctx, f)
+	span.SetAttr("count", len(tasks))
+	end(span, err)
+	return tasks, err
+}
+
+func (r *repo) Update(ctx context.Context, t domain.Task) (domain.Task, bool, error) {
+	ctx, span := r.start(ctx, "Update")
+	span.SetAttr("task.id", t.ID)
+	out, ok, err := r.next.Update(ctx, t)
+	span.SetAttr("found", ok)
+	end(span, err)
+	return out, ok, err
+}
+
+func (r *repo) Delete(ctx context.Context, id string, at time.Time) (bool, error) {
+	ctx, span := r.start(ctx, "Delete")
+	span.SetAttr("task.id", id)
+	ok, err := r.next.Delete(ctx, id, at)
+	span.SetAttr("found", ok)
+	end(span, err)
+	return ok, err
+}
+
+func (r *repo) Revision(ctx context.Context) (domain.Revision, error) {
+	ctx, span := r.start(ctx, "Revision")
+	rev, err := r.next.Revision(ctx)
+	end(span, err)
+	return rev, err
+}
+
+func (r *repo) AddComment(ctx context.Context, c domain.Comment) (domain.Comment, error) {
+	ctx, span := r.start(ctx, "AddComment")
+	span.SetAttr("task.id", c.TaskID)
+	out, err := r.next.AddComment(ctx, c)
+	end(span, err)
+	return out, err
+}
+
+func (r *repo) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	ctx, span := r.start(ctx, "ListComments")
+	comments, err := r.next.ListComments(ctx, taskIDs)
+	span.SetAttr("count", len(comments))
+	end(span, err)
+	return comments, err
+}
@@ -0,0 +1,305 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+type SpanKind int
+
+// Значения совпадают с SpanKind в OTLP.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// SpanData — завершенный спан, который получает экспортер.
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+	Events     []Event
+	Error      string
+}
+
+type Event struct {
+	Name string
+	Time time.Time
+}
+
+// Span — незавершенная операция. Методы безопасны для nil: без трассировщика
+// код не проверяет, создан ли спан.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetName меняет имя, например когда маршрут становится известен только
+// после выбора обработчика.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+func (s *Span) AddEvent(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Events = append(s.data.Events, Event{Name: name, Time: s.tracer.now()})
+	s.mu.Unlock()
+}
+
+// SetError помечает спан ошибочным; пустое сообщение ничего не меняет.
+func (s *Span) SetError(msg string) {
+	if s == nil || msg == "" {
+		return
+	}
+	s.mu.Lock()
+	s.data.Error = msg
+	s.mu.Unlock()
+}
+
+// End завершает спан и передает его на экспорт; повторные вызовы игнорируются.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.now()
+	data := s.data
+	s.mu.Unlock()
+	if s.sc.Sampled() {
+		s.tracer.enqueue(data)
+	}
+}
+
+type Options struct {
+	// SampleRatio — доля новых трасс, которые экспортируются (0..1). Для
+	// трасс, начатых вызывающим сервисом, решение берется из traceparent.
+	SampleRatio float64
+	// BatchSize и FlushInterval задают, как часто спаны уходят в экспортер.
+	BatchSize     int
+	FlushInterval time.Duration
+	// QueueSize — сколько спанов ждет экспорта; лишние отбрасываются.
+	QueueSize int
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 256
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 5 * time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 2048
+	}
+	return o
+}
+
+// Tracer создает спаны и пачками отдает завершенные экспортеру в фоне.
+type Tracer struct {
+	exporter Exporter
+	opts     Options
+	now      func() time.Time
+
+	queue   chan SpanData
+	flushCh chan chan struct{}
+	done    chan struct{}
+
+	stopOnce sync.Once
+	mu       sync.RWMutex
+	stopped  bool
+}
+
+// New создает трассировщик. Без экспортера спаны и идентификаторы все равно
+// создаются — они нужны для логов и передачи traceparent дальше.
+func New(exp Exporter, opts Options) *Tracer {
+	t := &Tracer{
+		exporter: exp,
+		opts:     opts.withDefaults(),
+		now:      func() time.Time { return time.Now().UTC() },
+		flushCh:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if exp == nil {
+		close(t.done)
+		return t
+	}
+	t.queue = make(chan SpanData, t.opts.QueueSize)
+	go t.run()
+	return t
+}
+
+// Start начинает спан, дочерний к спану из ctx или к контексту вызывающего
+// сервиса. Для nil трассировщика возвращает ctx без изменений и nil спан.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: randomSpanID()}
+	if parent.IsValid() {
+		sc.TraceID, sc.Flags, sc.State = parent.TraceID, parent.Flags, parent.State
+	} else {
+		sc.TraceID, sc.SpanID = randomIDs()
+		if t.sample(sc.TraceID) {
+			sc.Flags = flagSampled
+		}
+	}
+	s := &Span{tracer: t, sc: sc, data: SpanData{
+		Name:    name,
+		Kind:    kind,
+		Context: sc,
+		Parent:  parent.SpanID,
+		Start:   t.now(),
+	}}
+	return ContextWithSpan(ctx, s), s
+}
+
+// sample решает по младшим байтам trace ID, как TraceIdRatioBased в
+// OpenTelemetry, поэтому решение одинаково для всех спанов трассы.
+func (t *Tracer) sample(id TraceID) bool {
+	switch r := t.opts.SampleRatio; {
+	case r >= 1:
+		return true
+	case r <= 0:
+		return false
+	default:
+		return binary.BigEndian.Uint64(id[8:])>>1 < uint64(r*(1<<63))
+	}
+}
+
+func (t *Tracer) enqueue(d SpanData) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.queue == nil || t.stopped {
+		return
+	}
+	select {
+	case t.queue <- d:
+	default:
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.opts.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, t.opts.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_ = t.exporter.Export(ctx, batch)
+		cancel()
+		batch = make([]SpanData, 0, t.opts.BatchSize)
+	}
+	for {
+		select {
+		case d, ok := <-t.queue:
+			if !ok {
+				export()
+				return
+			}
+			batch = append(batch, d)
+			if len(batch) >= t.opts.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-t.flushCh:
+			for n := len(t.queue); n > 0; n-- {
+				batch = append(batch, <-t.queue)
+			}
+			export()
+			close(ack)
+		}
+	}
+}
+
+// Flush экспортирует все завершенные к этому моменту спаны.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	ack := make(chan struct{})
+	select {
+	case t.flushCh <- ack:
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown экспортирует оставшиеся спаны и закрывает экспортер.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.stopOnce.Do(func() {
+		t.mu.Lock()
+		t.stopped = true
+		if t.queue != nil {
+			close(t.queue)
+		}
+		t.mu.Unlock()
+	})
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if t.exporter == nil {
+		return nil
+	}
+	return t.exporter.Shutdown(ctx)
+}
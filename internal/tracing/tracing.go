@@ -0,0 +1,171 @@
+// Package tracing — трассировка запросов по W3C Trace Context: разбор и
+// передача traceparent/tracestate, спаны и их экспорт.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+type TraceID [16]byte
+
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+type SpanID [8]byte
+
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+const flagSampled byte = 0x01
+
+// SpanContext — то, что передается между сервисами в traceparent/tracestate.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+	State   string
+	Remote  bool
+}
+
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+func (sc SpanContext) Sampled() bool { return sc.Flags&flagSampled != 0 }
+
+// Traceparent форматирует заголовок версии 00.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceparent разбирает заголовок traceparent. Версии новее 00
+// принимаются, если их начало совпадает с форматом 00.
+func ParseTraceparent(h string) (SpanContext, bool) {
+	h = strings.TrimSpace(h)
+	if len(h) < 55 {
+		return SpanContext{}, false
+	}
+	version, rest := h[:2], h[2:]
+	if !isLowerHex(version) || version == "ff" {
+		return SpanContext{}, false
+	}
+	if version == "00" && len(h) != 55 {
+		return SpanContext{}, false
+	}
+	if len(rest) > 53 && rest[53] != '-' {
+		return SpanContext{}, false
+	}
+	if rest[0] != '-' || rest[33] != '-' || rest[50] != '-' {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	if !decodeHex(sc.TraceID[:], rest[1:33]) || !decodeHex(sc.SpanID[:], rest[34:50]) {
+		return SpanContext{}, false
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], rest[51:53]) {
+		return SpanContext{}, false
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Remote = true
+	return sc, true
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if !isLowerHex(s) {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract достает контекст вызывающего сервиса из заголовков. tracestate без
+// корректного traceparent игнорируется.
+func Extract(ctx context.Context, h interface{ Get(string) string }) context.Context {
+	sc, ok := ParseTraceparent(h.Get(HeaderTraceparent))
+	if !ok {
+		return ctx
+	}
+	sc.State = strings.TrimSpace(h.Get(HeaderTracestate))
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject пишет текущий контекст трассировки в заголовки исходящего запроса.
+func Inject(ctx context.Context, h interface{ Set(string, string) }) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.State != "" {
+		h.Set(HeaderTracestate, sc.State)
+	}
+}
+
+type (
+	spanKey   struct{}
+	remoteKey struct{}
+)
+
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext возвращает текущий спан или nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SpanContextFromContext — контекст текущего спана, а если его нет —
+// контекст, пришедший от вызывающего сервиса.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithSpanContext сохраняет контекст трассировки без спана, например
+// для фоновой работы, начатой в рамках запроса.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func randomIDs() (TraceID, SpanID) {
+	var t TraceID
+	for !t.IsValid() {
+		_, _ = rand.Read(t[:])
+	}
+	return t, randomSpanID()
+}
+
+func randomSpanID() SpanID {
+	var s SpanID
+	for !s.IsValid() {
+		_, _ = rand.Read(s[:])
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"taskapi/internal/logger"
+	"taskapi/internal/repository/memory"
+	"taskapi/internal/tracing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantOK  bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with suffix", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what", true, true},
+		{"version 00 with suffix", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"upper case", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"short", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"empty", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := tracing.ParseTraceparent(tt.header)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok=%v, got %v", tt.wantOK, ok)
+			}
+			if ok && sc.Sampled() != tt.sampled {
+				t.Errorf("expected sampled=%v", tt.sampled)
+			}
+			if ok && tt.name == "sampled" && sc.Traceparent() != tt.header {
+				t.Errorf("round trip: got %s", sc.Traceparent())
+			}
+		})
+	}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *recorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	r.spans = append(r.spans, spans...)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recorder) Shutdown(context.Context) error { return nil }
+
+func TestTracer_Propagation(t *testing.T) {
+	rec := &recorder{}
+	tr := tracing.New(rec, tracing.Options{SampleRatio: 1})
+	h := http.Header{}
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Set("tracestate", "vendor=1")
+
+	ctx := tracing.Extract(context.Background(), h)
+	ctx, parent := tr.Start(ctx, "parent", tracing.KindServer)
+	repo := tracing.Repository(memory.New(), tr, "memory")
+	_, _, _ = repo.GetByID(ctx, "missing")
+	e := tracing.Annotate(ctx, logger.Entry{Event: "task_read", Error: "TASK NOT FOUND"})
+	parent.End()
+
+	out := http.Header{}
+	tracing.Inject(ctx, out)
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(rec.spans))
+	}
+	child, root := rec.spans[0], rec.spans[1]
+	if root.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || root.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("server span must continue the remote trace: %+v", root.Context)
+	}
+	if child.Name != "repo.GetByID" || child.Parent != root.Context.SpanID || child.Context.TraceID != root.Context.TraceID {
+		t.Errorf("unexpected child span: %+v", child)
+	}
+	if e.TraceID != root.Context.TraceID.String() || e.SpanID != root.Context.SpanID.String() {
+		t.Errorf("log entry is not annotated: %+v", e)
+	}
+	if root.Error != "TASK NOT FOUND" || len(root.Events) != 1 {
+		t.Errorf("log entry must be recorded on the span: %+v", root)
+	}
+	if out.Get("traceparent") != root.Context.Traceparent() || out.Get("tracestate") != "vendor=1" {
+		t.Errorf("unexpected injected headers: %v", out)
+	}
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	rec := &recorder{}
+	tr := tracing.New(rec, tracing.Options{SampleRatio: 0})
+	_, s := tr.Start(context.Background(), "dropped", tracing.KindInternal)
+	s.End()
+
+	h := http.Header{}
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, s = tr.Start(tracing.Extract(context.Background(), h), "kept", tracing.KindServer)
+	s.End()
+	_ = tr.Shutdown(context.Background())
+
+	if len(rec.spans) != 1 || rec.spans[0].Name != "kept" {
+		t.Errorf("expected only the span of a sampled remote trace, got %+v", rec.spans)
+	}
+
+	var nilTracer *tracing.Tracer
+	ctx, span := nilTracer.Start(context.Background(), "noop", tracing.KindInternal)
+	span.SetAttr("k", "v")
+	span.End()
+	if tracing.SpanFromContext(ctx) != nil {
+		t.Error("nil tracer must not create spans")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &body)
+	}))
+	defer srv.Close()
+
+	tr := tracing.New(tracing.NewOTLPExporter(srv.URL+"/v1/traces", "taskapi"), tracing.Options{SampleRatio: 1, FlushInterval: time.Hour})
+	_, s := tr.Start(context.Background(), "GET /tasks", tracing.KindServer)
+	s.SetAttr("http.response.status_code", 500)
+	s.SetError("Internal Server Error")
+	s.End()
+	if err := tr.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := json.Marshal(body)
+	for _, want := range []string{
+		`"key":"service.name","value":{"stringValue":"taskapi"}`,
+		`"name":"GET /tasks"`,
+		`"kind":2`,
+		`"key":"http.response.status_code","value":{"intValue":"500"}`,
+		`"status":{"code":2,"message":"Internal Server Error"}`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("export is missing %s: %s", want, b)
+		}
+	}
+	_ = tr.Shutdown(context.Background())
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Сентинелы для errors.Is: все ошибки сервиса с кодом из каталога сводятся к
+// одному из них.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrBadRequest = errors.New("bad request")
+)
+
+// Code — стабильный машинный код ошибки. Тексты сообщений могут меняться,
+// коды — нет: клиенты сравнивают именно их.
+type Code string
+
+const (
+	CodeTaskNotFound     Code = "task_not_found"
+	CodeValidationFailed Code = "validation_failed"
+	CodeTaskHasSubtasks  Code = "task_has_subtasks"
+	CodeInternal         Code = "internal_error"
+)
+
+// Коды ошибок отдельных полей в FieldError.
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+	FieldNotFound = "not_found"
+)
+
+// CodeInfo — запись каталога: краткое описание кода и сентинел, к которому
+// он сводится.
+type CodeInfo struct {
+	Code  Code
+	Title string
+	kind  error
+}
+
+var catalog = map[Code]CodeInfo{
+	CodeTaskNotFound:     {Code: CodeTaskNotFound, Title: "Task not found", kind: ErrNotFound},
+	CodeValidationFailed: {Code: CodeValidationFailed, Title: "Validation failed", kind: ErrBadRequest},
+	CodeTaskHasSubtasks:  {Code: CodeTaskHasSubtasks, Title: "Task has subtasks", kind: ErrBadRequest},
+	CodeInternal:         {Code: CodeInternal, Title: "Internal error"},
+}
+
+// Catalog возвращает доменные коды ошибок по алфавиту.
+func Catalog() []CodeInfo {
+	out := make([]CodeInfo, 0, len(catalog))
+	for _, info := range catalog {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// Title — описание кода из каталога; для кодов вне каталога — сам код.
+func (c Code) Title() string {
+	if info, ok := catalog[c]; ok {
+		return info.Title
+	}
+	return string(c)
+}
+
+// FieldError — нарушение в одном поле входных данных.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error — ошибка сервиса с кодом из каталога и, для проверки входных
+// данных, списком нарушений по полям. errors.Is(err, ErrNotFound) и
+// errors.Is(err, ErrBadRequest) работают через Unwrap.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return e.Message + ": " + strings.Join(parts, "; ")
+}
+
+func (e *Error) Unwrap() error { return catalog[e.Code].kind }
+
+// CodeOf возвращает код ошибки; ошибки без кода считаются внутренними.
+func CodeOf(err error) Code {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e.Code
+	case errors.Is(err, ErrNotFound):
+		return CodeTaskNotFound
+	case errors.Is(err, ErrBadRequest):
+		return CodeValidationFailed
+	default:
+		return CodeInternal
+	}
+}
+
+func taskNotFound(id string) error {
+	return &Error{Code: CodeTaskNotFound, Message: fmt.Sprintf("task %q not found", id)}
+}
+
+func taskHasSubtasks(id string, n int) error {
+	return &Error{Code: CodeTaskHasSubtasks, Message: fmt.Sprintf("task %q has %d subtasks; delete or move them first", id, n)}
+}
+
+// fieldErrors собирает нарушения по полям, чтобы сообщить обо всех сразу.
+type fieldErrors []FieldError
+
+func (fe *fieldErrors) add(field, code, msg string) {
+	*fe = append(*fe, FieldError{Field: field, Code: code, Message: msg})
+}
+
+func (fe fieldErrors) err() error {
+	if len(fe) == 0 {
+		return nil
+	}
+	return &Error{Code: CodeValidationFailed, Message: "invalid input", Fields: fe}
+}
@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"taskapi/internal/domain"
+)
+
+// TaskEvent — изменение задачи, которое рассылается подписчикам
+// (вебхуки, стримы) после успешной записи в репозиторий.
+type TaskEvent struct {
+	Type      string      `json:"type"`
+	At        time.Time   `json:"at"`
+	RequestID string      `json:"request_id,omitempty"`
+	Task      domain.Task `json:"task"`
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, ev TaskEvent)
+}
+
+// Publishers рассылает событие всем подписчикам по очереди.
+type Publishers []Publisher
+
+func (ps Publishers) Publish(ctx context.Context, ev TaskEvent) {
+	for _, p := range ps {
+		p.Publish(ctx, ev)
+	}
+}
+
+// TaskEventTypes — события, на которые можно подписаться.
+var TaskEventTypes = []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted}
+
+func IsTaskEventType(s string) bool {
+	for _, t := range TaskEventTypes {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
@@ -7,7 +7,15 @@ import (
 )
 
 type TaskService interface {
-	Create(ctx context.Context, reqID string, in dto.CreateInput) (domain.Task, error)
-	Get(ctx context.Context, reqID, id string) (domain.Task, error)
-	List(ctx context.Context, reqID string, status *domain.Status) ([]domain.Task, error)
+	Create(ctx context.Context, in dto.CreateInput) (domain.Task, error)
+	Get(ctx context.Context, id string) (domain.Task, error)
+	List(ctx context.Context, f dto.ListFilter) ([]domain.Task, error)
+	Update(ctx context.Context, id string, in dto.UpdateInput) (domain.Task, error)
+	Delete(ctx context.Context, id string) error
+	// Revision — версия коллекции задач из хранилища.
+	Revision(ctx context.Context) (domain.Revision, error)
+	AddComment(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error)
+	// ListComments возвращает комментарии сразу нескольких задач — для
+	// пакетной загрузки.
+	ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error)
 }
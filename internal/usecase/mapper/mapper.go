@@ -3,6 +3,7 @@ package mapper
 import (
 	"taskapi/internal/domain"
 	"taskapi/internal/dto"
+	"taskapi/internal/repository"
 	"time"
 )
 
@@ -12,7 +13,53 @@ func ToDomainTask(in dto.CreateInput, id string, now time.Time) domain.Task {
 		Title:       in.Title,
 		Description: in.Description,
 		Status:      in.Status,
+		Project:     in.Project,
+		Assignee:    in.Assignee,
+		ParentID:    in.ParentID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 }
+
+func ToDomainComment(in dto.CommentInput, id, taskID string, now time.Time) domain.Comment {
+	return domain.Comment{
+		ID:        id,
+		TaskID:    taskID,
+		Author:    in.Author,
+		Body:      in.Body,
+		CreatedAt: now,
+	}
+}
+
+func ApplyUpdate(t domain.Task, in dto.UpdateInput, now time.Time) domain.Task {
+	if in.Title != nil {
+		t.Title = *in.Title
+	}
+	if in.Description != nil {
+		t.Description = *in.Description
+	}
+	if in.Status != nil {
+		t.Status = *in.Status
+	}
+	if in.Project != nil {
+		t.Project = *in.Project
+	}
+	if in.Assignee != nil {
+		t.Assignee = *in.Assignee
+	}
+	if in.ParentID != nil {
+		t.ParentID = *in.ParentID
+	}
+	t.UpdatedAt = now
+	return t
+}
+
+func ToRepoFilter(f dto.ListFilter) repository.Filter {
+	return repository.Filter{
+		Status:    f.Status,
+		Project:   f.Project,
+		Assignee:  f.Assignee,
+		IDs:       f.IDs,
+		ParentIDs: f.ParentIDs,
+	}
+}
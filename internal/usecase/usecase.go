@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"taskapi/internal/dto"
 	"taskapi/internal/usecase/mapper"
 	"taskapi/internal/usecase/validation"
@@ -13,17 +14,17 @@ import (
 	"taskapi/internal/domain"
 	"taskapi/internal/logger"
 	"taskapi/internal/repository"
-)
-
-var (
-	ErrNotFound   = errors.New("TASK NOT FOUND")
-	ErrBadRequest = errors.New("BAD REQUEST")
+	"taskapi/internal/requestid"
+	"taskapi/internal/tracing"
 )
 
 const (
-	EventTaskCreated = "task_created"
-	EventTaskRead    = "task_read"
-	EventTaskList    = "task_list"
+	EventTaskCreated  = "task_created"
+	EventTaskRead     = "task_read"
+	EventTaskList     = "task_list"
+	EventTaskUpdated  = "task_updated"
+	EventTaskDeleted  = "task_deleted"
+	EventCommentAdded = "comment_added"
 )
 
 type Logger interface {
@@ -31,10 +32,12 @@ type Logger interface {
 }
 
 type Service struct {
-	Repo  repository.TaskRepository
-	Log   Logger
-	Now   func() time.Time
-	IdGen func() string
+	Repo   repository.TaskRepository
+	Log    Logger
+	Events Publisher
+	Tracer *tracing.Tracer
+	Now    func() time.Time
+	IdGen  func() string
 }
 
 func NewService(repo repository.TaskRepository, log Logger) *Service {
@@ -50,56 +53,272 @@ func NewService(repo repository.TaskRepository, log Logger) *Service {
 	}
 }
 
-func (s *Service) Create(ctx context.Context, reqID string, in dto.CreateInput) (domain.Task, error) {
+func (s *Service) Create(ctx context.Context, in dto.CreateInput) (domain.Task, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.Create", tracing.KindInternal)
+	defer span.End()
+	var fe fieldErrors
 	if in.Title == "" {
-		return domain.Task{}, ErrBadRequest
+		fe.add("title", FieldRequired, "title is required")
+	}
+	if err := fe.err(); err != nil {
+		return domain.Task{}, err
 	}
 	if !validation.IsValidStatus(in.Status) {
 		in.Status = domain.StatusTodo
 	}
+	if err := s.checkParent(ctx, "", in.ParentID); err != nil {
+		return domain.Task{}, err
+	}
 
 	now := s.Now()
 	t := mapper.ToDomainTask(in, s.IdGen(), now)
 	out, err := s.Repo.Create(ctx, t)
-	s.Log.Log(logger.Entry{
+	s.Log.Log(tracing.Annotate(ctx, logger.Entry{
 		Time:      now,
+		Level:     levelOf(err),
 		Event:     EventTaskCreated,
-		RequestID: reqID,
+		RequestID: requestid.FromContext(ctx),
 		Data: map[string]any{
 			"id":     t.ID,
 			"status": t.Status,
 		},
 		Error: validation.ErrString(err),
-	})
+	}))
+	if err == nil {
+		s.publish(ctx, EventTaskCreated, out, now)
+	}
 	return out, err
 }
 
-func (s *Service) Get(ctx context.Context, reqID, id string) (domain.Task, error) {
+func (s *Service) Get(ctx context.Context, id string) (domain.Task, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.Get", tracing.KindInternal)
+	defer span.End()
 	t, ok, err := s.Repo.GetByID(ctx, id)
 	if err == nil && !ok {
-		err = ErrNotFound
+		err = taskNotFound(id)
 	}
-	s.Log.Log(logger.Entry{
+	s.Log.Log(tracing.Annotate(ctx, logger.Entry{
 		Time:      s.Now(),
+		Level:     levelOf(err),
 		Event:     EventTaskRead,
-		RequestID: reqID,
+		RequestID: requestid.FromContext(ctx),
 		Data:      map[string]any{"id": id},
 		Error:     validation.ErrString(err),
-	})
+	}))
 	return t, err
 }
 
-func (s *Service) List(ctx context.Context, reqID string, status *domain.Status) ([]domain.Task, error) {
-	tasks, err := s.Repo.List(ctx, repository.Filter{Status: status})
-	s.Log.Log(logger.Entry{
+func (s *Service) List(ctx context.Context, f dto.ListFilter) ([]domain.Task, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.List", tracing.KindInternal)
+	defer span.End()
+	tasks, err := s.Repo.List(ctx, mapper.ToRepoFilter(f))
+	s.Log.Log(tracing.Annotate(ctx, logger.Entry{
 		Time:      s.Now(),
+		Level:     levelOf(err),
 		Event:     EventTaskList,
-		RequestID: reqID,
+		RequestID: requestid.FromContext(ctx),
 		Data: map[string]any{
-			"status": validation.StatusString(status),
-			"count":  len(tasks),
+			"status":  validation.StatusString(f.Status),
+			"project": f.Project,
+			"count":   len(tasks),
 		},
 		Error: validation.ErrString(err),
-	})
+	}))
 	return tasks, err
 }
+
+func (s *Service) Update(ctx context.Context, id string, in dto.UpdateInput) (domain.Task, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.Update", tracing.KindInternal)
+	defer span.End()
+	var fe fieldErrors
+	if in.Title != nil && *in.Title == "" {
+		fe.add("title", FieldRequired, "title must not be empty")
+	}
+	if in.Status != nil && !validation.IsValidStatus(*in.Status) {
+		fe.add("status", FieldInvalid, fmt.Sprintf("unknown status %q", *in.Status))
+	}
+	if err := fe.err(); err != nil {
+		return domain.Task{}, err
+	}
+
+	now := s.Now()
+	out, err := s.update(ctx, id, in, now)
+	s.Log.Log(tracing.Annotate(ctx, logger.Entry{
+		Time:      now,
+		Level:     levelOf(err),
+		Event:     EventTaskUpdated,
+		RequestID: requestid.FromContext(ctx),
+		Data: map[string]any{
+			"id":     id,
+			"status": out.Status,
+		},
+		Error: validation.ErrString(err),
+	}))
+	if err == nil {
+		s.publish(ctx, EventTaskUpdated, out, now)
+	}
+	return out, err
+}
+
+func (s *Service) update(ctx context.Context, id string, in dto.UpdateInput, now time.Time) (domain.Task, error) {
+	t, ok, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if !ok {
+		return domain.Task{}, taskNotFound(id)
+	}
+	if in.ParentID != nil {
+		if err := s.checkParent(ctx, id, *in.ParentID); err != nil {
+			return domain.Task{}, err
+		}
+	}
+	out, ok, err := s.Repo.Update(ctx, mapper.ApplyUpdate(t, in, now))
+	if err == nil && !ok {
+		err = taskNotFound(id)
+	}
+	return out, err
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	ctx, span := s.Tracer.Start(ctx, "usecase.Delete", tracing.KindInternal)
+	defer span.End()
+	now := s.Now()
+	t, ok, err := s.Repo.GetByID(ctx, id)
+	if err == nil && ok {
+		err = s.checkNoSubtasks(ctx, id)
+	}
+	if err == nil && ok {
+		ok, err = s.Repo.Delete(ctx, id, now)
+	}
+	if err == nil && !ok {
+		err = taskNotFound(id)
+	}
+	s.Log.Log(tracing.Annotate(ctx, logger.Entry{
+		Time:      now,
+		Level:     levelOf(err),
+		Event:     EventTaskDeleted,
+		RequestID: requestid.FromContext(ctx),
+		Data:      map[string]any{"id": id},
+		Error:     validation.ErrString(err),
+	}))
+	if err == nil {
+		s.publish(ctx, EventTaskDeleted, t, now)
+	}
+	return err
+}
+
+// Revision не пишет в журнал: ее спрашивает каждый GET /tasks.
+func (s *Service) Revision(ctx context.Context) (domain.Revision, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.Revision", tracing.KindInternal)
+	defer span.End()
+	return s.Repo.Revision(ctx)
+}
+
+func (s *Service) AddComment(ctx context.Context, taskID string, in dto.CommentInput) (domain.Comment, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.AddComment", tracing.KindInternal)
+	defer span.End()
+	var fe fieldErrors
+	if in.Body == "" {
+		fe.add("body", FieldRequired, "body is required")
+	}
+	if err := fe.err(); err != nil {
+		return domain.Comment{}, err
+	}
+
+	now := s.Now()
+	c := mapper.ToDomainComment(in, s.IdGen(), taskID, now)
+	_, ok, err := s.Repo.GetByID(ctx, taskID)
+	if err == nil && !ok {
+		err = taskNotFound(taskID)
+	}
+	if err == nil {
+		c, err = s.Repo.AddComment(ctx, c)
+	}
+	s.Log.Log(tracing.Annotate(ctx, logger.Entry{
+		Time:      now,
+		Level:     levelOf(err),
+		Event:     EventCommentAdded,
+		RequestID: requestid.FromContext(ctx),
+		Data: map[string]any{
+			"id":      c.ID,
+			"task_id": taskID,
+		},
+		Error: validation.ErrString(err),
+	}))
+	return c, err
+}
+
+// ListComments, как и Revision, не пишет в журнал: это догрузка связей
+// к уже залогированному запросу задач.
+func (s *Service) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	ctx, span := s.Tracer.Start(ctx, "usecase.ListComments", tracing.KindInternal)
+	defer span.End()
+	return s.Repo.ListComments(ctx, taskIDs)
+}
+
+// checkParent проверяет, что родительская задача существует и задача не
+// оказывается среди собственных предков.
+func (s *Service) checkParent(ctx context.Context, id, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	var fe fieldErrors
+	seen := map[string]bool{}
+	for cur := parentID; cur != ""; {
+		if cur == id {
+			fe.add("parent_id", FieldInvalid, "a task cannot be its own ancestor")
+			return fe.err()
+		}
+		// Цикл выше по цепочке, не затрагивающий задачу, — не ее забота.
+		if seen[cur] {
+			return nil
+		}
+		seen[cur] = true
+		t, ok, err := s.Repo.GetByID(ctx, cur)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if cur == parentID {
+				fe.add("parent_id", FieldNotFound, fmt.Sprintf("parent task %q not found", parentID))
+				return fe.err()
+			}
+			return nil
+		}
+		cur = t.ParentID
+	}
+	return nil
+}
+
+// checkNoSubtasks не дает удалить задачу с подзадачами: иначе они
+// ссылались бы на несуществующего родителя.
+func (s *Service) checkNoSubtasks(ctx context.Context, id string) error {
+	children, err := s.Repo.List(ctx, repository.Filter{ParentIDs: []string{id}})
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return taskHasSubtasks(id, len(children))
+	}
+	return nil
+}
+
+// levelOf: ошибки клиента — warn, остальные ошибки — error.
+func levelOf(err error) logger.Level {
+	switch {
+	case err == nil:
+		return logger.LevelInfo
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrBadRequest):
+		return logger.LevelWarn
+	default:
+		return logger.LevelError
+	}
+}
+
+func (s *Service) publish(ctx context.Context, event string, t domain.Task, at time.Time) {
+	if s.Events == nil {
+		return
+	}
+	s.Events.Publish(ctx, TaskEvent{Type: event, At: at, RequestID: requestid.FromContext(ctx), Task: t})
+}
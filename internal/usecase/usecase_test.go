@@ -3,6 +3,7 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"taskapi/internal/dto"
 	"taskapi/internal/logger"
 	"taskapi/internal/repository"
+	"taskapi/internal/requestid"
+	"taskapi/internal/tracing"
 	"taskapi/internal/usecase"
 )
 
@@ -17,6 +20,11 @@ type mockRepo struct {
 	createFn func(ctx context.Context, t domain.Task) (domain.Task, error)
 	getFn    func(ctx context.Context, id string) (domain.Task, bool, error)
 	listFn   func(ctx context.Context, f repository.Filter) ([]domain.Task, error)
+	updateFn func(ctx context.Context, t domain.Task) (domain.Task, bool, error)
+	deleteFn func(ctx context.Context, id string, at time.Time) (bool, error)
+
+	addCommentFn   func(ctx context.Context, c domain.Comment) (domain.Comment, error)
+	listCommentsFn func(ctx context.Context, taskIDs []string) ([]domain.Comment, error)
 }
 
 func (m *mockRepo) Create(ctx context.Context, t domain.Task) (domain.Task, error) {
@@ -28,6 +36,21 @@ func (m *mockRepo) GetByID(ctx context.Context, id string) (domain.Task, bool, e
 func (m *mockRepo) List(ctx context.Context, f repository.Filter) ([]domain.Task, error) {
 	return m.listFn(ctx, f)
 }
+func (m *mockRepo) Update(ctx context.Context, t domain.Task) (domain.Task, bool, error) {
+	return m.updateFn(ctx, t)
+}
+func (m *mockRepo) Delete(ctx context.Context, id string, at time.Time) (bool, error) {
+	return m.deleteFn(ctx, id, at)
+}
+func (m *mockRepo) Revision(context.Context) (domain.Revision, error) {
+	return domain.Revision{}, nil
+}
+func (m *mockRepo) AddComment(ctx context.Context, c domain.Comment) (domain.Comment, error) {
+	return m.addCommentFn(ctx, c)
+}
+func (m *mockRepo) ListComments(ctx context.Context, taskIDs []string) ([]domain.Comment, error) {
+	return m.listCommentsFn(ctx, taskIDs)
+}
 
 type mockLogger struct {
 	entries []logger.Entry
@@ -78,7 +101,7 @@ func TestService_Create(t *testing.T) {
 				IdGen: func() string { return "test-id" },
 			}
 
-			got, err := svc.Create(context.Background(), "req-1", tt.input)
+			got, err := svc.Create(requestid.WithContext(context.Background(), "req-1"), tt.input)
 
 			if tt.wantErr != nil {
 				if !errors.Is(err, tt.wantErr) {
@@ -91,6 +114,9 @@ func TestService_Create(t *testing.T) {
 				if got.ID != "test-id" {
 					t.Errorf("expected ID 'test-id', got %v", got.ID)
 				}
+				if len(mockLog.entries) != 1 || mockLog.entries[0].RequestID != "req-1" {
+					t.Errorf("expected the request ID from context in the log, got %+v", mockLog.entries)
+				}
 			}
 		})
 	}
@@ -109,7 +135,7 @@ func TestService_Get(t *testing.T) {
 	svc := usecase.NewService(mockRepo, mockLog)
 
 	t.Run("found", func(t *testing.T) {
-		got, err := svc.Get(context.Background(), "req-1", "exists")
+		got, err := svc.Get(requestid.WithContext(context.Background(), "req-1"), "exists")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -119,7 +145,7 @@ func TestService_Get(t *testing.T) {
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		_, err := svc.Get(context.Background(), "req-1", "missing")
+		_, err := svc.Get(requestid.WithContext(context.Background(), "req-1"), "missing")
 		if !errors.Is(err, usecase.ErrNotFound) {
 			t.Errorf("expected ErrNotFound, got %v", err)
 		}
@@ -138,7 +164,7 @@ func TestService_List(t *testing.T) {
 	mockLog := &mockLogger{}
 	svc := usecase.NewService(mockRepo, mockLog)
 
-	got, err := svc.List(context.Background(), "req-1", nil)
+	got, err := svc.List(requestid.WithContext(context.Background(), "req-1"), dto.ListFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -146,3 +172,266 @@ func TestService_List(t *testing.T) {
 		t.Errorf("expected 2 tasks, got %d", len(got))
 	}
 }
+
+type recordingPublisher struct {
+	events []usecase.TaskEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, ev usecase.TaskEvent) {
+	p.events = append(p.events, ev)
+}
+
+func TestService_Update(t *testing.T) {
+	empty := ""
+	bad := domain.Status("bad")
+	done := domain.StatusDone
+
+	tests := []struct {
+		name    string
+		id      string
+		input   dto.UpdateInput
+		wantErr error
+	}{
+		{"success", "exists", dto.UpdateInput{Status: &done}, nil},
+		{"empty title", "exists", dto.UpdateInput{Title: &empty}, usecase.ErrBadRequest},
+		{"invalid status", "exists", dto.UpdateInput{Status: &bad}, usecase.ErrBadRequest},
+		{"not found", "missing", dto.UpdateInput{Status: &done}, usecase.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockRepo{
+				getFn: func(ctx context.Context, id string) (domain.Task, bool, error) {
+					if id == "exists" {
+						return domain.Task{ID: id, Title: "Task", Status: domain.StatusTodo}, true, nil
+					}
+					return domain.Task{}, false, nil
+				},
+				updateFn: func(ctx context.Context, tsk domain.Task) (domain.Task, bool, error) {
+					return tsk, true, nil
+				},
+			}
+			pub := &recordingPublisher{}
+			svc := usecase.NewService(mockRepo, &mockLogger{})
+			svc.Events = pub
+
+			got, err := svc.Update(requestid.WithContext(context.Background(), "req-1"), tt.id, tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if len(pub.events) != 0 {
+					t.Errorf("expected no events on error, got %d", len(pub.events))
+				}
+				return
+			}
+			if got.Status != done {
+				t.Errorf("expected status %q, got %q", done, got.Status)
+			}
+			if len(pub.events) != 1 || pub.events[0].Type != usecase.EventTaskUpdated {
+				t.Errorf("expected one %s event, got %+v", usecase.EventTaskUpdated, pub.events)
+			}
+		})
+	}
+}
+
+func TestService_AddComment(t *testing.T) {
+	var stored []domain.Comment
+	repo := &mockRepo{
+		getFn: func(ctx context.Context, id string) (domain.Task, bool, error) {
+			return domain.Task{ID: id}, id == "exists", nil
+		},
+		addCommentFn: func(ctx context.Context, c domain.Comment) (domain.Comment, error) {
+			stored = append(stored, c)
+			return c, nil
+		},
+	}
+	mockLog := &mockLogger{}
+	svc := usecase.NewService(repo, mockLog)
+	svc.Now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		taskID   string
+		in       dto.CommentInput
+		wantCode usecase.Code
+	}{
+		{"added", "exists", dto.CommentInput{Author: "ann", Body: "looks good"}, ""},
+		{"empty body", "exists", dto.CommentInput{Author: "ann"}, usecase.CodeValidationFailed},
+		{"unknown task", "missing", dto.CommentInput{Body: "hello"}, usecase.CodeTaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored = nil
+			c, err := svc.AddComment(context.Background(), tt.taskID, tt.in)
+			if tt.wantCode != "" {
+				if usecase.CodeOf(err) != tt.wantCode || stored != nil {
+					t.Fatalf("expected %s and nothing stored, got %v, %+v", tt.wantCode, err, stored)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(stored) != 1 || c.ID == "" || c.TaskID != "exists" || c.Author != "ann" || !c.CreatedAt.Equal(svc.Now()) {
+				t.Errorf("unexpected comment %+v, stored %+v", c, stored)
+			}
+		})
+	}
+	if last := mockLog.entries[len(mockLog.entries)-1]; last.Event != usecase.EventCommentAdded {
+		t.Errorf("expected %s in the log, got %+v", usecase.EventCommentAdded, last)
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var deletedAt time.Time
+	mockRepo := &mockRepo{
+		getFn: func(ctx context.Context, id string) (domain.Task, bool, error) {
+			return domain.Task{ID: id}, id == "exists", nil
+		},
+		listFn: func(ctx context.Context, f repository.Filter) ([]domain.Task, error) {
+			return nil, nil
+		},
+		deleteFn: func(ctx context.Context, id string, at time.Time) (bool, error) {
+			deletedAt = at
+			return true, nil
+		},
+	}
+	pub := &recordingPublisher{}
+	svc := usecase.NewService(mockRepo, &mockLogger{})
+	svc.Events = pub
+	svc.Now = func() time.Time { return now }
+
+	if err := svc.Delete(requestid.WithContext(context.Background(), "req-1"), "exists"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Delete(requestid.WithContext(context.Background(), "req-1"), "missing"); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if len(pub.events) != 1 || pub.events[0].Type != usecase.EventTaskDeleted || !pub.events[0].At.Equal(now) {
+		t.Errorf("expected one %s event, got %+v", usecase.EventTaskDeleted, pub.events)
+	}
+	if !deletedAt.Equal(now) {
+		t.Errorf("expected the repository to get the service clock, got %v", deletedAt)
+	}
+}
+
+func TestService_ErrorCatalog(t *testing.T) {
+	// exists ← child ← grandchild
+	parents := map[string]string{"exists": "", "child": "exists", "grandchild": "child"}
+	repo := &mockRepo{
+		getFn: func(ctx context.Context, id string) (domain.Task, bool, error) {
+			parent, ok := parents[id]
+			return domain.Task{ID: id, Title: "Task", ParentID: parent}, ok, nil
+		},
+		listFn: func(ctx context.Context, f repository.Filter) ([]domain.Task, error) {
+			var out []domain.Task
+			for id, parent := range parents {
+				if len(f.ParentIDs) == 1 && parent == f.ParentIDs[0] {
+					out = append(out, domain.Task{ID: id, ParentID: parent})
+				}
+			}
+			return out, nil
+		},
+	}
+	svc := usecase.NewService(repo, &mockLogger{})
+	empty := ""
+	bad := domain.Status("later")
+	missing := "missing"
+	self := "exists"
+	descendant := "grandchild"
+
+	tests := []struct {
+		name       string
+		call       func() error
+		wantCode   usecase.Code
+		wantIs     error
+		wantFields []string
+	}{
+		{"not found", func() error { _, err := svc.Get(context.Background(), "missing"); return err },
+			usecase.CodeTaskNotFound, usecase.ErrNotFound, nil},
+		{"all invalid fields at once", func() error {
+			_, err := svc.Update(context.Background(), "exists", dto.UpdateInput{Title: &empty, Status: &bad})
+			return err
+		}, usecase.CodeValidationFailed, usecase.ErrBadRequest, []string{"title", "status"}},
+		{"unknown parent", func() error {
+			_, err := svc.Create(context.Background(), dto.CreateInput{Title: "T", ParentID: missing})
+			return err
+		}, usecase.CodeValidationFailed, usecase.ErrBadRequest, []string{"parent_id"}},
+		{"own parent", func() error {
+			_, err := svc.Update(context.Background(), "exists", dto.UpdateInput{ParentID: &self})
+			return err
+		}, usecase.CodeValidationFailed, usecase.ErrBadRequest, []string{"parent_id"}},
+		{"cycle through a descendant", func() error {
+			_, err := svc.Update(context.Background(), "exists", dto.UpdateInput{ParentID: &descendant})
+			return err
+		}, usecase.CodeValidationFailed, usecase.ErrBadRequest, []string{"parent_id"}},
+		{"delete with subtasks", func() error { return svc.Delete(context.Background(), "child") },
+			usecase.CodeTaskHasSubtasks, usecase.ErrBadRequest, nil},
+		{"plain error", func() error { return errors.New("disk full") }, usecase.CodeInternal, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if got := usecase.CodeOf(err); got != tt.wantCode {
+				t.Fatalf("expected code %s, got %s (%v)", tt.wantCode, got, err)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("expected errors.Is(%v, %v)", err, tt.wantIs)
+			}
+			var e *usecase.Error
+			if tt.wantFields == nil {
+				return
+			}
+			if !errors.As(err, &e) || len(e.Fields) != len(tt.wantFields) {
+				t.Fatalf("expected fields %v, got %v", tt.wantFields, err)
+			}
+			for i, f := range tt.wantFields {
+				if e.Fields[i].Field != f || e.Fields[i].Code == "" || e.Fields[i].Message == "" {
+					t.Errorf("unexpected field error %+v", e.Fields[i])
+				}
+			}
+		})
+	}
+
+	for _, info := range usecase.Catalog() {
+		if info.Title == "" || info.Code.Title() != info.Title {
+			t.Errorf("catalog entry %s has no title", info.Code)
+		}
+	}
+}
+
+type spanRecorder struct{ names []string }
+
+func (r *spanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	for _, s := range spans {
+		r.names = append(r.names, s.Name)
+	}
+	return nil
+}
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+// Каждый метод сервиса, включая не пишущие в журнал, открывает спан.
+func TestService_Spans(t *testing.T) {
+	rec := &spanRecorder{}
+	tr := tracing.New(rec, tracing.Options{SampleRatio: 1})
+	svc := usecase.NewService(&mockRepo{
+		listCommentsFn: func(context.Context, []string) ([]domain.Comment, error) { return nil, nil },
+	}, &mockLogger{})
+	svc.Tracer = tr
+
+	ctx := context.Background()
+	if _, err := svc.Revision(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ListComments(ctx, []string{"1"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = tr.Shutdown(ctx)
+
+	want := []string{"usecase.Revision", "usecase.ListComments"}
+	if !slices.Equal(rec.names, want) {
+		t.Errorf("expected spans %v, got %v", want, rec.names)
+	}
+}
@@ -0,0 +1,339 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"taskapi/internal/logger"
+	"taskapi/internal/requestid"
+	"taskapi/internal/tracing"
+	"taskapi/internal/usecase"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+const (
+	EventWebhookDelivered = "webhook_delivered"
+	EventWebhookFailed    = "webhook_failed"
+	EventWebhookDisabled  = "webhook_disabled"
+	EventWebhookDropped   = "webhook_dropped"
+)
+
+type Logger interface {
+	Log(logger.Entry)
+}
+
+type Options struct {
+	Workers      int
+	QueueSize    int
+	MaxAttempts  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Timeout      time.Duration
+	DisableAfter int
+	StopTimeout  time.Duration
+	// AllowLoopback разрешает доставку на loopback и link-local адреса —
+	// только для локальной разработки и тестов.
+	AllowLoopback bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 256
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = 500 * time.Millisecond
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 30 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.StopTimeout <= 0 {
+		o.StopTimeout = 10 * time.Second
+	}
+	return o
+}
+
+type Payload struct {
+	ID        string            `json:"id"`
+	Event     string            `json:"event"`
+	At        time.Time         `json:"at"`
+	RequestID string            `json:"request_id,omitempty"`
+	Data      usecase.TaskEvent `json:"data"`
+}
+
+type job struct {
+	sub     Subscription
+	payload Payload
+	// trace — контекст запроса, породившего событие; уходит подписчику в
+	// traceparent.
+	trace tracing.SpanContext
+}
+
+// Dispatcher доставляет события задач подписчикам в фоне:
+// подписывает тело HMAC-SHA256, повторяет неудачные попытки
+// с экспоненциальной задержкой и выключает стабильно падающие адреса.
+type Dispatcher struct {
+	store  *Store
+	log    Logger
+	client *http.Client
+	opts   Options
+	queue  chan job
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	stopMu  sync.RWMutex
+	stopped bool
+	dropped atomic.Uint64
+}
+
+func NewDispatcher(store *Store, log Logger, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &http.Client{Timeout: opts.Timeout}
+	if !opts.AllowLoopback {
+		client.Transport = guardedTransport()
+	}
+	d := &Dispatcher{
+		store:  store,
+		log:    log,
+		client: client,
+		opts:   opts,
+		queue:  make(chan job, opts.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < opts.Workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for j := range d.queue {
+				d.deliver(j)
+			}
+		}()
+	}
+	return d
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, ev usecase.TaskEvent) {
+	d.stopMu.RLock()
+	defer d.stopMu.RUnlock()
+	if d.stopped {
+		return
+	}
+	for _, sub := range d.store.matching(ev.Type) {
+		j := job{
+			sub: sub,
+			payload: Payload{
+				ID:        newID(),
+				Event:     ev.Type,
+				At:        ev.At,
+				RequestID: ev.RequestID,
+				Data:      ev,
+			},
+			trace: tracing.SpanContextFromContext(ctx),
+		}
+		select {
+		case d.queue <- j:
+		default:
+			d.dropped.Add(1)
+			d.log.Log(tracing.Annotate(ctx, logger.Entry{
+				Time:      time.Now().UTC(),
+				Level:     logger.LevelWarn,
+				Event:     EventWebhookDropped,
+				RequestID: ev.RequestID,
+				Data:      map[string]any{"webhook_id": sub.ID, "event": ev.Type},
+			}))
+		}
+	}
+}
+
+// Stop перестает принимать события и дает очереди доставиться за
+// StopTimeout; после этого оставшиеся попытки прерываются.
+func (d *Dispatcher) Stop() {
+	d.stopMu.Lock()
+	if d.stopped {
+		d.stopMu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.queue)
+	d.stopMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(d.opts.StopTimeout):
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+func (d *Dispatcher) deliver(j job) {
+	body, err := json.Marshal(j.payload)
+	if err != nil {
+		return
+	}
+	ctx := tracing.ContextWithSpanContext(d.ctx, j.trace)
+	var lastErr string
+	for attempt := 1; attempt <= d.opts.MaxAttempts; attempt++ {
+		if attempt > 1 && !d.sleep(Backoff(attempt-1, d.opts.BaseDelay, d.opts.MaxDelay)) {
+			break
+		}
+		dl, retry := d.attempt(ctx, j, body, attempt)
+		d.store.recordAttempt(j.sub.ID, dl)
+		if dl.Success {
+			d.store.recordResult(j.sub.ID, true, d.opts.DisableAfter)
+			d.log.Log(tracing.Annotate(ctx, logger.Entry{
+				Time:      time.Now().UTC(),
+				Event:     EventWebhookDelivered,
+				RequestID: j.payload.RequestID,
+				Data:      map[string]any{"webhook_id": j.sub.ID, "delivery_id": j.payload.ID, "attempt": attempt},
+			}))
+			return
+		}
+		lastErr = dl.Error
+		if !retry {
+			break
+		}
+	}
+
+	d.log.Log(tracing.Annotate(ctx, logger.Entry{
+		Time:      time.Now().UTC(),
+		Level:     logger.LevelWarn,
+		Event:     EventWebhookFailed,
+		RequestID: j.payload.RequestID,
+		Data:      map[string]any{"webhook_id": j.sub.ID, "delivery_id": j.payload.ID},
+		Error:     lastErr,
+	}))
+	if d.store.recordResult(j.sub.ID, false, d.opts.DisableAfter) {
+		d.log.Log(logger.Entry{
+			Time:  time.Now().UTC(),
+			Level: logger.LevelWarn,
+			Event: EventWebhookDisabled,
+			Data:  map[string]any{"webhook_id": j.sub.ID, "url": j.sub.URL},
+		})
+	}
+}
+
+// Check сообщает, что диспетчер остановлен. Заполненная очередь — это
+// нагрузка, а не неисправность: API без вебхуков продолжает работать,
+// поэтому очередь видна только в метриках.
+func (d *Dispatcher) Check(context.Context) error {
+	d.stopMu.RLock()
+	defer d.stopMu.RUnlock()
+	if d.stopped {
+		return errors.New("dispatcher stopped")
+	}
+	return nil
+}
+
+// QueueLen и QueueCap — заполненность очереди доставок.
+func (d *Dispatcher) QueueLen() int { return len(d.queue) }
+func (d *Dispatcher) QueueCap() int { return cap(d.queue) }
+
+// Dropped — события, не попавшие в заполненную очередь.
+func (d *Dispatcher) Dropped() uint64 { return d.dropped.Load() }
+
+func (d *Dispatcher) attempt(ctx context.Context, j job, body []byte, attempt int) (dl Delivery, retry bool) {
+	start := time.Now()
+	dl = Delivery{ID: j.payload.ID, Event: j.payload.Event, Attempt: attempt, At: start.UTC()}
+	defer func() { dl.Took = time.Since(start).String() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.sub.URL, bytes.NewReader(body))
+	if err != nil {
+		dl.Error = err.Error()
+		return dl, false
+	}
+	ts := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, j.payload.Event)
+	req.Header.Set(HeaderDelivery, j.payload.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(j.sub.Secret, ts, body))
+	if j.payload.RequestID != "" {
+		req.Header.Set(requestid.Header, j.payload.RequestID)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		dl.Error = err.Error()
+		return dl, retryable(dl, err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+	dl.StatusCode = resp.StatusCode
+	dl.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !dl.Success {
+		dl.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return dl, !dl.Success && retryable(dl, nil)
+}
+
+func (d *Dispatcher) sleep(delay time.Duration) bool {
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-d.ctx.Done():
+		return false
+	}
+}
+
+// Sign считает подпись "sha256=<hex>" от строки "<timestamp>.<body>".
+// Получатель должен пересчитать ее тем же секретом и сравнить.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff — экспоненциальная задержка с джиттером: случайное
+// значение в [base*2^(retry-1)/2, base*2^(retry-1)], но не больше max.
+func Backoff(retry int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < retry && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
@@ -0,0 +1,251 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"taskapi/internal/dto"
+	"taskapi/internal/usecase"
+)
+
+var ErrInvalid = errors.New("INVALID WEBHOOK")
+
+const maxDeliveries = 100
+
+type Subscription struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"secret,omitempty"`
+	Events         []string  `json:"events"`
+	Active         bool      `json:"active"`
+	Failures       int       `json:"consecutive_failures"`
+	DisabledReason string    `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (s Subscription) matches(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type Delivery struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	At         time.Time `json:"at"`
+	Took       string    `json:"took"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Store хранит подписки и историю попыток доставки в памяти.
+type Store struct {
+	mu         sync.RWMutex
+	subs       map[string]Subscription
+	deliveries map[string][]Delivery
+	Now        func() time.Time
+	// AllowLoopback разрешает подписки на loopback и link-local адреса.
+	AllowLoopback bool
+}
+
+func NewStore() *Store {
+	return &Store{
+		subs:       make(map[string]Subscription),
+		deliveries: make(map[string][]Delivery),
+		Now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Store) Create(in dto.WebhookInput) (Subscription, error) {
+	if err := s.validate(in); err != nil {
+		return Subscription{}, err
+	}
+	now := s.Now()
+	sub := Subscription{
+		ID:        newID(),
+		URL:       in.URL,
+		Secret:    in.Secret,
+		Events:    in.Events,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if sub.Secret == "" {
+		sub.Secret = newID() + newID()
+	}
+	if in.Active != nil {
+		sub.Active = *in.Active
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[sub.ID] = sub
+	return sub, nil
+}
+
+func (s *Store) Get(id string) (Subscription, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.subs[id]
+	return hideSecret(sub), ok
+}
+
+func (s *Store) List() []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		out = append(out, hideSecret(sub))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Update заменяет URL, фильтр событий и секрет (если передан).
+// Включение подписки обратно сбрасывает счетчик ошибок.
+func (s *Store) Update(id string, in dto.WebhookInput) (Subscription, bool, error) {
+	if err := s.validate(in); err != nil {
+		return Subscription{}, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return Subscription{}, false, nil
+	}
+	sub.URL = in.URL
+	sub.Events = in.Events
+	if in.Secret != "" {
+		sub.Secret = in.Secret
+	}
+	if in.Active != nil {
+		if *in.Active && !sub.Active {
+			sub.Failures = 0
+			sub.DisabledReason = ""
+		}
+		sub.Active = *in.Active
+	}
+	sub.UpdatedAt = s.Now()
+	s.subs[id] = sub
+	return hideSecret(sub), true, nil
+}
+
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[id]; !ok {
+		return false
+	}
+	delete(s.subs, id)
+	delete(s.deliveries, id)
+	return true
+}
+
+// Deliveries возвращает попытки доставки, новые первыми.
+func (s *Store) Deliveries(id string) ([]Delivery, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.subs[id]; !ok {
+		return nil, false
+	}
+	src := s.deliveries[id]
+	out := make([]Delivery, 0, len(src))
+	for i := len(src) - 1; i >= 0; i-- {
+		out = append(out, src[i])
+	}
+	return out, true
+}
+
+func (s *Store) matching(event string) []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Subscription
+	for _, sub := range s.subs {
+		if sub.Active && sub.matches(event) {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+func (s *Store) recordAttempt(subID string, d Delivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[subID]; !ok {
+		return
+	}
+	list := append(s.deliveries[subID], d)
+	if len(list) > maxDeliveries {
+		list = list[len(list)-maxDeliveries:]
+	}
+	s.deliveries[subID] = list
+}
+
+// recordResult обновляет счетчик подряд неудачных доставок и выключает
+// подписку, когда он достигает disableAfter. Возвращает true, если
+// подписка была выключена этим вызовом.
+func (s *Store) recordResult(subID string, success bool, disableAfter int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[subID]
+	if !ok {
+		return false
+	}
+	if success {
+		sub.Failures = 0
+		s.subs[subID] = sub
+		return false
+	}
+	sub.Failures++
+	disabled := false
+	if disableAfter > 0 && sub.Failures >= disableAfter && sub.Active {
+		sub.Active = false
+		sub.DisabledReason = fmt.Sprintf("%d consecutive failed deliveries", sub.Failures)
+		sub.UpdatedAt = s.Now()
+		disabled = true
+	}
+	s.subs[subID] = sub
+	return disabled
+}
+
+func (s *Store) validate(in dto.WebhookInput) error {
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalid)
+	}
+	if !s.AllowLoopback && checkHost(u.Hostname()) != nil {
+		return fmt.Errorf("%w: url must not point to a loopback or link-local address", ErrInvalid)
+	}
+	for _, e := range in.Events {
+		if !usecase.IsTaskEventType(e) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalid, e)
+		}
+	}
+	return nil
+}
+
+func hideSecret(s Subscription) Subscription {
+	s.Secret = ""
+	return s
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
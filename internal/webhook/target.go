@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget — адрес подписки указывает на сам сервис или его
+// окружение: loopback, link-local (в том числе метаданные облака
+// 169.254.169.254) или неуказанный адрес.
+var ErrForbiddenTarget = errors.New("webhook target address is not allowed")
+
+func forbiddenAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// checkHost отклоняет запрещенный адрес, записанный в URL явно. Имена
+// проверяются уже при соединении: DNS может ответить иначе, чем при
+// создании подписки.
+func checkHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenTarget
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && forbiddenAddr(ip) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// guardedTransport проверяет каждый адрес, к которому подключается
+// доставка, включая переходы по редиректам. Прокси из окружения не
+// используется: через него проверка адреса назначения была бы невозможна.
+func guardedTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if forbiddenAddr(ap.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenTarget, ap.Addr())
+			}
+			return nil
+		},
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return t
+}
+
+// retryable — стоит ли повторять попытку: сетевые ошибки, 408, 429 и 5xx.
+// Остальные ответы получатель вернет и на повтор.
+func retryable(dl Delivery, err error) bool {
+	switch {
+	case errors.Is(err, ErrForbiddenTarget):
+		return false
+	case dl.StatusCode == 0:
+		return true
+	case dl.StatusCode == http.StatusRequestTimeout, dl.StatusCode == http.StatusTooManyRequests:
+		return true
+	default:
+		return dl.StatusCode >= 500
+	}
+}
@@ -0,0 +1,229 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"taskapi/internal/domain"
+	"taskapi/internal/dto"
+	"taskapi/internal/logger"
+	"taskapi/internal/usecase"
+	"taskapi/internal/webhook"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(logger.Entry) {}
+
+func TestStore_CreateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      dto.WebhookInput
+		wantErr bool
+	}{
+		{"valid", dto.WebhookInput{URL: "https://example.com/hook"}, false},
+		{"valid with filter", dto.WebhookInput{URL: "http://example.com", Events: []string{usecase.EventTaskCreated}}, false},
+		{"relative url", dto.WebhookInput{URL: "/hook"}, true},
+		{"bad scheme", dto.WebhookInput{URL: "ftp://example.com"}, true},
+		{"unknown event", dto.WebhookInput{URL: "https://example.com", Events: []string{"nope"}}, true},
+		{"loopback", dto.WebhookInput{URL: "http://127.0.0.1:8080/hook"}, true},
+		{"localhost", dto.WebhookInput{URL: "http://LocalHost./hook"}, true},
+		{"ipv6 loopback", dto.WebhookInput{URL: "http://[::1]/hook"}, true},
+		{"cloud metadata", dto.WebhookInput{URL: "http://169.254.169.254/latest/meta-data"}, true},
+		{"unspecified", dto.WebhookInput{URL: "http://0.0.0.0/"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := webhook.NewStore().Create(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, webhook.ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	got := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := webhook.Sign("secret", r.Header.Get(webhook.HeaderTimestamp), body)
+		got <- r.Header.Get(webhook.HeaderSignature) == want && r.Header.Get(webhook.HeaderEvent) == usecase.EventTaskCreated &&
+			r.Header.Get("X-Request-ID") == "req-1"
+	}))
+	defer srv.Close()
+
+	store := webhook.NewStore()
+	store.AllowLoopback = true
+	sub, err := store.Create(dto.WebhookInput{URL: srv.URL, Secret: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := webhook.NewDispatcher(store, nopLogger{}, webhook.Options{AllowLoopback: true})
+	defer d.Stop()
+
+	d.Publish(context.Background(), usecase.TaskEvent{Type: usecase.EventTaskCreated, RequestID: "req-1", Task: domain.Task{ID: "1"}})
+
+	select {
+	case ok := <-got:
+		if !ok {
+			t.Errorf("signature, event or request ID header mismatch")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	d.Stop()
+	list, _ := store.Deliveries(sub.ID)
+	if len(list) != 1 || !list[0].Success {
+		t.Errorf("expected one successful delivery, got %+v", list)
+	}
+}
+
+func TestDispatcher_RetriesAndDisables(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := webhook.NewStore()
+	store.AllowLoopback = true
+	sub, _ := store.Create(dto.WebhookInput{URL: srv.URL})
+	d := webhook.NewDispatcher(store, nopLogger{}, webhook.Options{
+		Workers:       1,
+		MaxAttempts:   3,
+		BaseDelay:     time.Millisecond,
+		MaxDelay:      5 * time.Millisecond,
+		DisableAfter:  2,
+		AllowLoopback: true,
+	})
+
+	ev := usecase.TaskEvent{Type: usecase.EventTaskCreated}
+	d.Publish(context.Background(), ev)
+	d.Publish(context.Background(), ev)
+	d.Stop()
+
+	if calls.Load() != 6 {
+		t.Errorf("expected 6 attempts, got %d", calls.Load())
+	}
+	got, _ := store.Get(sub.ID)
+	if got.Active {
+		t.Errorf("expected subscription to be disabled after repeated failures")
+	}
+	list, _ := store.Deliveries(sub.ID)
+	if len(list) != 6 || list[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("unexpected deliveries: %+v", list)
+	}
+}
+
+func TestDispatcher_QueueFull(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+
+	store := webhook.NewStore()
+	store.AllowLoopback = true
+	_, _ = store.Create(dto.WebhookInput{URL: srv.URL})
+	d := webhook.NewDispatcher(store, nopLogger{}, webhook.Options{Workers: 1, QueueSize: 1, AllowLoopback: true})
+	ev := usecase.TaskEvent{Type: usecase.EventTaskCreated}
+
+	d.Publish(context.Background(), ev)
+	<-started // единственный воркер занят
+	d.Publish(context.Background(), ev)
+	d.Publish(context.Background(), ev)
+
+	if d.QueueLen() != 1 || d.QueueCap() != 1 || d.Dropped() != 1 {
+		t.Errorf("expected 1/1 queued and 1 dropped, got %d/%d and %d", d.QueueLen(), d.QueueCap(), d.Dropped())
+	}
+	if err := d.Check(context.Background()); err != nil {
+		t.Errorf("a full queue must not fail the check: %v", err)
+	}
+	close(release)
+	d.Stop()
+	if err := d.Check(context.Background()); err == nil {
+		t.Error("expected the check to fail after Stop")
+	}
+}
+
+func TestDispatcher_RetriesOnlyTransientFailures(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int32
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusGone, 1},
+		{http.StatusRequestTimeout, 3},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusBadGateway, 3},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			store := webhook.NewStore()
+			store.AllowLoopback = true
+			_, _ = store.Create(dto.WebhookInput{URL: srv.URL})
+			d := webhook.NewDispatcher(store, nopLogger{}, webhook.Options{
+				MaxAttempts:   3,
+				BaseDelay:     time.Millisecond,
+				AllowLoopback: true,
+			})
+			d.Publish(context.Background(), usecase.TaskEvent{Type: usecase.EventTaskCreated})
+			d.Stop()
+			if calls.Load() != tt.want {
+				t.Errorf("expected %d attempts, got %d", tt.want, calls.Load())
+			}
+		})
+	}
+}
+
+func TestDispatcher_RefusesLoopbackAtDial(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	// Подписка уже есть (например, имя разрешалось иначе), но соединение
+	// с loopback доставка все равно не откроет и не будет повторять.
+	store := webhook.NewStore()
+	store.AllowLoopback = true
+	sub, _ := store.Create(dto.WebhookInput{URL: srv.URL})
+
+	d := webhook.NewDispatcher(store, nopLogger{}, webhook.Options{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	d.Publish(context.Background(), usecase.TaskEvent{Type: usecase.EventTaskCreated})
+	d.Stop()
+
+	list, _ := store.Deliveries(sub.ID)
+	if calls.Load() != 0 || len(list) != 1 || !strings.Contains(list[0].Error, webhook.ErrForbiddenTarget.Error()) {
+		t.Errorf("expected one refused attempt, got %d calls and %+v", calls.Load(), list)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for retry := 1; retry <= 10; retry++ {
+		d := webhook.Backoff(retry, 100*time.Millisecond, time.Second)
+		if d < 50*time.Millisecond || d > time.Second {
+			t.Errorf("retry %d: backoff %v out of range", retry, d)
+		}
+	}
+}
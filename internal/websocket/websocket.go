@@ -0,0 +1,456 @@
+// Package websocket — минимальная серверная реализация RFC 6455
+// без расширений: рукопожатие, фрейминг, фрагментация, ping/pong и close.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// CloseError возвращается из ReadMessage, когда соединение закрыто
+// пиром или из-за нарушения протокола.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+type Options struct {
+	// MaxMessageSize — предел размера собранного сообщения в байтах.
+	MaxMessageSize int64
+	// CheckOrigin; nil — разрешены все источники, поэтому серверам с
+	// браузерными клиентами нужен хотя бы SameOrigin.
+	CheckOrigin func(r *http.Request) bool
+	// Error пишет ответ на неудачное рукопожатие; nil — http.Error.
+	Error func(w http.ResponseWriter, r *http.Request, status int, reason string)
+}
+
+type Conn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	maxSize int64
+	client  bool
+
+	writeMu sync.Mutex
+	closed  bool
+
+	// PongHandler вызывается при получении pong; по умолчанию ничего не делает.
+	PongHandler func(data []byte)
+}
+
+// SameOrigin разрешает рукопожатия без Origin (не из браузера) и со
+// страниц того же хоста. Без этой проверки любая страница могла бы открыть
+// соединение от имени посетителя (cross-site WebSocket hijacking).
+func SameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Upgrade проверяет рукопожатие и забирает соединение у net/http.
+// При ошибке ответ клиенту уже записан.
+func Upgrade(w http.ResponseWriter, r *http.Request, opts Options) (*Conn, error) {
+	fail := func(status int, msg string) (*Conn, error) {
+		if opts.Error != nil {
+			opts.Error(w, r, status, msg)
+		} else {
+			http.Error(w, msg, status)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrBadHandshake, msg)
+	}
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "method not allowed")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	if opts.CheckOrigin != nil && !opts.CheckOrigin(r) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "hijack not supported")
+	}
+	// Снимаем дедлайны, выставленные http.Server.
+	_ = netConn.SetDeadline(time.Time{})
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(resp); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	maxSize := opts.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = 1 << 20
+	}
+	return &Conn{conn: netConn, br: rw.Reader, maxSize: maxSize}, nil
+}
+
+// Dial открывает клиентское соединение по ws:// URL (без TLS);
+// используется в тестах и внутренних утилитах.
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyBytes [16]byte
+	_, _ = rand.Read(keyBytes[:])
+	key := base64.StdEncoding.EncodeToString(keyBytes[:])
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Header:     http.Header{},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(netConn); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != AcceptKey(key) {
+		_ = netConn.Close()
+		return nil, fmt.Errorf("%w: status %d", ErrBadHandshake, resp.StatusCode)
+	}
+	return &Conn{conn: netConn, br: br, maxSize: 1 << 20, client: true}, nil
+}
+
+func AcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+func (c *Conn) readFrame(limit int64) (frame, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		return frame{}, err
+	}
+	f := frame{fin: hdr[0]&0x80 != 0, opcode: hdr[0] & 0x0F}
+	if hdr[0]&0x70 != 0 {
+		return f, &CloseError{Code: CloseProtocolError, Reason: "reserved bits set"}
+	}
+	masked := hdr[1]&0x80 != 0
+	if masked == c.client {
+		return f, &CloseError{Code: CloseProtocolError, Reason: "invalid frame masking"}
+	}
+	length := int64(hdr[1] & 0x7F)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return f, err
+		}
+		length = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return f, err
+		}
+		u := binary.BigEndian.Uint64(b[:])
+		if u > 1<<62 {
+			return f, &CloseError{Code: CloseMessageTooBig, Reason: "message too big"}
+		}
+		length = int64(u)
+	}
+	if f.opcode >= OpClose {
+		if !f.fin || length > 125 {
+			return f, &CloseError{Code: CloseProtocolError, Reason: "invalid control frame"}
+		}
+	} else if length > limit {
+		return f, &CloseError{Code: CloseMessageTooBig, Reason: "message too big"}
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return f, err
+		}
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		maskBytes(mask, f.payload)
+	}
+	return f, nil
+}
+
+// ReadMessage возвращает следующее текстовое или бинарное сообщение,
+// собирая фрагменты. Ping отвечается автоматически, pong передается в
+// PongHandler. При ошибке протокола соединению отправляется close с
+// соответствующим кодом, и возвращается *CloseError.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	op, data, err := c.readMessage()
+	if err != nil {
+		var ce *CloseError
+		if errors.As(err, &ce) {
+			code := ce.Code
+			if code == CloseNoStatus {
+				code = CloseNormal
+			}
+			_ = c.WriteClose(code, ce.Reason)
+		}
+		return 0, nil, err
+	}
+	return op, data, nil
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	var (
+		op  byte
+		buf []byte
+	)
+	for {
+		f, err := c.readFrame(c.maxSize - int64(len(buf)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch f.opcode {
+		case OpPing:
+			if err := c.writeFrame(OpPong, f.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			if c.PongHandler != nil {
+				c.PongHandler(f.payload)
+			}
+			continue
+		case OpClose:
+			return 0, nil, parseClose(f.payload)
+		case OpText, OpBinary:
+			if op != 0 {
+				return 0, nil, &CloseError{Code: CloseProtocolError, Reason: "expected continuation frame"}
+			}
+			op = f.opcode
+		case OpContinuation:
+			if op == 0 {
+				return 0, nil, &CloseError{Code: CloseProtocolError, Reason: "unexpected continuation frame"}
+			}
+		default:
+			return 0, nil, &CloseError{Code: CloseProtocolError, Reason: "unknown opcode"}
+		}
+		buf = append(buf, f.payload...)
+		if !f.fin {
+			continue
+		}
+		if op == OpText && !utf8.Valid(buf) {
+			return 0, nil, &CloseError{Code: CloseInvalidPayload, Reason: "invalid utf-8"}
+		}
+		return int(op), buf, nil
+	}
+}
+
+func parseClose(payload []byte) error {
+	if len(payload) == 0 {
+		return &CloseError{Code: CloseNoStatus}
+	}
+	if len(payload) == 1 {
+		return &CloseError{Code: CloseProtocolError, Reason: "invalid close payload"}
+	}
+	code := int(binary.BigEndian.Uint16(payload[:2]))
+	reason := payload[2:]
+	if !validCloseCode(code) || !utf8.Valid(reason) {
+		return &CloseError{Code: CloseProtocolError, Reason: "invalid close payload"}
+	}
+	// Пир инициировал закрытие — отвечаем тем же кодом.
+	return &CloseError{Code: code, Reason: string(reason)}
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+func (c *Conn) WriteMessage(op int, data []byte) error {
+	if op != OpText && op != OpBinary {
+		return errors.New("websocket: invalid message opcode")
+	}
+	return c.writeFrame(byte(op), data)
+}
+
+func (c *Conn) WritePing(data []byte) error {
+	return c.writeFrame(OpPing, data)
+}
+
+// WriteClose отправляет close-фрейм; после него запись запрещена.
+func (c *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	err := c.writeFrame(OpClose, payload)
+
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+	return err
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		buf = append(buf, mask[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		maskBytes(mask, buf[start:])
+	} else {
+		buf = append(buf, payload...)
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,124 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"taskapi/internal/websocket"
+)
+
+func echoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, websocket.Options{MaxMessageSize: 1024})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			op, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(op, msg); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestAcceptKey(t *testing.T) {
+	// Пример из RFC 6455, раздел 1.3.
+	if got := websocket.AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected accept key %q", got)
+	}
+}
+
+func TestUpgrade_RejectsPlainRequests(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+	}{
+		{"no upgrade headers", nil, http.StatusBadRequest},
+		{"wrong version", map[string]string{
+			"Connection": "Upgrade", "Upgrade": "websocket",
+			"Sec-WebSocket-Version": "8", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ==",
+		}, http.StatusUpgradeRequired},
+		{"bad key", map[string]string{
+			"Connection": "keep-alive, Upgrade", "Upgrade": "websocket",
+			"Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "short",
+		}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("expected code %d, got %d", tt.wantCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestConn_EchoAndLimits(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+
+	conn, err := websocket.Dial(context.Background(), wsURL(srv), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	long := strings.Repeat("x", 300)
+	for _, msg := range []string{"hello", long} {
+		if err := conn.WriteMessage(websocket.OpText, []byte(msg)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		op, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if op != websocket.OpText || string(got) != msg {
+			t.Errorf("expected echo of %d bytes, got op=%d len=%d", len(msg), op, len(got))
+		}
+	}
+
+	if err := conn.WritePing([]byte("p")); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	pong := make(chan string, 1)
+	conn.PongHandler = func(b []byte) { pong <- string(b) }
+
+	if err := conn.WriteMessage(websocket.OpBinary, make([]byte, 2048)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != websocket.CloseMessageTooBig {
+		t.Errorf("expected close %d, got %v", websocket.CloseMessageTooBig, err)
+	}
+	if got := <-pong; got != "p" {
+		t.Errorf("expected pong payload %q, got %q", "p", got)
+	}
+}
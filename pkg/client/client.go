@@ -0,0 +1,353 @@
+// Package client — типизированный Go-клиент TaskAPI.
+//
+//	c, err := client.New("http://localhost:8080")
+//	task, err := c.CreateTask(ctx, client.CreateTaskInput{Title: "Write docs"})
+//	if errors.Is(err, client.ErrBadRequest) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy — повторы идемпотентных запросов (GET, PUT, DELETE) при
+// сетевых ошибках и ответах 429/502/503/504. POST и PATCH не повторяются.
+type RetryPolicy struct {
+	// MaxAttempts — всего попыток, включая первую; 1 отключает повторы.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+type Client struct {
+	base      *url.URL
+	http      *http.Client
+	retry     RetryPolicy
+	userAgent string
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New создает клиент для сервиса по адресу baseURL (например, http://localhost:8080).
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: invalid base URL %q: scheme must be http or https", baseURL)
+	}
+	c := &Client{
+		base:      u,
+		http:      &http.Client{Timeout: 30 * time.Second},
+		retry:     DefaultRetryPolicy,
+		userAgent: "taskapi-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+	return c, nil
+}
+
+// Meta — сведения о последнем обмене с сервисом.
+type Meta struct {
+	RequestID  string
+	StatusCode int
+	Attempts   int
+}
+
+type metaKey struct{}
+
+// WithMeta возвращает контекст, в котором клиент заполнит m после вызова:
+//
+//	var m client.Meta
+//	task, err := c.GetTask(client.WithMeta(ctx, &m), id)
+//	log.Println(m.RequestID)
+func WithMeta(ctx context.Context, m *Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, m)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID передает id сервису в заголовке X-Request-ID, чтобы записи
+// журнала сервиса и вызывающего можно было связать. Повторы запроса идут с
+// тем же id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func (c *Client) CreateTask(ctx context.Context, in CreateTaskInput) (Task, error) {
+	var t Task
+	err := c.do(ctx, http.MethodPost, "/tasks", nil, in, &t)
+	return t, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id string) (Task, error) {
+	var t Task
+	err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(id), nil, nil, &t)
+	return t, err
+}
+
+func (c *Client) ListTasks(ctx context.Context, opts ListOptions) ([]Task, error) {
+	q := url.Values{}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("status", string(opts.Status))
+	set("project", opts.Project)
+	set("assignee", opts.Assignee)
+	set("parent_id", opts.ParentID)
+	var list []Task
+	err := c.do(ctx, http.MethodGet, "/tasks", q, nil, &list)
+	return list, err
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id string, in UpdateTaskInput) (Task, error) {
+	var t Task
+	err := c.do(ctx, http.MethodPatch, "/tasks/"+url.PathEscape(id), nil, in, &t)
+	return t, err
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, in WebhookInput) (Webhook, error) {
+	var w Webhook
+	err := c.do(ctx, http.MethodPost, "/webhooks", nil, in, &w)
+	return w, err
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id string) (Webhook, error) {
+	var w Webhook
+	err := c.do(ctx, http.MethodGet, "/webhooks/"+url.PathEscape(id), nil, nil, &w)
+	return w, err
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var list []Webhook
+	err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, &list)
+	return list, err
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, id string, in WebhookInput) (Webhook, error) {
+	var w Webhook
+	err := c.do(ctx, http.MethodPut, "/webhooks/"+url.PathEscape(id), nil, in, &w)
+	return w, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) WebhookDeliveries(ctx context.Context, id string) ([]Delivery, error) {
+	var list []Delivery
+	err := c.do(ctx, http.MethodGet, "/webhooks/"+url.PathEscape(id)+"/deliveries", nil, nil, &list)
+	return list, err
+}
+
+// Health возвращает nil, если сервис отвечает на GET /health.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil, nil)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do выполняет запрос с повторами и декодирует JSON-ответ в out.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+	target := c.base.String() + path
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+
+	attempts := 1
+	if idempotent(method) {
+		attempts = c.retry.MaxAttempts
+	}
+	meta, _ := ctx.Value(metaKey{}).(*Meta)
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if meta != nil {
+			meta.Attempts = attempt
+		}
+		resp, err := c.send(ctx, method, target, body)
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return err
+			}
+			lastErr = err
+		case retryableStatus(resp.StatusCode) && attempt < attempts:
+			wait = retryAfter(resp.Header)
+			lastErr = readError(resp)
+		default:
+			if meta != nil {
+				meta.RequestID = resp.Header.Get("X-Request-ID")
+				meta.StatusCode = resp.StatusCode
+			}
+			return decode(resp, out)
+		}
+		if attempt == attempts {
+			break
+		}
+		if wait == 0 {
+			wait = backoff(attempt, c.retry.BaseDelay, c.retry.MaxDelay)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if id, _ := ctx.Value(requestIDKey{}).(string); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	return c.http.Do(req)
+}
+
+func decode(resp *http.Response, out any) error {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= 400 {
+		return readError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode response: %w", err)
+	}
+	return nil
+}
+
+// readError разбирает тело ошибки application/problem+json; прежний формат
+// {"error": "...", "details": [...]} тоже понимается, не-JSON тело попадает
+// в Message как есть.
+func readError(resp *http.Response) error {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Title   string       `json:"title"`
+		Detail  string       `json:"detail"`
+		Code    string       `json:"code"`
+		Errors  []FieldError `json:"errors"`
+		Error   string       `json:"error"`
+		Details []string     `json:"details"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil || body.Code == "" && body.Error == "" {
+		apiErr.Message = strings.TrimSpace(string(raw))
+		return apiErr
+	}
+	apiErr.Code = body.Code
+	apiErr.Fields = body.Errors
+	apiErr.Details = body.Details
+	for _, f := range body.Errors {
+		apiErr.Details = append(apiErr.Details, f.Field+": "+f.Message)
+	}
+	switch {
+	case body.Detail != "":
+		apiErr.Message = body.Detail
+	case body.Title != "":
+		apiErr.Message = body.Title
+	default:
+		apiErr.Message = body.Error
+	}
+	return apiErr
+}
+
+func retryAfter(h http.Header) time.Duration {
+	if s, err := strconv.Atoi(h.Get("Retry-After")); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return 0
+}
+
+// backoff — экспоненциальная задержка с полным джиттером.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base << (attempt - 1)
+	if max > 0 && (d <= 0 || d > max) {
+		d = max
+	}
+	if d <= 0 {
+		d = base
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
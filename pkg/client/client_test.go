@@ -0,0 +1,229 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpHandler "taskapi/internal/handlers/http"
+	"taskapi/internal/logger"
+	"taskapi/internal/repository/memory"
+	"taskapi/internal/usecase"
+	"taskapi/internal/webhook"
+	"taskapi/pkg/client"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(logger.Entry)          {}
+func (nopLogger) Enabled(logger.Level) bool { return true }
+func (nopLogger) Stop()                     {}
+
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) *client.Client {
+	t.Helper()
+	svc := usecase.NewService(memory.New(), nopLogger{})
+	h := httpHandler.NewRouter(svc, nopLogger{}, httpHandler.WithWebhooks(webhook.NewStore())).Handler()
+	if wrap != nil {
+		h = wrap(h)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := client.New(srv.URL, client.WithRetry(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient_Tasks(t *testing.T) {
+	c := newServer(t, nil)
+	ctx := context.Background()
+
+	parent, err := c.CreateTask(ctx, client.CreateTaskInput{Title: "parent", Project: "core"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.ID == "" || parent.Status != client.StatusTodo || parent.Project != "core" {
+		t.Fatalf("unexpected task: %+v", parent)
+	}
+	child, err := c.CreateTask(ctx, client.CreateTaskInput{Title: "child", ParentID: parent.ID, Status: client.StatusInProgress})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m client.Meta
+	got, err := c.GetTask(client.WithMeta(ctx, &m), child.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ParentID != parent.ID {
+		t.Errorf("expected parent %s, got %s", parent.ID, got.ParentID)
+	}
+	if m.RequestID == "" || m.StatusCode != http.StatusOK || m.Attempts != 1 {
+		t.Errorf("unexpected meta: %+v", m)
+	}
+	if _, err := c.GetTask(client.WithRequestID(client.WithMeta(ctx, &m), "caller-42"), child.ID); err != nil || m.RequestID != "caller-42" {
+		t.Errorf("expected the caller's request ID to be honoured, got %q, %v", m.RequestID, err)
+	}
+
+	list, err := c.ListTasks(ctx, client.ListOptions{Status: client.StatusInProgress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != child.ID {
+		t.Errorf("unexpected filtered list: %+v", list)
+	}
+	list, err = c.ListTasks(ctx, client.ListOptions{ParentID: parent.ID})
+	if err != nil || len(list) != 1 {
+		t.Errorf("unexpected subtasks: %+v, %v", list, err)
+	}
+
+	updated, err := c.UpdateTask(ctx, child.ID, client.UpdateTaskInput{Status: client.Ptr(client.StatusDone), ParentID: client.Ptr("")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != client.StatusDone || updated.ParentID != "" || updated.Title != "child" {
+		t.Errorf("unexpected update result: %+v", updated)
+	}
+
+	if err := c.DeleteTask(ctx, child.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetTask(ctx, child.ID)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if client.RequestID(err) == "" {
+		t.Error("expected request ID on error")
+	}
+	if err := c.DeleteTask(ctx, child.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	c := newServer(t, nil)
+	ctx := context.Background()
+
+	_, err := c.CreateTask(ctx, client.CreateTaskInput{})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("expected ErrBadRequest, got %v", err)
+	}
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Details) == 0 ||
+		apiErr.Code != "validation_failed" || len(apiErr.Fields) == 0 || apiErr.Fields[0].Field != "body.title" {
+		t.Errorf("unexpected API error: %+v", apiErr)
+	}
+
+	_, err = c.CreateTask(ctx, client.CreateTaskInput{Title: "orphan", ParentID: "missing"})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("expected ErrBadRequest for unknown parent, got %v", err)
+	}
+	if _, err := c.ListTasks(ctx, client.ListOptions{Status: "later"}); !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("expected ErrBadRequest for unknown status, got %v", err)
+	}
+	if _, err := client.New("localhost:8080"); err == nil {
+		t.Error("expected error for base URL without scheme")
+	}
+}
+
+func TestClient_Webhooks(t *testing.T) {
+	c := newServer(t, nil)
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.WebhookInput{URL: "https://example.com/hook", Events: []string{"task_created"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.Secret == "" || !hook.Active {
+		t.Errorf("unexpected webhook: %+v", hook)
+	}
+	hook, err = c.UpdateWebhook(ctx, hook.ID, client.WebhookInput{URL: "https://example.com/other", Active: client.Ptr(false)})
+	if err != nil || hook.Active || hook.Secret != "" {
+		t.Fatalf("unexpected update: %+v, %v", hook, err)
+	}
+	list, err := c.ListWebhooks(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("unexpected list: %+v, %v", list, err)
+	}
+	deliveries, err := c.WebhookDeliveries(ctx, hook.ID)
+	if err != nil || len(deliveries) != 0 {
+		t.Errorf("unexpected deliveries: %+v, %v", deliveries, err)
+	}
+	if err := c.DeleteWebhook(ctx, hook.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetWebhook(ctx, hook.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// flaky отвечает 503 на первые n запросов.
+func flaky(n int32, calls *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= n {
+				w.Header().Set("X-Request-ID", "flaky")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("idempotent call is retried", func(t *testing.T) {
+		var calls atomic.Int32
+		c := newServer(t, flaky(2, &calls))
+		var m client.Meta
+		if err := c.Health(client.WithMeta(ctx, &m)); err != nil {
+			t.Fatal(err)
+		}
+		if m.Attempts != 3 || calls.Load() != 3 {
+			t.Errorf("expected 3 attempts, got %d (%d calls)", m.Attempts, calls.Load())
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		var calls atomic.Int32
+		c := newServer(t, flaky(10, &calls))
+		_, err := c.ListTasks(ctx, client.ListOptions{})
+		if !errors.Is(err, client.ErrUnavailable) || client.RequestID(err) != "flaky" {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("POST is not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		c := newServer(t, flaky(1, &calls))
+		_, err := c.CreateTask(ctx, client.CreateTaskInput{Title: "once"})
+		if !errors.Is(err, client.ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 call, got %d", calls.Load())
+		}
+	})
+
+	t.Run("context cancels backoff", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(flaky(10, &calls)(http.NotFoundHandler()))
+		defer srv.Close()
+		c, _ := client.New(srv.URL, client.WithRetry(client.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}))
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if err := c.Health(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+}
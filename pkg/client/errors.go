@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Ошибки сервиса; проверяются через errors.Is, как usecase.ErrNotFound и
+// usecase.ErrBadRequest на стороне сервера.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrBadRequest  = errors.New("bad request")
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// APIError — ответ сервиса с кодом 4xx/5xx (application/problem+json).
+type APIError struct {
+	StatusCode int
+	// Code — стабильный код ошибки из каталога сервиса, например
+	// "task_not_found" или "validation_failed".
+	Code    string
+	Message string
+	// Details — нарушения по полям в виде "поле: сообщение".
+	Details []string
+	Fields  []FieldError
+	// RequestID — значение X-Request-ID ответа, по нему запрос ищется в логах сервиса.
+	RequestID string
+}
+
+// FieldError — нарушение в одном поле запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("taskapi: %d %s (request %s)", e.StatusCode, msg, e.RequestID)
+	}
+	return fmt.Sprintf("taskapi: %d %s", e.StatusCode, msg)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == http.StatusBadGateway ||
+			e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// RequestID достает X-Request-ID из ошибки клиента, если ответ был получен.
+func RequestID(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RequestID
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package client
+
+import "time"
+
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in_progress"
+	StatusDone       Status = "done"
+)
+
+type Task struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Status      Status    `json:"status"`
+	Project     string    `json:"project,omitempty"`
+	Assignee    string    `json:"assignee,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateTaskInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Status      Status `json:"status,omitempty"`
+	Project     string `json:"project,omitempty"`
+	Assignee    string `json:"assignee,omitempty"`
+	ParentID    string `json:"parent_id,omitempty"`
+}
+
+// UpdateTaskInput — частичное обновление: nil-поля не отправляются и не
+// меняются. Удобно заполнять через Ptr.
+type UpdateTaskInput struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Status      *Status `json:"status,omitempty"`
+	Project     *string `json:"project,omitempty"`
+	Assignee    *string `json:"assignee,omitempty"`
+	ParentID    *string `json:"parent_id,omitempty"`
+}
+
+// ListOptions — фильтры списка задач; пустые поля не фильтруют.
+type ListOptions struct {
+	Status   Status
+	Project  string
+	Assignee string
+	ParentID string
+}
+
+type Webhook struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"secret,omitempty"`
+	Events         []string  `json:"events"`
+	Active         bool      `json:"active"`
+	Failures       int       `json:"consecutive_failures"`
+	DisabledReason string    `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type WebhookInput struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type Delivery struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	At         time.Time `json:"at"`
+	Took       string    `json:"took"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Ptr возвращает указатель на v — для полей UpdateTaskInput и WebhookInput.
+func Ptr[T any](v T) *T {
+	return &v
+}